@@ -1,13 +1,34 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"item-comparison-api/internal"
+	"item-comparison-api/internal/alerts"
 	"item-comparison-api/internal/api"
+	"item-comparison-api/internal/comparisons"
+	"item-comparison-api/internal/config"
+	"item-comparison-api/internal/cooccurrence"
+	"item-comparison-api/internal/duplicates"
+	"item-comparison-api/internal/events"
+	"item-comparison-api/internal/gql"
+	"item-comparison-api/internal/jobs"
+	"item-comparison-api/internal/metrics"
+	"item-comparison-api/internal/offers"
+	"item-comparison-api/internal/outbox"
 	"item-comparison-api/internal/repository"
+	"item-comparison-api/internal/rpc"
 	"item-comparison-api/internal/services"
+	"item-comparison-api/internal/similarity"
+	"item-comparison-api/internal/tracing"
+	"item-comparison-api/internal/webhooks"
 	"log"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/joho/godotenv"
 )
@@ -18,18 +39,286 @@ func main() {
 		log.Println("No .env file found")
 	}
 
-	storagePath := os.Getenv("STORAGE_PATH")
+	// Resolve defaults, config file, environment and flags
+	cfg, opts, err := config.Load(os.Args[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+	if opts.Print {
+		if err := cfg.Print(os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	// Cancel the root context on SIGINT/SIGTERM to start a graceful shutdown
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing.Exporter, cfg.Tracing.OTLPEndpoint)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Initialize metrics, repository, service and handlers
+	m := metrics.New()
+	storage, err := newStorage(cfg.Storage)
+	if err != nil {
+		log.Fatal(err)
+	}
+	repo := tracing.TraceRepo(metrics.InstrumentRepo(storage, m))
+	broker := events.NewBroker(cfg.Events.LogSize)
+
+	// Learn which products are compared together, over every API. The counts
+	// outlive the signal context so the comparisons of the last requests are saved.
+	tracker, err := cooccurrence.NewTracker(cfg.CooccurrencePath(), cooccurrence.Options{
+		HalfLife:      cfg.Cooccurrence.HalfLife,
+		MinScore:      cfg.Cooccurrence.MinScore,
+		FlushInterval: cfg.Cooccurrence.FlushInterval,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	trackerCtx, stopTracker := context.WithCancel(context.Background())
+	tracker.Start(trackerCtx)
 
-	// Initialize repository, service and handler
-	repo := repository.NewProductRepo(storagePath)
-	service := services.NewProductService(repo)
-	handler := api.NewProductHandler(service)
+	// Seller offers are kept apart from the catalog products, comparisons
+	// show the best offer of each product
+	catalog := tracing.TraceService(services.NewProductService(repo))
+	offerManager, err := offers.NewManager(cfg.OffersDir(), catalog)
+	if err != nil {
+		log.Fatal(err)
+	}
+	offerHandler := api.NewOfferHandler(offerManager)
 
-	// Setup router and start server
-	r := internal.SetupRouter(handler)
-	log.Println("Server running on http://localhost:8080")
-	err := http.ListenAndServe(":8080", r)
+	// Flag likely duplicate products for review, merged products' IDs keep
+	// resolving to the product they were merged into
+	duplicateManager, err := duplicates.NewManager(cfg.DuplicatesDir(), repo, offerManager, duplicates.Options{
+		Threshold: cfg.Duplicates.Threshold,
+		Reviewers: cfg.Duplicates.Reviewers,
+	})
 	if err != nil {
 		log.Fatal(err)
 	}
+	if err := duplicateManager.Load(ctx); err != nil {
+		log.Fatal(err)
+	}
+	duplicateHandler := api.NewDuplicateHandler(duplicateManager)
+
+	service := cooccurrence.RecordService(duplicates.RedirectService(offers.BestOfferService(catalog, offerManager), duplicateManager), tracker)
+	handler := api.NewProductHandler(service, cfg.Limits.MaxCompareIDs)
+	health := api.NewHealthHandler(storage)
+
+	// Resume interrupted imports and start processing new ones
+	importManager, err := jobs.NewManager(cfg.ImportsDir(), service, cfg.Imports.Workers, cfg.Imports.ChunkSize)
+	if err != nil {
+		log.Fatal(err)
+	}
+	importManager.Start(ctx)
+	imports := api.NewImportHandler(importManager)
+	eventStream := api.NewEventsHandler(broker, cfg.Events.Heartbeat)
+
+	// Resume pending webhook deliveries and queue one for every matching event
+	webhookManager, err := webhooks.NewManager(cfg.WebhooksDir(), webhooks.Options{
+		Workers:     cfg.Webhooks.Workers,
+		Timeout:     cfg.Webhooks.Timeout,
+		MaxAttempts: cfg.Webhooks.MaxAttempts,
+		BackoffBase: cfg.Webhooks.BackoffBase,
+		BackoffMax:  cfg.Webhooks.BackoffMax,
+		HistorySize: cfg.Webhooks.HistorySize,
+
+		AllowPrivateTargets: cfg.Webhooks.AllowPrivateTargets,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	broker.OnPublish(webhookManager.Notify)
+	webhookManager.Start(ctx)
+	webhookHandler := api.NewWebhookHandler(webhookManager)
+
+	// Relay the changes recorded by the storage backend. The relay outlives
+	// the signal context so the changes of the last requests still go out.
+	sinks, brokerSinks, err := newSinks(cfg.Outbox, broker)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Index the catalog for similar product recommendations. The scan also
+	// seeds the catalog size gauge.
+	similarityIndex := similarity.NewIndex()
+	if err := similarityIndex.Load(ctx, repo); err != nil {
+		log.Fatal(err)
+	}
+	similar := api.NewSimilarityHandler(service, similarityIndex, cfg.Similarity.DefaultLimit, cfg.Similarity.MaxLimit)
+	compared := api.NewCooccurrenceHandler(service, tracker, cfg.Cooccurrence.DefaultLimit, cfg.Cooccurrence.MaxLimit)
+	sinks = append(sinks, similarityIndex, tracker, offerManager, duplicateManager)
+
+	// Evaluate price drop alerts on the relayed changes and send their notifications
+	alertManager, err := alerts.NewManager(cfg.AlertsDir(), service, map[string]alerts.Notifier{
+		"webhook": alerts.NewWebhookNotifier(cfg.Webhooks.AllowPrivateTargets),
+		"log":     alerts.LogNotifier{},
+	}, alerts.Options{
+		NotifyTimeout: cfg.Alerts.NotifyTimeout,
+		MaxAttempts:   cfg.Alerts.MaxAttempts,
+		RetryBase:     cfg.Alerts.RetryBase,
+		RetryMax:      cfg.Alerts.RetryMax,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	alertManager.Start(ctx)
+	alertHandler := api.NewAlertHandler(alertManager)
+	sinks = append(sinks, alertManager)
+
+	dispatcher := outbox.NewDispatcher(storage, outbox.Options{
+		BatchSize:    cfg.Outbox.BatchSize,
+		PollInterval: cfg.Outbox.PollInterval,
+		RetryBase:    cfg.Outbox.RetryBase,
+		RetryMax:     cfg.Outbox.RetryMax,
+	}, sinks...)
+	dispatchCtx, stopDispatch := context.WithCancel(context.Background())
+	dispatcher.Start(dispatchCtx)
+
+	// Saved comparisons, with anonymous ones removed once they expire
+	comparisonManager, err := comparisons.NewManager(cfg.ComparisonsDir(), service, comparisons.Options{
+		MaxIDs:          cfg.Limits.MaxCompareIDs,
+		MaxNotesLength:  cfg.Comparisons.MaxNotesLength,
+		AnonymousTTL:    cfg.Comparisons.AnonymousTTL,
+		CleanupInterval: cfg.Comparisons.CleanupInterval,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	comparisonManager.Start(ctx)
+	comparisonHandler := api.NewComparisonHandler(comparisonManager)
+
+	graphql, err := gql.NewHandler(service, cfg.Limits.MaxCompareIDs, cfg.GraphQL.MaxDepth, cfg.GraphQL.MaxComplexity)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Setup router
+	r := internal.SetupRouter(cfg, handler, offerHandler, similar, compared, imports, eventStream, webhookHandler, alertHandler, comparisonHandler, duplicateHandler, graphql, health, m)
+
+	// Setup server
+	server := &http.Server{
+		Addr:              cfg.Server.Addr,
+		Handler:           r,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+	}
+	// Shutdown waits for open connections, so end the event streams first
+	server.RegisterOnShutdown(broker.Close)
+
+	// Setup the gRPC server on its own port
+	grpcServer, err := rpc.NewServer(cfg, rpc.NewCatalogServer(service, cfg.Limits.MaxCompareIDs))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	serverErr := make(chan error, 2)
+	if cfg.GRPC.Enabled {
+		listener, err := net.Listen("tcp", cfg.GRPC.Addr)
+		if err != nil {
+			log.Fatal(err)
+		}
+		go func() {
+			log.Printf("gRPC server running on %s", cfg.GRPC.Addr)
+			serverErr <- grpcServer.Serve(listener)
+		}()
+	}
+	go func() {
+		if cfg.TLS.Enabled {
+			log.Printf("Server running on https://%s", cfg.Server.Addr)
+			serverErr <- server.ListenAndServeTLS(cfg.TLS.CertFile, cfg.TLS.KeyFile)
+			return
+		}
+		log.Printf("Server running on http://%s", cfg.Server.Addr)
+		serverErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErr:
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+	case <-ctx.Done():
+		log.Println("Shutdown signal received, draining in-flight requests")
+	}
+
+	// Fail readiness first, then stop accepting connections and wait for
+	// in-flight requests up to the shutdown timeout
+	health.SetDraining()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Server shutdown did not complete: %v", err)
+	}
+	rpc.Shutdown(shutdownCtx, grpcServer)
+	// Import workers stop after their current chunk, the rest resumes on restart
+	importManager.Wait()
+	// No more writes happen, relay what is left
+	stopDispatch()
+	dispatcher.Wait()
+	for _, sink := range brokerSinks {
+		if err := sink.Close(); err != nil {
+			log.Printf("Closing %s failed: %v", sink.Name(), err)
+		}
+	}
+	// Save the co-occurrence counts once no more comparisons or deletions arrive
+	stopTracker()
+	tracker.Wait()
+	// Webhook deliveries of the relayed events are saved, attempts in
+	// progress finish and pending deliveries resume on restart
+	webhookManager.Close()
+	webhookManager.Wait()
+	// Notifications in progress finish, pending ones are sent after a restart
+	alertManager.Wait()
+	comparisonManager.Wait()
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		log.Printf("Tracing shutdown failed: %v", err)
+	}
+	log.Println("Server stopped")
+}
+
+// storageBackend is what every product storage backend provides
+type storageBackend interface {
+	repository.ProductRepo
+	repository.HealthChecker
+	repository.Outbox
+}
+
+// newStorage creates the configured storage backend
+func newStorage(cfg config.StorageConfig) (storageBackend, error) {
+	switch cfg.Backend {
+	case config.BackendJSON:
+		return repository.NewProductRepo(cfg.Path), nil
+	case config.BackendMemory:
+		return repository.NewProductRepoMemory(), nil
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", cfg.Backend)
+	}
+}
+
+// newSinks creates the outbox sinks: the event bus and the configured
+// extras. The broker sinks are returned as well, to be closed on shutdown.
+func newSinks(cfg config.OutboxConfig, bus outbox.Publisher) ([]outbox.Sink, []*outbox.BrokerSink, error) {
+	sinks := []outbox.Sink{outbox.NewBusSink(bus)}
+	if cfg.FilePath != "" {
+		sinks = append(sinks, outbox.NewFileSink(cfg.FilePath))
+	}
+	var brokers []*outbox.BrokerSink
+	if cfg.NATSURL != "" {
+		producer, err := outbox.NewNATSProducer(cfg.NATSURL)
+		if err != nil {
+			return nil, nil, err
+		}
+		brokers = append(brokers, outbox.NewBrokerSink(producer, cfg.NATSSubject))
+	}
+	for _, b := range brokers {
+		sinks = append(sinks, b)
+	}
+	return sinks, brokers, nil
 }
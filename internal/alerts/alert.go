@@ -0,0 +1,70 @@
+package alerts
+
+import "errors"
+
+// Alert statuses. Active alerts are evaluated on every price change. A
+// triggered alert waits for its notification, which ends notified or, after
+// the last attempt, failed. Alerts of deleted products expire.
+const (
+	StatusActive    = "active"
+	StatusTriggered = "triggered"
+	StatusNotified  = "notified"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+	StatusExpired   = "expired"
+)
+
+var (
+	// ErrAlertNotFound is returned for unknown alert IDs
+	ErrAlertNotFound = errors.New("alert not found")
+	// ErrForbidden is returned when a caller accesses an alert of someone else
+	ErrForbidden = errors.New("unauthorized: you do not own this alert")
+	// ErrInvalidAlert is returned for alerts with an invalid target or channel
+	ErrInvalidAlert = errors.New("invalid alert")
+	// ErrAlertNotActive is returned when cancelling an alert that already ended
+	ErrAlertNotActive = errors.New("alert is no longer active")
+)
+
+// Alert is a persisted subscription to a price drop of one product. The
+// drop is given as TargetPrice or as DropPercent below BasePrice, the price
+// when the alert was created; Threshold is the resulting price to reach.
+type Alert struct {
+	ID             string  `json:"id"`
+	OwnerID        string  `json:"owner_id"`
+	ProductID      int     `json:"product_id"`
+	TargetPrice    float32 `json:"target_price,omitempty"`
+	DropPercent    float32 `json:"drop_percent,omitempty"`
+	BasePrice      float32 `json:"base_price"`
+	Threshold      float32 `json:"threshold"`
+	LastPrice      float32 `json:"last_price"`
+	Channel        Channel `json:"channel"`
+	Status         string  `json:"status"`
+	TriggeredPrice float32 `json:"triggered_price,omitempty"`
+	TriggeredAt    string  `json:"triggered_at,omitempty"`
+	ProductName    string  `json:"product_name,omitempty"`
+	Attempts       int     `json:"attempts,omitempty"`
+	Error          string  `json:"error,omitempty"`
+	NextAttemptAt  string  `json:"next_attempt_at,omitempty"`
+	NotifiedAt     string  `json:"notified_at,omitempty"`
+	CreatedAt      string  `json:"created_at"`
+	UpdatedAt      string  `json:"updated_at"`
+}
+
+// Channel says how an alert is delivered. Type selects the notifier; URL and
+// Secret are used by the webhook notifier.
+type Channel struct {
+	Type   string `json:"type"`
+	URL    string `json:"url,omitempty"`
+	Secret string `json:"secret,omitempty"`
+}
+
+// reached reports whether a price meets the alert's threshold
+func (a *Alert) reached(price float32) bool {
+	return price <= a.Threshold
+}
+
+// clone returns a copy of the alert that is safe to hand out
+func (a *Alert) clone() *Alert {
+	c := *a
+	return &c
+}
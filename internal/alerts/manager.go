@@ -0,0 +1,426 @@
+// Package alerts notifies shoppers when a product gets cheaper. Alerts are
+// evaluated on the product changes relayed from the outbox, so every price
+// change counts, whichever API made it. Triggered alerts are delivered in the
+// background through the notifier of their channel and retried on failure.
+package alerts
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"item-comparison-api/internal/dto"
+	"item-comparison-api/internal/models"
+	"item-comparison-api/internal/services"
+	"os"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Options tune notification delivery. A notification is attempted at most
+// MaxAttempts times, waiting RetryBase after the first failure and doubling
+// up to RetryMax.
+type Options struct {
+	NotifyTimeout time.Duration
+	MaxAttempts   int
+	RetryBase     time.Duration
+	RetryMax      time.Duration
+}
+
+// Manager stores alerts, evaluates them on price changes and sends their
+// notifications
+type Manager struct {
+	dir       string
+	products  services.ProductServiceInterface
+	notifiers map[string]Notifier
+	opts      Options
+
+	mu     sync.Mutex
+	alerts map[string]*Alert
+	wake   chan struct{}
+	wg     sync.WaitGroup
+}
+
+// NewManager creates a Manager keeping its alerts in dir and loads the alerts
+// saved there. notifiers maps channel types to the notifier serving them.
+func NewManager(dir string, products services.ProductServiceInterface, notifiers map[string]Notifier, opts Options) (*Manager, error) {
+	fmt.Printf("[AlertManager][NewManager] Initializing with directory: %s\n", dir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create alerts directory: %w", err)
+	}
+	alerts, err := loadFiles[Alert](dir)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Manager{
+		dir:       dir,
+		products:  products,
+		notifiers: notifiers,
+		opts:      opts,
+		alerts:    make(map[string]*Alert, len(alerts)),
+		wake:      make(chan struct{}, 1),
+	}
+	for _, a := range alerts {
+		m.alerts[a.ID] = a
+	}
+	fmt.Printf("[AlertManager][NewManager] Loaded %d alerts\n", len(alerts))
+	return m, nil
+}
+
+// Start launches the notification loop. It stops once ctx is canceled;
+// pending notifications are sent after the next start.
+func (m *Manager) Start(ctx context.Context) {
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		m.run(ctx)
+	}()
+}
+
+// Wait blocks until the notification loop has stopped
+func (m *Manager) Wait() {
+	m.wg.Wait()
+}
+
+// Create validates and stores a new alert for the owner. The product's
+// current price becomes the base price, which the target must be below.
+func (m *Manager) Create(ctx context.Context, ownerID string, a Alert) (*Alert, error) {
+	fmt.Printf("[AlertManager][Create] Called with ownerID: %s, productID: %d\n", ownerID, a.ProductID)
+	if err := m.validate(&a); err != nil {
+		return nil, err
+	}
+
+	product, err := m.products.GetProductByID(ctx, a.ProductID)
+	if err != nil {
+		return nil, err
+	}
+	a.BasePrice = product.Price
+	a.Threshold = a.TargetPrice
+	if a.DropPercent > 0 {
+		a.Threshold = product.Price * (1 - a.DropPercent/100)
+	}
+	if a.Threshold >= product.Price {
+		return nil, fmt.Errorf("%w: target price %.2f must be below the current price %.2f", ErrInvalidAlert, a.Threshold, product.Price)
+	}
+
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().Format(time.RFC3339)
+	a.ID = id
+	a.OwnerID = ownerID
+	a.LastPrice = product.Price
+	a.ProductName = product.Name
+	a.Status = StatusActive
+	a.CreatedAt = now
+	a.UpdatedAt = now
+	if err := saveFile(m.dir, a.ID, &a); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	m.alerts[id] = &a
+	snapshot := a.clone()
+	m.mu.Unlock()
+
+	fmt.Printf("[AlertManager][Create] Created alert %s at threshold %.2f\n", id, a.Threshold)
+	return snapshot, nil
+}
+
+// Get returns an alert of the owner
+func (m *Manager) Get(id, ownerID string) (*Alert, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	a, err := m.owned(id, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	return a.clone(), nil
+}
+
+// List returns the owner's alerts, newest first, optionally only those with
+// the given status or product
+func (m *Manager) List(ownerID, status string, productID int) []*Alert {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	alerts := []*Alert{}
+	for _, a := range m.alerts {
+		if a.OwnerID != ownerID || (status != "" && a.Status != status) || (productID != 0 && a.ProductID != productID) {
+			continue
+		}
+		alerts = append(alerts, a.clone())
+	}
+	sort.Slice(alerts, func(i, j int) bool {
+		if alerts[i].CreatedAt != alerts[j].CreatedAt {
+			return alerts[i].CreatedAt > alerts[j].CreatedAt
+		}
+		return alerts[i].ID > alerts[j].ID
+	})
+	return alerts
+}
+
+// Cancel stops an alert that is active or waiting for its notification
+func (m *Manager) Cancel(id, ownerID string) (*Alert, error) {
+	fmt.Printf("[AlertManager][Cancel] Called with ID: %s, ownerID: %s\n", id, ownerID)
+	m.mu.Lock()
+	a, err := m.owned(id, ownerID)
+	if err != nil {
+		m.mu.Unlock()
+		return nil, err
+	}
+	if a.Status != StatusActive && a.Status != StatusTriggered {
+		m.mu.Unlock()
+		return nil, fmt.Errorf("%w: alert %s is %s", ErrAlertNotActive, id, a.Status)
+	}
+	a.Status = StatusCancelled
+	a.NextAttemptAt = ""
+	a.UpdatedAt = time.Now().Format(time.RFC3339)
+	snapshot := a.clone()
+	m.mu.Unlock()
+
+	if err := saveFile(m.dir, id, snapshot); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// Name identifies the manager as an outbox sink
+func (m *Manager) Name() string {
+	return "alerts"
+}
+
+// Send evaluates the alerts of the updated products and expires those of
+// deleted ones. Evaluating a batch again changes nothing, since only price
+// changes against the last seen price count.
+func (m *Manager) Send(ctx context.Context, events []dto.ProductEvent) error {
+	m.mu.Lock()
+	now := time.Now().Format(time.RFC3339)
+	changed := make(map[string]*Alert)
+	for _, event := range events {
+		for _, a := range m.alerts {
+			if a.ProductID != event.ProductID || a.Status != StatusActive {
+				continue
+			}
+			switch {
+			case event.Type == models.ChangeDeleted:
+				a.Status = StatusExpired
+			case event.Product != nil && event.Product.Price != a.LastPrice:
+				a.LastPrice = event.Product.Price
+				a.ProductName = event.Product.Name
+				if a.reached(a.LastPrice) {
+					a.Status = StatusTriggered
+					a.TriggeredPrice = a.LastPrice
+					a.TriggeredAt = now
+					a.NextAttemptAt = now
+				}
+			default:
+				continue
+			}
+			a.UpdatedAt = now
+			changed[a.ID] = a.clone()
+		}
+	}
+	m.mu.Unlock()
+
+	var errs []error
+	triggered := 0
+	for id, a := range changed {
+		if a.Status == StatusTriggered {
+			triggered++
+			fmt.Printf("[AlertManager][Send] Alert %s triggered, product %d at %.2f\n", id, a.ProductID, a.TriggeredPrice)
+		}
+		if err := saveFile(m.dir, id, a); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if triggered > 0 {
+		m.signal()
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to save %d alerts: %w", len(errs), errs[0])
+	}
+	return nil
+}
+
+// run sends due notifications one at a time and sleeps until the next one
+// is due or an alert triggers
+func (m *Manager) run(ctx context.Context) {
+	for {
+		due, wait := m.due(time.Now())
+		for _, id := range due {
+			if ctx.Err() != nil {
+				return
+			}
+			m.notify(id)
+		}
+		if len(due) > 0 {
+			continue
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-m.wake:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
+
+// due returns the triggered alerts whose next attempt has come, with the
+// time until the next one is due otherwise
+func (m *Manager) due(now time.Time) ([]string, time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	wait := time.Minute
+	var due []string
+	for id, a := range m.alerts {
+		if a.Status != StatusTriggered {
+			continue
+		}
+		next, err := time.Parse(time.RFC3339, a.NextAttemptAt)
+		if err != nil || !next.After(now) {
+			due = append(due, id)
+			continue
+		}
+		wait = min(wait, next.Sub(now))
+	}
+	return due, wait
+}
+
+// notify attempts the notification of a triggered alert once and records
+// the outcome
+func (m *Manager) notify(id string) {
+	m.mu.Lock()
+	a, ok := m.alerts[id]
+	if !ok || a.Status != StatusTriggered {
+		m.mu.Unlock()
+		return
+	}
+	channel := a.Channel
+	notification := dto.AlertNotification{
+		Type:        NotificationPriceDrop,
+		AlertID:     a.ID,
+		ProductID:   a.ProductID,
+		ProductName: a.ProductName,
+		BasePrice:   a.BasePrice,
+		Price:       a.TriggeredPrice,
+		TargetPrice: a.TargetPrice,
+		DropPercent: a.DropPercent,
+		TriggeredAt: a.TriggeredAt,
+	}
+	m.mu.Unlock()
+
+	// Notifications in progress finish during shutdown, bounded by the timeout
+	err := fmt.Errorf("no notifier for channel type %q", channel.Type)
+	if notifier, ok := m.notifiers[channel.Type]; ok {
+		ctx, cancel := context.WithTimeout(context.Background(), m.opts.NotifyTimeout)
+		err = notifier.Notify(ctx, channel, notification)
+		cancel()
+	}
+
+	m.mu.Lock()
+	if a.Status != StatusTriggered {
+		// Cancelled while the notification was sent
+		m.mu.Unlock()
+		return
+	}
+	now := time.Now()
+	a.Attempts++
+	switch {
+	case err == nil:
+		a.Status = StatusNotified
+		a.NotifiedAt = now.Format(time.RFC3339)
+		a.NextAttemptAt = ""
+		a.Error = ""
+	case a.Attempts >= m.opts.MaxAttempts:
+		a.Status = StatusFailed
+		a.NextAttemptAt = ""
+		a.Error = err.Error()
+	default:
+		a.NextAttemptAt = now.Add(m.backoff(a.Attempts)).Format(time.RFC3339)
+		a.Error = err.Error()
+	}
+	a.UpdatedAt = now.Format(time.RFC3339)
+	snapshot := a.clone()
+	m.mu.Unlock()
+
+	if err != nil {
+		fmt.Printf("[AlertManager][notify][ERROR] Alert %s attempt %d: %v\n", id, snapshot.Attempts, err)
+	} else {
+		fmt.Printf("[AlertManager][notify] Notified alert %s via %s\n", id, channel.Type)
+	}
+	if err := saveFile(m.dir, id, snapshot); err != nil {
+		fmt.Printf("[AlertManager][notify][ERROR] %v\n", err)
+	}
+}
+
+// backoff returns the wait after the given number of failed attempts
+func (m *Manager) backoff(attempts int) time.Duration {
+	wait := m.opts.RetryBase
+	for i := 1; i < attempts && wait < m.opts.RetryMax; i++ {
+		wait *= 2
+	}
+	return min(wait, m.opts.RetryMax)
+}
+
+// validate checks the target and channel of a new alert
+func (m *Manager) validate(a *Alert) error {
+	if a.ProductID <= 0 {
+		return fmt.Errorf("%w: product_id must be positive", ErrInvalidAlert)
+	}
+	if (a.TargetPrice > 0) == (a.DropPercent > 0) || a.TargetPrice < 0 || a.DropPercent < 0 {
+		return fmt.Errorf("%w: set either a positive target_price or a positive drop_percent", ErrInvalidAlert)
+	}
+	if a.DropPercent >= 100 {
+		return fmt.Errorf("%w: drop_percent must be below 100, got %v", ErrInvalidAlert, a.DropPercent)
+	}
+
+	notifier, ok := m.notifiers[a.Channel.Type]
+	if !ok {
+		types := make([]string, 0, len(m.notifiers))
+		for t := range m.notifiers {
+			types = append(types, t)
+		}
+		slices.Sort(types)
+		return fmt.Errorf("%w: channel type %q must be one of %v", ErrInvalidAlert, a.Channel.Type, types)
+	}
+	return notifier.Validate(a.Channel)
+}
+
+// owned returns the alert if the caller owns it. Callers hold mu.
+func (m *Manager) owned(id, ownerID string) (*Alert, error) {
+	a, ok := m.alerts[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrAlertNotFound, id)
+	}
+	if a.OwnerID != ownerID {
+		return nil, ErrForbidden
+	}
+	return a, nil
+}
+
+// signal wakes up the notification loop
+func (m *Manager) signal() {
+	select {
+	case m.wake <- struct{}{}:
+	default:
+	}
+}
+
+// newID returns a random alert ID
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate alert ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,49 @@
+package alerts
+
+import "item-comparison-api/internal/dto"
+
+// RequestToAlert converts an AlertRequest DTO to an Alert
+func RequestToAlert(req dto.AlertRequest) Alert {
+	return Alert{
+		ProductID:   req.ProductID,
+		TargetPrice: req.TargetPrice,
+		DropPercent: req.DropPercent,
+		Channel: Channel{
+			Type:   req.Channel.Type,
+			URL:    req.Channel.URL,
+			Secret: req.Channel.Secret,
+		},
+	}
+}
+
+// AlertToResponse converts an Alert to an AlertResponse DTO, leaving out the
+// channel secret
+func AlertToResponse(a *Alert) dto.AlertResponse {
+	return dto.AlertResponse{
+		ID:             a.ID,
+		ProductID:      a.ProductID,
+		TargetPrice:    a.TargetPrice,
+		DropPercent:    a.DropPercent,
+		BasePrice:      a.BasePrice,
+		Threshold:      a.Threshold,
+		LastPrice:      a.LastPrice,
+		Channel:        dto.AlertChannel{Type: a.Channel.Type, URL: a.Channel.URL},
+		Status:         a.Status,
+		TriggeredPrice: a.TriggeredPrice,
+		TriggeredAt:    a.TriggeredAt,
+		Attempts:       a.Attempts,
+		Error:          a.Error,
+		NotifiedAt:     a.NotifiedAt,
+		CreatedAt:      a.CreatedAt,
+		UpdatedAt:      a.UpdatedAt,
+	}
+}
+
+// AlertsToResponse converts alerts to AlertResponse DTOs
+func AlertsToResponse(alerts []*Alert) []dto.AlertResponse {
+	responses := make([]dto.AlertResponse, 0, len(alerts))
+	for _, a := range alerts {
+		responses = append(responses, AlertToResponse(a))
+	}
+	return responses
+}
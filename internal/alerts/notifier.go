@@ -0,0 +1,105 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"item-comparison-api/internal/dto"
+	"item-comparison-api/internal/webhooks"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// NotificationPriceDrop is the type of price drop notifications
+const NotificationPriceDrop = "alert.price_drop"
+
+// Notifier delivers triggered alerts over one channel type. Implementations
+// are registered with the Manager under the channel type they serve.
+type Notifier interface {
+	// Validate checks the channel settings of a new alert
+	Validate(channel Channel) error
+	// Notify delivers the notification, an error leads to a retry
+	Notify(ctx context.Context, channel Channel, n dto.AlertNotification) error
+}
+
+// WebhookNotifier POSTs notifications as JSON to the channel URL, signed like
+// product webhooks when the channel has a secret. Like webhook deliveries,
+// notifications don't reach loopback, private or link-local addresses unless
+// allowPrivate is set.
+type WebhookNotifier struct {
+	client       *http.Client
+	allowPrivate bool
+}
+
+// NewWebhookNotifier creates a new instance of WebhookNotifier
+func NewWebhookNotifier(allowPrivate bool) *WebhookNotifier {
+	return &WebhookNotifier{
+		client: &http.Client{
+			Transport: webhooks.NewTransport(allowPrivate),
+			// Receivers must answer themselves, a redirect counts as a failure
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		allowPrivate: allowPrivate,
+	}
+}
+
+// Validate requires an absolute http or https URL that isn't a blocked address
+func (n *WebhookNotifier) Validate(channel Channel) error {
+	u, err := url.Parse(channel.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: channel url %q must be an absolute http or https URL", ErrInvalidAlert, channel.URL)
+	}
+	if !n.allowPrivate {
+		if err := webhooks.CheckHost(u.Hostname()); err != nil {
+			return fmt.Errorf("%w: channel url %q: %v", ErrInvalidAlert, channel.URL, err)
+		}
+	}
+	return nil
+}
+
+// Notify sends the notification, only 2xx responses count as delivered
+func (n *WebhookNotifier) Notify(ctx context.Context, channel Channel, notification dto.AlertNotification) error {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, channel.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "item-comparison-api-alerts/1.0")
+	req.Header.Set(webhooks.HeaderEvent, notification.Type)
+	if channel.Secret != "" {
+		req.Header.Set(webhooks.HeaderSignature, webhooks.Sign(channel.Secret, time.Now(), body))
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("receiver answered %s", resp.Status)
+	}
+	return nil
+}
+
+// LogNotifier writes notifications to the server log, for local runs and demos
+type LogNotifier struct{}
+
+// Validate accepts every channel
+func (LogNotifier) Validate(Channel) error {
+	return nil
+}
+
+// Notify logs the notification
+func (LogNotifier) Notify(ctx context.Context, channel Channel, n dto.AlertNotification) error {
+	fmt.Printf("[LogNotifier][Notify] Alert %s: product %d %q dropped from %.2f to %.2f\n", n.AlertID, n.ProductID, n.ProductName, n.BasePrice, n.Price)
+	return nil
+}
@@ -0,0 +1,39 @@
+package alerts
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestWebhookNotifierValidate(t *testing.T) {
+	tests := []struct {
+		url          string
+		allowPrivate bool
+		wantErr      bool
+	}{
+		{url: "https://shopper.example.com/alerts"},
+		{url: "http://203.0.113.10:8080/hook"},
+		{url: "ftp://shopper.example.com/alerts", wantErr: true},
+		{url: "/alerts", wantErr: true},
+		{url: "http://localhost:9000/hook", wantErr: true},
+		{url: "http://127.0.0.1/hook", wantErr: true},
+		{url: "http://[::1]/hook", wantErr: true},
+		{url: "http://10.0.0.5/hook", wantErr: true},
+		{url: "http://192.168.1.20/hook", wantErr: true},
+		{url: "http://169.254.169.254/latest/meta-data", wantErr: true},
+		{url: "http://[::ffff:127.0.0.1]/hook", wantErr: true},
+		{url: "http://0.0.0.0/hook", wantErr: true},
+		{url: "http://localhost:9000/hook", allowPrivate: true},
+		{url: "http://169.254.169.254/latest/meta-data", allowPrivate: true},
+	}
+
+	for _, tt := range tests {
+		err := NewWebhookNotifier(tt.allowPrivate).Validate(Channel{Type: "webhook", URL: tt.url})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Validate(%q, allowPrivate=%v) = %v, want error %v", tt.url, tt.allowPrivate, err, tt.wantErr)
+		}
+		if err != nil && !errors.Is(err, ErrInvalidAlert) {
+			t.Errorf("Validate(%q) = %v, want %v", tt.url, err, ErrInvalidAlert)
+		}
+	}
+}
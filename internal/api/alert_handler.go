@@ -0,0 +1,123 @@
+package api
+
+import (
+	"fmt"
+	"item-comparison-api/internal/alerts"
+	"item-comparison-api/internal/dto"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type AlertHandler struct {
+	manager *alerts.Manager
+}
+
+// NewAlertHandler creates a new instance of AlertHandler
+func NewAlertHandler(m *alerts.Manager) *AlertHandler {
+	return &AlertHandler{manager: m}
+}
+
+// CreateAlert subscribes the caller to a price drop of a product
+func (h *AlertHandler) CreateAlert(w http.ResponseWriter, r *http.Request) {
+	fmt.Printf("[AlertHandler][CreateAlert] %s %s\n", r.Method, r.URL.String())
+	enc, ownerID, ok := h.begin(w, r, "CreateAlert")
+	if !ok {
+		return
+	}
+
+	// Decode the alert from request body
+	var req dto.AlertRequest
+	if err := decodeBody(r, &req); err != nil {
+		fmt.Printf("[AlertHandler][CreateAlert][ERROR] %v\n", err)
+		writeDecodeError(w, err)
+		return
+	}
+
+	alert, err := h.manager.Create(r.Context(), ownerID, alerts.RequestToAlert(req))
+	if err != nil {
+		fmt.Printf("[AlertHandler][CreateAlert][ERROR] %v\n", err)
+		http.Error(w, "Failed to create alert: "+err.Error(), errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Location", "/api/v1/alerts/"+alert.ID)
+	writeBody(w, r, enc, http.StatusCreated, alerts.AlertToResponse(alert))
+}
+
+// ListAlerts lists the caller's alerts, optionally filtered by status and product
+func (h *AlertHandler) ListAlerts(w http.ResponseWriter, r *http.Request) {
+	fmt.Printf("[AlertHandler][ListAlerts] %s %s\n", r.Method, r.URL.String())
+	enc, ownerID, ok := h.begin(w, r, "ListAlerts")
+	if !ok {
+		return
+	}
+
+	// Parse the optional product filter
+	productID := 0
+	if value := r.URL.Query().Get("product_id"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			fmt.Printf("[AlertHandler][ListAlerts][ERROR] Invalid product ID: %v\n", err)
+			http.Error(w, "Invalid product ID", http.StatusBadRequest)
+			return
+		}
+		productID = id
+	}
+
+	list := h.manager.List(ownerID, r.URL.Query().Get("status"), productID)
+	writeBody(w, r, enc, http.StatusOK, alerts.AlertsToResponse(list))
+}
+
+// GetAlert returns one alert of the caller
+func (h *AlertHandler) GetAlert(w http.ResponseWriter, r *http.Request) {
+	fmt.Printf("[AlertHandler][GetAlert] %s %s\n", r.Method, r.URL.String())
+	enc, ownerID, ok := h.begin(w, r, "GetAlert")
+	if !ok {
+		return
+	}
+
+	alert, err := h.manager.Get(chi.URLParam(r, "id"), ownerID)
+	if err != nil {
+		fmt.Printf("[AlertHandler][GetAlert][ERROR] %v\n", err)
+		http.Error(w, "Failed to get alert: "+err.Error(), errorStatus(err))
+		return
+	}
+	writeBody(w, r, enc, http.StatusOK, alerts.AlertToResponse(alert))
+}
+
+// CancelAlert stops an alert. It stays listed with the cancelled status.
+func (h *AlertHandler) CancelAlert(w http.ResponseWriter, r *http.Request) {
+	fmt.Printf("[AlertHandler][CancelAlert] %s %s\n", r.Method, r.URL.String())
+	enc, ownerID, ok := h.begin(w, r, "CancelAlert")
+	if !ok {
+		return
+	}
+
+	alert, err := h.manager.Cancel(chi.URLParam(r, "id"), ownerID)
+	if err != nil {
+		fmt.Printf("[AlertHandler][CancelAlert][ERROR] %v\n", err)
+		http.Error(w, "Failed to cancel alert: "+err.Error(), errorStatus(err))
+		return
+	}
+	writeBody(w, r, enc, http.StatusOK, alerts.AlertToResponse(alert))
+}
+
+// begin resolves the response encoding and the caller of a request, and
+// answers the request itself when either is missing
+func (h *AlertHandler) begin(w http.ResponseWriter, r *http.Request, method string) (*encoding, string, bool) {
+	enc, ok := negotiate(w, r, entityEncodings)
+	if !ok {
+		return nil, "", false
+	}
+
+	// Alerts belong to the authenticated caller
+	ownerID := SellerFromContext(r.Context())
+	if ownerID == "" {
+		fmt.Printf("[AlertHandler][%s][ERROR] Missing seller credentials\n", method)
+		http.Error(w, "Missing seller credentials", http.StatusBadRequest)
+		return nil, "", false
+	}
+	return enc, ownerID, true
+}
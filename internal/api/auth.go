@@ -0,0 +1,51 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"item-comparison-api/internal/config"
+	"net/http"
+)
+
+type sellerContextKey struct{}
+
+// APIKeyHeader carries the API key in api_key auth mode
+const APIKeyHeader = "X-API-Key"
+
+// AuthMiddleware resolves the seller making the request and stores it in the
+// request context. In header mode the configured seller header is trusted; in
+// api_key mode the seller comes from the API key and unknown keys get a 401.
+// Requests without credentials pass through, write handlers reject them.
+func AuthMiddleware(cfg config.AuthConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var sellerID string
+			switch cfg.Mode {
+			case config.AuthModeAPIKey:
+				if key := r.Header.Get(APIKeyHeader); key != "" {
+					seller, ok := cfg.APIKeys[key]
+					if !ok {
+						fmt.Printf("[AuthMiddleware][ERROR] Unknown API key for %s %s\n", r.Method, r.URL.Path)
+						http.Error(w, "Invalid API key", http.StatusUnauthorized)
+						return
+					}
+					sellerID = seller
+				}
+			default:
+				sellerID = r.Header.Get(cfg.SellerHeader)
+			}
+
+			if sellerID != "" {
+				r = r.WithContext(context.WithValue(r.Context(), sellerContextKey{}, sellerID))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// SellerFromContext returns the seller resolved by AuthMiddleware, or an
+// empty string when the request carried no credentials
+func SellerFromContext(ctx context.Context) string {
+	sellerID, _ := ctx.Value(sellerContextKey{}).(string)
+	return sellerID
+}
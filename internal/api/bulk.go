@@ -0,0 +1,33 @@
+package api
+
+import (
+	"item-comparison-api/internal/dto"
+	"item-comparison-api/internal/services"
+	"net/http"
+	"strconv"
+)
+
+// bulkMode reports whether the request asks for per-item results with ?bulk=true
+func bulkMode(r *http.Request) bool {
+	bulk, _ := strconv.ParseBool(r.URL.Query().Get("bulk"))
+	return bulk
+}
+
+// bulkResponse converts per-item service results to the multi-status body,
+// using okStatus for the items that succeeded
+func bulkResponse(results []services.ItemResult, okStatus int) dto.BulkResponse {
+	response := dto.BulkResponse{Results: make([]dto.BulkItemResult, 0, len(results))}
+	for _, result := range results {
+		item := dto.BulkItemResult{Index: result.Index, ID: result.ID, Status: okStatus, Product: result.Product}
+		if result.Err != nil {
+			item.Status = errorStatus(result.Err)
+			item.Code = services.ErrorCode(result.Err)
+			item.Error = result.Err.Error()
+			response.Failed++
+		} else {
+			response.Succeeded++
+		}
+		response.Results = append(response.Results, item)
+	}
+	return response
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"fmt"
+	"item-comparison-api/internal/comparisons"
+	"item-comparison-api/internal/dto"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type ComparisonHandler struct {
+	manager *comparisons.Manager
+}
+
+// NewComparisonHandler creates a new instance of ComparisonHandler
+func NewComparisonHandler(m *comparisons.Manager) *ComparisonHandler {
+	return &ComparisonHandler{manager: m}
+}
+
+// CreateComparison saves a comparison. Without credentials it is saved
+// anonymously and expires.
+func (h *ComparisonHandler) CreateComparison(w http.ResponseWriter, r *http.Request) {
+	fmt.Printf("[ComparisonHandler][CreateComparison] %s %s\n", r.Method, r.URL.String())
+	enc, ok := negotiate(w, r, entityEncodings)
+	if !ok {
+		return
+	}
+
+	// Decode the comparison from request body
+	var req dto.ComparisonRequest
+	if err := decodeBody(r, &req); err != nil {
+		fmt.Printf("[ComparisonHandler][CreateComparison][ERROR] %v\n", err)
+		writeDecodeError(w, err)
+		return
+	}
+
+	comparison, err := h.manager.Create(r.Context(), SellerFromContext(r.Context()), comparisons.RequestToComparison(req))
+	if err != nil {
+		fmt.Printf("[ComparisonHandler][CreateComparison][ERROR] %v\n", err)
+		http.Error(w, "Failed to save comparison: "+err.Error(), errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Location", "/api/v1/comparisons/"+comparison.ID)
+	writeBody(w, r, enc, http.StatusCreated, comparisons.ComparisonToDetailResponse(comparison, comparison.Snapshot))
+}
+
+// ListComparisons lists the comparisons saved by the caller
+func (h *ComparisonHandler) ListComparisons(w http.ResponseWriter, r *http.Request) {
+	fmt.Printf("[ComparisonHandler][ListComparisons] %s %s\n", r.Method, r.URL.String())
+	enc, ok := negotiate(w, r, entityEncodings)
+	if !ok {
+		return
+	}
+
+	// Validate that the request identifies an owner
+	ownerID := SellerFromContext(r.Context())
+	if ownerID == "" {
+		fmt.Printf("[ComparisonHandler][ListComparisons][ERROR] Missing seller credentials\n")
+		http.Error(w, "Missing seller credentials", http.StatusBadRequest)
+		return
+	}
+
+	list := h.manager.List(ownerID)
+	responses := make([]dto.ComparisonResponse, 0, len(list))
+	for _, comparison := range list {
+		responses = append(responses, comparisons.ComparisonToResponse(comparison))
+	}
+	writeBody(w, r, enc, http.StatusOK, responses)
+}
+
+// GetComparison returns a comparison with the current product data and the
+// changes since it was saved. Anyone with the ID may see it.
+func (h *ComparisonHandler) GetComparison(w http.ResponseWriter, r *http.Request) {
+	fmt.Printf("[ComparisonHandler][GetComparison] %s %s\n", r.Method, r.URL.String())
+	enc, ok := negotiate(w, r, entityEncodings)
+	if !ok {
+		return
+	}
+
+	comparison, current, err := h.manager.Get(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Printf("[ComparisonHandler][GetComparison][ERROR] %v\n", err)
+		http.Error(w, "Failed to get comparison: "+err.Error(), errorStatus(err))
+		return
+	}
+	writeBody(w, r, enc, http.StatusOK, comparisons.ComparisonToDetailResponse(comparison, current))
+}
+
+// DeleteComparison removes a comparison of the caller
+func (h *ComparisonHandler) DeleteComparison(w http.ResponseWriter, r *http.Request) {
+	fmt.Printf("[ComparisonHandler][DeleteComparison] %s %s\n", r.Method, r.URL.String())
+	ownerID := SellerFromContext(r.Context())
+	if ownerID == "" {
+		fmt.Printf("[ComparisonHandler][DeleteComparison][ERROR] Missing seller credentials\n")
+		http.Error(w, "Missing seller credentials", http.StatusBadRequest)
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	if err := h.manager.Delete(id, ownerID); err != nil {
+		fmt.Printf("[ComparisonHandler][DeleteComparison][ERROR] %v\n", err)
+		http.Error(w, "Failed to delete comparison: "+err.Error(), errorStatus(err))
+		return
+	}
+
+	// Respond with success
+	fmt.Printf("[ComparisonHandler][DeleteComparison] Deleted comparison %s for %s\n", id, ownerID)
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Comparison deleted successfully"))
+}
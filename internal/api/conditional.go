@@ -0,0 +1,91 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"item-comparison-api/internal/dto"
+	"item-comparison-api/internal/repository"
+	"item-comparison-api/internal/services"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// etag returns the strong entity tag of a product version
+func etag(version int) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
+
+// setValidators sets the ETag and Last-Modified headers of a product response
+func setValidators(w http.ResponseWriter, product *dto.ProductResponse) {
+	w.Header().Set("ETag", etag(product.Version))
+	if modified, err := time.Parse(time.RFC3339, product.UpdatedAt); err == nil {
+		w.Header().Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	}
+}
+
+// notModified reports whether the client's cached copy of the product is
+// still current, based on If-None-Match or, without it, If-Modified-Since
+func notModified(r *http.Request, product *dto.ProductResponse) bool {
+	if header := r.Header.Get("If-None-Match"); header != "" {
+		current := etag(product.Version)
+		for _, tag := range strings.Split(header, ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == "*" || tag == current {
+				return true
+			}
+		}
+		return false
+	}
+
+	if header := r.Header.Get("If-Modified-Since"); header != "" {
+		since, err := http.ParseTime(header)
+		modified, modErr := time.Parse(time.RFC3339, product.UpdatedAt)
+		return err == nil && modErr == nil && !modified.Truncate(time.Second).After(since)
+	}
+	return false
+}
+
+// ifMatchAny is the version ifMatchVersion returns for "If-Match: *"
+const ifMatchAny = -1
+
+// ifMatchVersion returns the product version required by the If-Match
+// header, 0 when the header is absent or ifMatchAny for "*"
+func ifMatchVersion(r *http.Request) (int, error) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" {
+		return 0, nil
+	}
+	if header == "*" {
+		return ifMatchAny, nil
+	}
+	if strings.HasPrefix(header, "W/") {
+		return 0, fmt.Errorf("weak entity tags can't be used with If-Match")
+	}
+
+	version, err := strconv.Atoi(strings.Trim(header, `"`))
+	if err != nil || version <= 0 || strings.Contains(header, ",") {
+		return 0, fmt.Errorf("If-Match must be a single entity tag returned by this API")
+	}
+	return version, nil
+}
+
+// resolveIfMatch turns ifMatchAny into the current version of the product,
+// which must exist (RFC 9110). Writing with that version also fails if the
+// product is deleted in the meantime. Other versions are returned as is.
+func resolveIfMatch(ctx context.Context, service services.ProductServiceInterface, id, version int) (int, error) {
+	if version != ifMatchAny {
+		return version, nil
+	}
+	product, err := service.GetProductByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && product.ID != id) {
+		// A merged product resolves to the one it was merged into
+		return 0, fmt.Errorf("product with ID %d does not exist: %w", id, repository.ErrVersionConflict)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return product.Version, nil
+}
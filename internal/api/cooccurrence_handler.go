@@ -0,0 +1,124 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"item-comparison-api/internal/cooccurrence"
+	"item-comparison-api/internal/dto"
+	"item-comparison-api/internal/repository"
+	"item-comparison-api/internal/services"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type CooccurrenceHandler struct {
+	service      services.ProductServiceInterface
+	tracker      *cooccurrence.Tracker
+	defaultLimit int
+	maxLimit     int
+}
+
+// NewCooccurrenceHandler creates a new instance of CooccurrenceHandler
+func NewCooccurrenceHandler(s services.ProductServiceInterface, tracker *cooccurrence.Tracker, defaultLimit, maxLimit int) *CooccurrenceHandler {
+	return &CooccurrenceHandler{service: s, tracker: tracker, defaultLimit: defaultLimit, maxLimit: maxLimit}
+}
+
+// GetFrequentlyCompared returns the products most often compared with a
+// product, highest score first
+func (h *CooccurrenceHandler) GetFrequentlyCompared(w http.ResponseWriter, r *http.Request) {
+	fmt.Printf("[CooccurrenceHandler][GetFrequentlyCompared] %s %s\n", r.Method, r.URL.String())
+	// Resolve the response encoding before doing any work
+	enc, ok := negotiate(w, r, entityEncodings)
+	if !ok {
+		return
+	}
+
+	// Convert ID to integer
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Printf("[CooccurrenceHandler][GetFrequentlyCompared][ERROR] Invalid product ID: %v\n", err)
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+	limit, ok := h.limit(w, r, "GetFrequentlyCompared")
+	if !ok {
+		return
+	}
+
+	// Unknown products are reported as such rather than with an empty list
+	if _, err := h.service.GetProductByID(r.Context(), id); err != nil {
+		fmt.Printf("[CooccurrenceHandler][GetFrequentlyCompared][ERROR] %v\n", err)
+		http.Error(w, "Failed to get product: "+err.Error(), errorStatus(err))
+		return
+	}
+
+	// Resolve the products, skipping those deleted since they were compared
+	responses := []dto.ComparedProduct{}
+	for _, pair := range h.tracker.FrequentlyCompared(id, math.MaxInt) {
+		if len(responses) == limit {
+			break
+		}
+		product, err := h.service.GetProductByID(r.Context(), pair.ProductID)
+		if errors.Is(err, repository.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			fmt.Printf("[CooccurrenceHandler][GetFrequentlyCompared][ERROR] %v\n", err)
+			http.Error(w, "Failed to get product: "+err.Error(), errorStatus(err))
+			return
+		}
+		responses = append(responses, dto.ComparedProduct{
+			Product:        *product,
+			Score:          pair.Score,
+			Count:          pair.Count,
+			LastComparedAt: pair.LastAt.Format(time.RFC3339),
+		})
+	}
+	writeBody(w, r, enc, http.StatusOK, responses)
+}
+
+// GetPopularComparisons returns the ID sets compared most often
+func (h *CooccurrenceHandler) GetPopularComparisons(w http.ResponseWriter, r *http.Request) {
+	fmt.Printf("[CooccurrenceHandler][GetPopularComparisons] %s %s\n", r.Method, r.URL.String())
+	// Resolve the response encoding before doing any work
+	enc, ok := negotiate(w, r, entityEncodings)
+	if !ok {
+		return
+	}
+	limit, ok := h.limit(w, r, "GetPopularComparisons")
+	if !ok {
+		return
+	}
+
+	sets := h.tracker.Popular(limit)
+	responses := make([]dto.PopularComparison, 0, len(sets))
+	for _, set := range sets {
+		responses = append(responses, dto.PopularComparison{
+			IDs:            set.IDs,
+			Score:          set.Score,
+			Count:          set.Count,
+			LastComparedAt: set.LastAt.Format(time.RFC3339),
+		})
+	}
+	writeBody(w, r, enc, http.StatusOK, responses)
+}
+
+// limit reads the limit query parameter, and answers the request itself
+// when it is invalid
+func (h *CooccurrenceHandler) limit(w http.ResponseWriter, r *http.Request, method string) (int, bool) {
+	value := r.URL.Query().Get("limit")
+	if value == "" {
+		return h.defaultLimit, true
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 1 || limit > h.maxLimit {
+		fmt.Printf("[CooccurrenceHandler][%s][ERROR] Invalid limit: %q\n", method, value)
+		http.Error(w, fmt.Sprintf("Invalid limit, must be between 1 and %d", h.maxLimit), http.StatusBadRequest)
+		return 0, false
+	}
+	return limit, true
+}
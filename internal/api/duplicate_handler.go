@@ -0,0 +1,176 @@
+package api
+
+import (
+	"fmt"
+	"item-comparison-api/internal/dto"
+	"item-comparison-api/internal/duplicates"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type DuplicateHandler struct {
+	manager *duplicates.Manager
+}
+
+// NewDuplicateHandler creates a new instance of DuplicateHandler
+func NewDuplicateHandler(m *duplicates.Manager) *DuplicateHandler {
+	return &DuplicateHandler{manager: m}
+}
+
+// ListCandidates returns the review queue of the caller, pending candidates
+// unless another status is asked for
+func (h *DuplicateHandler) ListCandidates(w http.ResponseWriter, r *http.Request) {
+	fmt.Printf("[DuplicateHandler][ListCandidates] %s %s\n", r.Method, r.URL.String())
+	enc, callerID, ok := h.begin(w, r, "ListCandidates")
+	if !ok {
+		return
+	}
+
+	// Validate the optional status filter
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "":
+		status = duplicates.StatusPending
+	case duplicates.StatusPending, duplicates.StatusDismissed, duplicates.StatusMerged:
+	default:
+		fmt.Printf("[DuplicateHandler][ListCandidates][ERROR] Invalid status: %q\n", status)
+		http.Error(w, "Invalid status, must be pending, dismissed or merged", http.StatusBadRequest)
+		return
+	}
+
+	list := h.manager.List(callerID, status)
+	writeBody(w, r, enc, http.StatusOK, duplicates.CandidatesToResponse(list))
+}
+
+// ScanDuplicates checks the whole catalog for duplicates
+func (h *DuplicateHandler) ScanDuplicates(w http.ResponseWriter, r *http.Request) {
+	fmt.Printf("[DuplicateHandler][ScanDuplicates] %s %s\n", r.Method, r.URL.String())
+	enc, _, ok := h.begin(w, r, "ScanDuplicates")
+	if !ok {
+		return
+	}
+
+	scanned, pending, err := h.manager.Scan(r.Context())
+	if err != nil {
+		fmt.Printf("[DuplicateHandler][ScanDuplicates][ERROR] %v\n", err)
+		http.Error(w, "Failed to scan for duplicates: "+err.Error(), errorStatus(err))
+		return
+	}
+	writeBody(w, r, enc, http.StatusOK, dto.DuplicateScanResponse{Scanned: scanned, Pending: pending})
+}
+
+// DismissCandidate marks a candidate as not a duplicate
+func (h *DuplicateHandler) DismissCandidate(w http.ResponseWriter, r *http.Request) {
+	fmt.Printf("[DuplicateHandler][DismissCandidate] %s %s\n", r.Method, r.URL.String())
+	enc, callerID, ok := h.begin(w, r, "DismissCandidate")
+	if !ok {
+		return
+	}
+
+	candidate, err := h.manager.Dismiss(r.Context(), chi.URLParam(r, "id"), callerID)
+	if err != nil {
+		fmt.Printf("[DuplicateHandler][DismissCandidate][ERROR] %v\n", err)
+		http.Error(w, "Failed to dismiss candidate: "+err.Error(), errorStatus(err))
+		return
+	}
+	writeBody(w, r, enc, http.StatusOK, duplicates.CandidateToResponse(candidate))
+}
+
+// MergeCandidate merges the products of a candidate into the one to keep
+func (h *DuplicateHandler) MergeCandidate(w http.ResponseWriter, r *http.Request) {
+	fmt.Printf("[DuplicateHandler][MergeCandidate] %s %s\n", r.Method, r.URL.String())
+	enc, callerID, ok := h.begin(w, r, "MergeCandidate")
+	if !ok {
+		return
+	}
+
+	// Decode the product to keep from request body
+	var req dto.MergeRequest
+	if err := decodeBody(r, &req); err != nil {
+		fmt.Printf("[DuplicateHandler][MergeCandidate][ERROR] %v\n", err)
+		writeDecodeError(w, err)
+		return
+	}
+
+	merge, err := h.manager.Merge(r.Context(), chi.URLParam(r, "id"), req.KeepID, callerID)
+	if err != nil {
+		fmt.Printf("[DuplicateHandler][MergeCandidate][ERROR] %v\n", err)
+		http.Error(w, "Failed to merge products: "+err.Error(), errorStatus(err))
+		return
+	}
+	writeBody(w, r, enc, http.StatusOK, duplicates.MergeToResponse(merge))
+}
+
+// ListMerges returns the merges, optionally only those involving a product
+func (h *DuplicateHandler) ListMerges(w http.ResponseWriter, r *http.Request) {
+	fmt.Printf("[DuplicateHandler][ListMerges] %s %s\n", r.Method, r.URL.String())
+	enc, ok := negotiate(w, r, entityEncodings)
+	if !ok {
+		return
+	}
+
+	// Parse the optional product filter
+	productID := 0
+	if value := r.URL.Query().Get("product_id"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			fmt.Printf("[DuplicateHandler][ListMerges][ERROR] Invalid product ID: %v\n", err)
+			http.Error(w, "Invalid product ID", http.StatusBadRequest)
+			return
+		}
+		productID = id
+	}
+
+	writeBody(w, r, enc, http.StatusOK, duplicates.MergesToResponse(h.manager.Merges(productID)))
+}
+
+// RedirectMerged answers requests for a merged product with a permanent
+// redirect to the product it was merged into. It wraps product routes with
+// the product ID in the {id} parameter.
+func (h *DuplicateHandler) RedirectMerged(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		param := chi.URLParam(r, "id")
+		id, err := strconv.Atoi(param)
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		target := h.manager.Resolve(id)
+		if target == id {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// Swap the ID segment, keeping the rest of the path and the query
+		location := *r.URL
+		before, after, found := strings.Cut(r.URL.Path, "/products/"+param)
+		if !found {
+			next.ServeHTTP(w, r)
+			return
+		}
+		location.Path = before + "/products/" + strconv.Itoa(target) + after
+		fmt.Printf("[DuplicateHandler][RedirectMerged] Product %d was merged into product %d\n", id, target)
+		http.Redirect(w, r, location.RequestURI(), http.StatusMovedPermanently)
+	})
+}
+
+// begin resolves the response encoding and the caller of a request, and
+// answers the request itself when either is missing
+func (h *DuplicateHandler) begin(w http.ResponseWriter, r *http.Request, method string) (*encoding, string, bool) {
+	enc, ok := negotiate(w, r, entityEncodings)
+	if !ok {
+		return nil, "", false
+	}
+
+	// Reviews are made by an authenticated seller
+	callerID := SellerFromContext(r.Context())
+	if callerID == "" {
+		fmt.Printf("[DuplicateHandler][%s][ERROR] Missing seller credentials\n", method)
+		http.Error(w, "Missing seller credentials", http.StatusBadRequest)
+		return nil, "", false
+	}
+	return enc, callerID, true
+}
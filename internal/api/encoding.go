@@ -0,0 +1,346 @@
+package api
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"item-comparison-api/internal/codec"
+	"item-comparison-api/internal/dto"
+	"mime"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+)
+
+var tracer = otel.Tracer("item-comparison-api/internal/api")
+
+// errUnsupportedMediaType is returned by decodeBody for bodies in a format the API doesn't read
+var errUnsupportedMediaType = errors.New("unsupported media type")
+
+// encoding reads and writes bodies in one format. decode is nil for formats
+// that are only used in responses.
+type encoding struct {
+	name string
+	// mediaTypes are matched against Accept and Content-Type, the first one
+	// is sent in Content-Type
+	mediaTypes []string
+	encode     func(w io.Writer, v any) error
+	decode     func(r io.Reader, v any) error
+}
+
+var (
+	jsonEncoding = &encoding{
+		name:       "json",
+		mediaTypes: []string{"application/json"},
+		encode:     func(w io.Writer, v any) error { return json.NewEncoder(w).Encode(v) },
+		decode:     func(r io.Reader, v any) error { return json.NewDecoder(r).Decode(v) },
+	}
+	xmlEncoding = &encoding{
+		name:       "xml",
+		mediaTypes: []string{"application/xml", "text/xml"},
+		encode:     encodeXML,
+		decode:     decodeXML,
+	}
+	msgpackEncoding = &encoding{
+		name:       "msgpack",
+		mediaTypes: []string{"application/msgpack", "application/x-msgpack", "application/vnd.msgpack"},
+		encode:     encodeMsgpack,
+		decode:     decodeMsgpack,
+	}
+	csvEncoding = &encoding{
+		name:       codec.CSV,
+		mediaTypes: []string{codec.CSVMediaType},
+		encode:     productListEncoder(codec.CSV),
+	}
+	ndjsonEncoding = &encoding{
+		name:       codec.NDJSON,
+		mediaTypes: []string{codec.NDJSONMediaType, "application/ndjson"},
+		encode:     productListEncoder(codec.NDJSON),
+	}
+)
+
+// entityEncodings can represent any response, JSON first as the default
+var entityEncodings = []*encoding{jsonEncoding, xmlEncoding, msgpackEncoding}
+
+// listEncodings can represent product lists, which also export as CSV and NDJSON
+var listEncodings = []*encoding{jsonEncoding, xmlEncoding, msgpackEncoding, csvEncoding, ndjsonEncoding}
+
+// negotiate picks the response encoding from the Accept header. It answers
+// 406 and returns false when nothing acceptable is offered.
+func negotiate(w http.ResponseWriter, r *http.Request, offered []*encoding) (*encoding, bool) {
+	w.Header().Add("Vary", "Accept")
+	accept := r.Header.Get("Accept")
+	if strings.TrimSpace(accept) == "" {
+		return offered[0], true
+	}
+	if e := match(accept, offered); e != nil {
+		return e, true
+	}
+
+	fmt.Printf("[API][negotiate][ERROR] Not acceptable: %q\n", accept)
+	http.Error(w, "Not acceptable, available types: "+mediaTypeList(offered), http.StatusNotAcceptable)
+	return nil, false
+}
+
+// negotiateFormat is negotiate with the format query parameter taking
+// precedence over Accept. An unknown format is answered with 400.
+func negotiateFormat(w http.ResponseWriter, r *http.Request, offered []*encoding) (*encoding, bool) {
+	name := r.URL.Query().Get("format")
+	if name == "" {
+		return negotiate(w, r, offered)
+	}
+	for _, e := range offered {
+		if e.name == name {
+			return e, true
+		}
+	}
+	fmt.Printf("[API][negotiateFormat][ERROR] Unknown format: %q\n", name)
+	http.Error(w, fmt.Sprintf("Unknown format %q, use one of: %s", name, encodingNames(offered)), http.StatusBadRequest)
+	return nil, false
+}
+
+// mediaRange is one entry of an Accept header
+type mediaRange struct {
+	mediaType string
+	q         float64
+}
+
+// match returns the offered encoding the Accept header prefers, honoring
+// q-values and wildcards. Ties go to the earlier media range, then to the
+// order of offered.
+func match(accept string, offered []*encoding) *encoding {
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(value, 64); err != nil {
+				continue
+			}
+		}
+		if q > 0 {
+			ranges = append(ranges, mediaRange{mediaType: mediaType, q: q})
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+
+	for _, rng := range ranges {
+		for _, e := range offered {
+			for _, mediaType := range e.mediaTypes {
+				if mediaTypeMatches(rng.mediaType, mediaType) {
+					return e
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// mediaTypeMatches reports whether a media range such as text/* covers a media type
+func mediaTypeMatches(rng, mediaType string) bool {
+	if rng == "*/*" || rng == mediaType {
+		return true
+	}
+	prefix, ok := strings.CutSuffix(rng, "/*")
+	return ok && strings.HasPrefix(mediaType, prefix+"/")
+}
+
+// requestEncoding returns the encoding of the request body from Content-Type.
+// A body without Content-Type is read as JSON.
+func requestEncoding(r *http.Request) (*encoding, error) {
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" {
+		return jsonEncoding, nil
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", errUnsupportedMediaType, contentType)
+	}
+	for _, e := range entityEncodings {
+		for _, t := range e.mediaTypes {
+			if t == mediaType {
+				return e, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("%w: %q", errUnsupportedMediaType, contentType)
+}
+
+// decodeBody decodes the request body into v by its Content-Type, in its own
+// span so decoding time shows up separately from the service call in traces
+func decodeBody(r *http.Request, v any) error {
+	e, err := requestEncoding(r)
+	if err != nil {
+		return err
+	}
+
+	_, span := tracer.Start(r.Context(), e.name+".decode")
+	defer span.End()
+
+	if err := e.decode(r.Body, v); err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	return nil
+}
+
+// writeDecodeError answers a request whose body could not be decoded
+func writeDecodeError(w http.ResponseWriter, err error) {
+	if errors.Is(err, errUnsupportedMediaType) {
+		http.Error(w, "Content-Type must be one of: "+mediaTypeList(entityEncodings), http.StatusUnsupportedMediaType)
+		return
+	}
+	http.Error(w, "Invalid request body", http.StatusBadRequest)
+}
+
+// writeBody encodes v as the response body in its own span
+func writeBody(w http.ResponseWriter, r *http.Request, e *encoding, status int, v any) {
+	_, span := tracer.Start(r.Context(), e.name+".encode")
+	defer span.End()
+
+	w.Header().Set("Content-Type", e.mediaTypes[0])
+	w.WriteHeader(status)
+	if err := e.encode(w, v); err != nil {
+		// Headers are already sent, the client sees a truncated body
+		fmt.Printf("[API][writeBody][ERROR] %v\n", err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
+
+// productListEncoder encodes product lists with a codec format
+func productListEncoder(format string) func(w io.Writer, v any) error {
+	return func(w io.Writer, v any) error {
+		products, ok := v.([]dto.ProductResponse)
+		if !ok {
+			return fmt.Errorf("%s can only encode product lists, got %T", format, v)
+		}
+		return codec.Write(format, w, products)
+	}
+}
+
+// encodeXML writes v as an XML document. Slices are wrapped in a root element
+// named after their elements, e.g. <products><product>...</product></products>.
+func encodeXML(w io.Writer, v any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice {
+		return enc.Encode(v)
+	}
+
+	item := xmlElementName(rv.Type().Elem())
+	root := xml.StartElement{Name: xml.Name{Local: item + "s"}}
+	if err := enc.EncodeToken(root); err != nil {
+		return err
+	}
+	if err := enc.EncodeElement(v, xml.StartElement{Name: xml.Name{Local: item}}); err != nil {
+		return err
+	}
+	if err := enc.EncodeToken(root.End()); err != nil {
+		return err
+	}
+	return enc.Flush()
+}
+
+// decodeXML reads an XML document into v. A pointer to a slice reads every
+// child of the root element as one element of the slice.
+func decodeXML(r io.Reader, v any) error {
+	dec := xml.NewDecoder(r)
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Slice {
+		return dec.Decode(v)
+	}
+
+	slice := rv.Elem()
+	depth := 0
+	for {
+		token, err := dec.Token()
+		if err == io.EOF {
+			if depth != 0 {
+				return io.ErrUnexpectedEOF
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if depth == 0 {
+				depth++
+				continue
+			}
+			elem := reflect.New(slice.Type().Elem())
+			if err := dec.DecodeElement(elem.Interface(), &t); err != nil {
+				return err
+			}
+			slice.Set(reflect.Append(slice, elem.Elem()))
+		case xml.EndElement:
+			depth--
+		}
+	}
+}
+
+// xmlElementName returns the element name a struct type declares with its XMLName field
+func xmlElementName(t reflect.Type) string {
+	if field, ok := t.FieldByName("XMLName"); ok {
+		if name, _, _ := strings.Cut(field.Tag.Get("xml"), ","); name != "" {
+			return name
+		}
+	}
+	return strings.ToLower(t.Name())
+}
+
+// encodeMsgpack writes v as MessagePack, using the JSON field names
+func encodeMsgpack(w io.Writer, v any) error {
+	enc := msgpack.NewEncoder(w)
+	enc.SetCustomStructTag("json")
+	return enc.Encode(v)
+}
+
+// decodeMsgpack reads MessagePack into v with the same rules as JSON. The
+// document is decoded generically first, since clients send numbers as
+// float64 or integers where the DTOs have float32, which msgpack won't convert.
+func decodeMsgpack(r io.Reader, v any) error {
+	var document any
+	if err := msgpack.NewDecoder(r).Decode(&document); err != nil {
+		return err
+	}
+	bytes, err := json.Marshal(document)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(bytes, v)
+}
+
+// encodingNames lists the format names of the encodings
+func encodingNames(encodings []*encoding) string {
+	names := make([]string, 0, len(encodings))
+	for _, e := range encodings {
+		names = append(names, e.name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// mediaTypeList lists the main media type of the encodings
+func mediaTypeList(encodings []*encoding) string {
+	types := make([]string, 0, len(encodings))
+	for _, e := range encodings {
+		types = append(types, e.mediaTypes[0])
+	}
+	return strings.Join(types, ", ")
+}
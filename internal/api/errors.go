@@ -0,0 +1,49 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"item-comparison-api/internal/alerts"
+	"item-comparison-api/internal/comparisons"
+	"item-comparison-api/internal/duplicates"
+	"item-comparison-api/internal/jobs"
+	"item-comparison-api/internal/offers"
+	"item-comparison-api/internal/repository"
+	"item-comparison-api/internal/services"
+	"item-comparison-api/internal/webhooks"
+	"net/http"
+)
+
+// errorStatus maps an error returned by the service layer to an HTTP status code
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, context.Canceled):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, repository.ErrNotFound), errors.Is(err, jobs.ErrJobNotFound),
+		errors.Is(err, webhooks.ErrWebhookNotFound), errors.Is(err, webhooks.ErrDeliveryNotFound),
+		errors.Is(err, alerts.ErrAlertNotFound), errors.Is(err, comparisons.ErrComparisonNotFound),
+		errors.Is(err, offers.ErrOfferNotFound), errors.Is(err, duplicates.ErrCandidateNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repository.ErrAlreadyExists):
+		return http.StatusConflict
+	case errors.Is(err, repository.ErrVersionConflict):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, services.ErrForbidden), errors.Is(err, jobs.ErrForbidden), errors.Is(err, webhooks.ErrForbidden),
+		errors.Is(err, alerts.ErrForbidden), errors.Is(err, comparisons.ErrForbidden), errors.Is(err, duplicates.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrValidation), errors.Is(err, services.ErrInvalidPatch), errors.Is(err, jobs.ErrInvalidImport),
+		errors.Is(err, webhooks.ErrInvalidWebhook), errors.Is(err, alerts.ErrInvalidAlert),
+		errors.Is(err, comparisons.ErrInvalidComparison), errors.Is(err, offers.ErrInvalidOffer),
+		errors.Is(err, duplicates.ErrInvalidMerge):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrPatchConflict), errors.Is(err, webhooks.ErrDeliveryPending),
+		errors.Is(err, alerts.ErrAlertNotActive), errors.Is(err, duplicates.ErrCandidateClosed):
+		return http.StatusConflict
+	case errors.Is(err, jobs.ErrKeyReused):
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
+}
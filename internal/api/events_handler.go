@@ -0,0 +1,161 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"item-comparison-api/internal/dto"
+	"item-comparison-api/internal/events"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// retryMillis tells EventSource clients how long to wait before reconnecting
+const retryMillis = 3000
+
+type EventsHandler struct {
+	broker    *events.Broker
+	heartbeat time.Duration
+}
+
+// NewEventsHandler creates a new instance of EventsHandler
+func NewEventsHandler(b *events.Broker, heartbeat time.Duration) *EventsHandler {
+	return &EventsHandler{broker: b, heartbeat: heartbeat}
+}
+
+// Stream sends product change events as Server-Sent Events until the client
+// disconnects. A Last-Event-ID header, or the last_event_id query parameter
+// for the first connection, replays the logged events after that ID; when
+// they are no longer logged a "reset" event tells the client to reload.
+func (h *EventsHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	fmt.Printf("[EventsHandler][Stream] %s %s\n", r.Method, r.URL.String())
+	filter, err := eventFilter(r)
+	if err != nil {
+		fmt.Printf("[EventsHandler][Stream][ERROR] %v\n", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// The stream outlives the server write timeout
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		fmt.Printf("[EventsHandler][Stream][ERROR] %v\n", err)
+	}
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+	sub, missed, reset := h.broker.Subscribe(lastEventID, filter)
+	defer h.broker.Unsubscribe(sub)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", retryMillis)
+
+	if reset {
+		fmt.Printf("[EventsHandler][Stream] Last-Event-ID %q is no longer logged, sending reset\n", lastEventID)
+		fmt.Fprint(w, "event: reset\ndata: {}\n\n")
+	}
+	for _, event := range missed {
+		if err := writeEvent(w, event); err != nil {
+			return
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		fmt.Printf("[EventsHandler][Stream][ERROR] %v\n", err)
+		return
+	}
+	fmt.Printf("[EventsHandler][Stream] Subscribed, replayed %d events\n", len(missed))
+
+	// Comments keep idle connections from being closed by proxies
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			fmt.Printf("[EventsHandler][Stream] Client disconnected\n")
+			return
+		case event, ok := <-sub.C:
+			// A closed channel means the server is shutting down or the
+			// client fell behind; either way it reconnects and resumes
+			if !ok {
+				fmt.Printf("[EventsHandler][Stream] Subscription ended\n")
+				return
+			}
+			if err := writeEvent(w, event); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			fmt.Printf("[EventsHandler][Stream][ERROR] %v\n", err)
+			return
+		}
+	}
+}
+
+// writeEvent writes one event in the text/event-stream format
+func writeEvent(w http.ResponseWriter, event dto.ProductEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		fmt.Printf("[EventsHandler][writeEvent][ERROR] %v\n", err)
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
+
+// eventFilter reads the ids, seller, category and types query parameters.
+// List parameters take comma-separated values and may be repeated.
+func eventFilter(r *http.Request) (events.Filter, error) {
+	query := r.URL.Query()
+	filter := events.Filter{
+		SellerID: query.Get("seller"),
+		Category: query.Get("category"),
+	}
+
+	for _, value := range listParam(query["ids"]) {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			return filter, fmt.Errorf("invalid product ID %q", value)
+		}
+		if filter.ProductIDs == nil {
+			filter.ProductIDs = make(map[int]bool)
+		}
+		filter.ProductIDs[id] = true
+	}
+
+	for _, value := range listParam(query["types"]) {
+		switch value {
+		case dto.EventProductCreated, dto.EventProductUpdated, dto.EventProductDeleted:
+		default:
+			return filter, fmt.Errorf("invalid event type %q, must be %s, %s or %s", value, dto.EventProductCreated, dto.EventProductUpdated, dto.EventProductDeleted)
+		}
+		if filter.Types == nil {
+			filter.Types = make(map[string]bool)
+		}
+		filter.Types[value] = true
+	}
+	return filter, nil
+}
+
+// listParam splits repeated, comma-separated query values
+func listParam(values []string) []string {
+	var items []string
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+	}
+	return items
+}
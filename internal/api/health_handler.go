@@ -0,0 +1,48 @@
+package api
+
+import (
+	"fmt"
+	"item-comparison-api/internal/repository"
+	"net/http"
+	"sync/atomic"
+)
+
+type HealthHandler struct {
+	storage  repository.HealthChecker
+	draining atomic.Bool
+}
+
+// NewHealthHandler creates a new instance of HealthHandler
+func NewHealthHandler(storage repository.HealthChecker) *HealthHandler {
+	return &HealthHandler{storage: storage}
+}
+
+// SetDraining makes readiness fail so load balancers stop routing new
+// requests while in-flight ones finish during shutdown
+func (h *HealthHandler) SetDraining() {
+	h.draining.Store(true)
+}
+
+// Liveness reports that the process is up and serving HTTP
+func (h *HealthHandler) Liveness(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("ok"))
+}
+
+// Readiness reports whether the server can take traffic, which requires the
+// storage backend to be reachable and writable
+func (h *HealthHandler) Readiness(w http.ResponseWriter, r *http.Request) {
+	if h.draining.Load() {
+		http.Error(w, "shutting down", http.StatusServiceUnavailable)
+		return
+	}
+
+	if err := h.storage.CheckHealth(r.Context()); err != nil {
+		fmt.Printf("[HealthHandler][Readiness][ERROR] %v\n", err)
+		http.Error(w, "storage not ready: "+err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("ready"))
+}
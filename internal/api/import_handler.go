@@ -0,0 +1,145 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"item-comparison-api/internal/codec"
+	"item-comparison-api/internal/idempotency"
+	"item-comparison-api/internal/jobs"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+type ImportHandler struct {
+	manager *jobs.Manager
+}
+
+// NewImportHandler creates a new instance of ImportHandler
+func NewImportHandler(m *jobs.Manager) *ImportHandler {
+	return &ImportHandler{manager: m}
+}
+
+// CreateImport stores the uploaded products and queues their import
+func (h *ImportHandler) CreateImport(w http.ResponseWriter, r *http.Request) {
+	fmt.Printf("[ImportHandler][CreateImport] %s %s\n", r.Method, r.URL.String())
+	// Resolve the response encoding before doing any work
+	enc, ok := negotiate(w, r, entityEncodings)
+	if !ok {
+		return
+	}
+
+	// Validate that the request identifies a seller
+	sellerID := SellerFromContext(r.Context())
+	if sellerID == "" {
+		fmt.Printf("[ImportHandler][CreateImport][ERROR] Missing seller credentials\n")
+		http.Error(w, "Missing seller credentials", http.StatusBadRequest)
+		return
+	}
+
+	// Create new products by default, upsert replaces existing ones
+	mode := r.URL.Query().Get("mode")
+	if mode == "" {
+		mode = jobs.ModeCreate
+	}
+
+	// The format query parameter wins over Content-Type, which defaults to JSON
+	format := r.URL.Query().Get("format")
+	if contentType := r.Header.Get("Content-Type"); format == "" && contentType != "" {
+		mediaType, _, _ := mime.ParseMediaType(contentType)
+		f, ok := codec.FormatOf(mediaType)
+		if !ok {
+			fmt.Printf("[ImportHandler][CreateImport][ERROR] Unsupported content type: %q\n", contentType)
+			http.Error(w, "Content-Type must be one of: "+strings.Join([]string{codec.JSONMediaType, codec.CSVMediaType, codec.NDJSONMediaType}, ", "), http.StatusUnsupportedMediaType)
+			return
+		}
+		format = f
+	}
+	if format == "" {
+		format = codec.JSON
+	}
+
+	// CSV columns may be mapped to product fields with map=<column>=<field>
+	columns, err := columnMapping(r.URL.Query()["map"])
+	if err != nil {
+		fmt.Printf("[ImportHandler][CreateImport][ERROR] %v\n", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Retries with the same Idempotency-Key get the job the first attempt created
+	key := r.Header.Get(idempotency.Header)
+	if len(key) > idempotency.MaxKeyLength {
+		fmt.Printf("[ImportHandler][CreateImport][ERROR] Idempotency key too long\n")
+		http.Error(w, fmt.Sprintf("%s must be at most %d characters", idempotency.Header, idempotency.MaxKeyLength), http.StatusBadRequest)
+		return
+	}
+
+	job, replayed, err := h.manager.Create(sellerID, mode, format, columns, key, r.Body)
+	if err != nil {
+		fmt.Printf("[ImportHandler][CreateImport][ERROR] %v\n", err)
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, fmt.Sprintf("Upload exceeds %d bytes", tooLarge.Limit), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "Failed to create import: "+err.Error(), errorStatus(err))
+		return
+	}
+
+	// Respond with the queued job and where to poll it
+	if replayed {
+		fmt.Printf("[ImportHandler][CreateImport] Returning import %s for a retry of seller %s\n", job.ID, sellerID)
+		w.Header().Set(idempotency.ReplayedHeader, "true")
+	} else {
+		fmt.Printf("[ImportHandler][CreateImport] Queued import %s with %d rows for seller %s\n", job.ID, job.Total, sellerID)
+	}
+	w.Header().Set("Location", "/api/v1/imports/"+job.ID)
+	writeBody(w, r, enc, http.StatusAccepted, jobs.JobToResponse(job))
+}
+
+// columnMapping parses map parameters of the form <column>=<field>. The last
+// "=" separates the field, so column names may contain "=".
+func columnMapping(params []string) (map[string]string, error) {
+	if len(params) == 0 {
+		return nil, nil
+	}
+	columns := make(map[string]string, len(params))
+	for _, param := range params {
+		i := strings.LastIndex(param, "=")
+		if i <= 0 || i == len(param)-1 {
+			return nil, fmt.Errorf("invalid column mapping %q, use map=<column>=<field>", param)
+		}
+		columns[param[:i]] = param[i+1:]
+	}
+	return columns, nil
+}
+
+// GetImport reports the progress and row errors of an import
+func (h *ImportHandler) GetImport(w http.ResponseWriter, r *http.Request) {
+	fmt.Printf("[ImportHandler][GetImport] %s %s\n", r.Method, r.URL.String())
+	// Resolve the response encoding before doing any work
+	enc, ok := negotiate(w, r, entityEncodings)
+	if !ok {
+		return
+	}
+
+	// Validate that the request identifies a seller
+	sellerID := SellerFromContext(r.Context())
+	if sellerID == "" {
+		fmt.Printf("[ImportHandler][GetImport][ERROR] Missing seller credentials\n")
+		http.Error(w, "Missing seller credentials", http.StatusBadRequest)
+		return
+	}
+
+	// Extract the job ID from the URL
+	id := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+	job, err := h.manager.Get(id, sellerID)
+	if err != nil {
+		fmt.Printf("[ImportHandler][GetImport][ERROR] %v\n", err)
+		http.Error(w, "Failed to get import: "+err.Error(), errorStatus(err))
+		return
+	}
+
+	writeBody(w, r, enc, http.StatusOK, jobs.JobToResponse(job))
+}
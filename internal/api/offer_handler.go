@@ -0,0 +1,125 @@
+package api
+
+import (
+	"fmt"
+	"item-comparison-api/internal/dto"
+	"item-comparison-api/internal/offers"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type OfferHandler struct {
+	manager *offers.Manager
+}
+
+// NewOfferHandler creates a new instance of OfferHandler
+func NewOfferHandler(m *offers.Manager) *OfferHandler {
+	return &OfferHandler{manager: m}
+}
+
+// ListOffers returns the offers for a product, best first
+func (h *OfferHandler) ListOffers(w http.ResponseWriter, r *http.Request) {
+	fmt.Printf("[OfferHandler][ListOffers] %s %s\n", r.Method, r.URL.String())
+	// Resolve the response encoding before doing any work
+	enc, ok := negotiate(w, r, entityEncodings)
+	if !ok {
+		return
+	}
+
+	// Convert ID to integer
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Printf("[OfferHandler][ListOffers][ERROR] Invalid product ID: %v\n", err)
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	list, err := h.manager.List(r.Context(), id)
+	if err != nil {
+		fmt.Printf("[OfferHandler][ListOffers][ERROR] %v\n", err)
+		http.Error(w, "Failed to list offers: "+err.Error(), errorStatus(err))
+		return
+	}
+	writeBody(w, r, enc, http.StatusOK, offers.OffersToResponse(list))
+}
+
+// PutOffer attaches the caller's offer to a product, replacing an earlier one
+func (h *OfferHandler) PutOffer(w http.ResponseWriter, r *http.Request) {
+	fmt.Printf("[OfferHandler][PutOffer] %s %s\n", r.Method, r.URL.String())
+	// Resolve the response encoding before doing any work
+	enc, ok := negotiate(w, r, entityEncodings)
+	if !ok {
+		return
+	}
+
+	// Convert ID to integer
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Printf("[OfferHandler][PutOffer][ERROR] Invalid product ID: %v\n", err)
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	// Offers belong to the authenticated seller
+	sellerID := SellerFromContext(r.Context())
+	if sellerID == "" {
+		fmt.Printf("[OfferHandler][PutOffer][ERROR] Missing seller credentials\n")
+		http.Error(w, "Missing seller credentials", http.StatusBadRequest)
+		return
+	}
+
+	// Decode the offer from request body
+	var req dto.OfferRequest
+	if err := decodeBody(r, &req); err != nil {
+		fmt.Printf("[OfferHandler][PutOffer][ERROR] %v\n", err)
+		writeDecodeError(w, err)
+		return
+	}
+
+	offer, created, err := h.manager.Put(r.Context(), id, sellerID, offers.RequestToOffer(req))
+	if err != nil {
+		fmt.Printf("[OfferHandler][PutOffer][ERROR] %v\n", err)
+		http.Error(w, "Failed to save offer: "+err.Error(), errorStatus(err))
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		w.Header().Set("Location", fmt.Sprintf("/api/v1/products/%d/offers", id))
+		status = http.StatusCreated
+	}
+	writeBody(w, r, enc, status, offers.OfferToResponse(offer))
+}
+
+// DeleteOffer withdraws the caller's offer for a product
+func (h *OfferHandler) DeleteOffer(w http.ResponseWriter, r *http.Request) {
+	fmt.Printf("[OfferHandler][DeleteOffer] %s %s\n", r.Method, r.URL.String())
+	// Convert ID to integer
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Printf("[OfferHandler][DeleteOffer][ERROR] Invalid product ID: %v\n", err)
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	// Offers belong to the authenticated seller
+	sellerID := SellerFromContext(r.Context())
+	if sellerID == "" {
+		fmt.Printf("[OfferHandler][DeleteOffer][ERROR] Missing seller credentials\n")
+		http.Error(w, "Missing seller credentials", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.manager.Delete(id, sellerID); err != nil {
+		fmt.Printf("[OfferHandler][DeleteOffer][ERROR] %v\n", err)
+		http.Error(w, "Failed to delete offer: "+err.Error(), errorStatus(err))
+		return
+	}
+
+	// Respond with success
+	fmt.Printf("[OfferHandler][DeleteOffer] Deleted the offer of seller %s for product ID %d\n", sellerID, id)
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Offer deleted successfully"))
+}
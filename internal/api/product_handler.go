@@ -1,114 +1,172 @@
 package api
 
 import (
-	"encoding/json"
 	"fmt"
+	"io"
 	"item-comparison-api/internal/dto"
 	"item-comparison-api/internal/services"
+	"mime"
 	"net/http"
 	"strconv"
 	"strings"
 )
 
 type ProductHandler struct {
-	service services.ProductServiceInterface
+	service       services.ProductServiceInterface
+	maxCompareIDs int
 }
 
 // NewProductHandler creates a new instance of ProductHandler
-func NewProductHandler(s services.ProductServiceInterface) *ProductHandler {
-	return &ProductHandler{service: s}
+func NewProductHandler(s services.ProductServiceInterface, maxCompareIDs int) *ProductHandler {
+	return &ProductHandler{service: s, maxCompareIDs: maxCompareIDs}
 }
 
 // LoadProducts handles the loading of all products
 func (h *ProductHandler) LoadProducts(w http.ResponseWriter, r *http.Request) {
 	fmt.Printf("[ProductHandler][LoadProducts] %s %s\n", r.Method, r.URL.String())
+	// Resolve the response encoding before doing any work
+	enc, ok := negotiateFormat(w, r, listEncodings)
+	if !ok {
+		return
+	}
+
 	// Load all products
-	responseProducts, err := h.service.LoadProducts()
+	responseProducts, err := h.service.LoadProducts(r.Context())
 	if err != nil {
 		fmt.Printf("[ProductHandler][LoadProducts][ERROR] %v\n", err)
-		http.Error(w, "Failed to load products: "+err.Error(), http.StatusInternalServerError)
+		http.Error(w, "Failed to load products: "+err.Error(), errorStatus(err))
 		return
 	}
 
-	// Respond with the products in JSON format
+	// Respond with the products in the requested format
 	fmt.Printf("[ProductHandler][LoadProducts] Loaded %d products\n", len(responseProducts))
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(responseProducts)
+	writeBody(w, r, enc, http.StatusOK, responseProducts)
 }
 
 func (h *ProductHandler) SaveProducts(w http.ResponseWriter, r *http.Request) {
 	fmt.Printf("[ProductHandler][SaveProducts] %s %s\n", r.Method, r.URL.String())
-	// Validate if the x-seller-id header is present
-	sellerID := r.Header.Get("x-seller-id")
+	// Resolve the response encoding before doing any work
+	enc, ok := negotiate(w, r, entityEncodings)
+	if !ok {
+		return
+	}
+
+	// Validate that the request identifies a seller
+	sellerID := SellerFromContext(r.Context())
 	if sellerID == "" {
-		fmt.Printf("[ProductHandler][SaveProducts][ERROR] Missing x-seller-id header\n")
-		http.Error(w, "Missing x-seller-id header", http.StatusBadRequest)
+		fmt.Printf("[ProductHandler][SaveProducts][ERROR] Missing seller credentials\n")
+		http.Error(w, "Missing seller credentials", http.StatusBadRequest)
 		return
 	}
 
 	// Decode new products from request body
 	var newProductsRequest []dto.ProductRequest
-	if err := json.NewDecoder(r.Body).Decode(&newProductsRequest); err != nil {
+	if err := decodeBody(r, &newProductsRequest); err != nil {
 		fmt.Printf("[ProductHandler][SaveProducts][ERROR] %v\n", err)
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		writeDecodeError(w, err)
+		return
+	}
+
+	// In bulk mode every product is saved on its own and reported separately
+	if bulkMode(r) {
+		results := h.service.SaveProductsBulk(r.Context(), newProductsRequest, sellerID)
+		response := bulkResponse(results, http.StatusCreated)
+		fmt.Printf("[ProductHandler][SaveProducts] Bulk saved %d of %d products for seller %s\n", response.Succeeded, len(results), sellerID)
+		writeBody(w, r, enc, http.StatusMultiStatus, response)
 		return
 	}
 
 	// Save all products
-	if err := h.service.SaveProducts(newProductsRequest, sellerID); err != nil {
+	if err := h.service.SaveProducts(r.Context(), newProductsRequest, sellerID); err != nil {
 		fmt.Printf("[ProductHandler][SaveProducts][ERROR] %v\n", err)
-		http.Error(w, "Failed to save products: "+err.Error(), http.StatusInternalServerError)
+		http.Error(w, "Failed to save products: "+err.Error(), errorStatus(err))
 		return
 	}
 
 	// Respond with success
 	fmt.Printf("[ProductHandler][SaveProducts] Saved %d products for seller %s\n", len(newProductsRequest), sellerID)
-	w.WriteHeader(http.StatusCreated)
-	w.Write([]byte("Products saved successfully"))
+	writeBody(w, r, enc, http.StatusCreated, dto.MessageResponse{Message: "Products saved successfully"})
 }
 
 func (h *ProductHandler) UpdateProducts(w http.ResponseWriter, r *http.Request) {
 	fmt.Printf("[ProductHandler][UpdateProducts] %s %s\n", r.Method, r.URL.String())
-	// Validate if the x-seller-id header is present
-	sellerID := r.Header.Get("x-seller-id")
+	// Resolve the response encoding before doing any work
+	enc, ok := negotiate(w, r, entityEncodings)
+	if !ok {
+		return
+	}
+
+	// Validate that the request identifies a seller
+	sellerID := SellerFromContext(r.Context())
 	if sellerID == "" {
-		fmt.Printf("[ProductHandler][UpdateProducts][ERROR] Missing x-seller-id header\n")
-		http.Error(w, "Missing x-seller-id header", http.StatusBadRequest)
+		fmt.Printf("[ProductHandler][UpdateProducts][ERROR] Missing seller credentials\n")
+		http.Error(w, "Missing seller credentials", http.StatusBadRequest)
 		return
 	}
 
 	// Decode updated products from request body
 	var updatedProductsRequest []dto.ProductRequest
-	if err := json.NewDecoder(r.Body).Decode(&updatedProductsRequest); err != nil {
+	if err := decodeBody(r, &updatedProductsRequest); err != nil {
 		fmt.Printf("[ProductHandler][UpdateProducts][ERROR] %v\n", err)
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		writeDecodeError(w, err)
+		return
+	}
+
+	// If-Match applies to single-product updates, batches carry a version per product
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		fmt.Printf("[ProductHandler][UpdateProducts][ERROR] %v\n", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if version != 0 {
+		if len(updatedProductsRequest) != 1 {
+			fmt.Printf("[ProductHandler][UpdateProducts][ERROR] If-Match sent with %d products\n", len(updatedProductsRequest))
+			http.Error(w, "If-Match requires exactly one product, use the version field for batches", http.StatusBadRequest)
+			return
+		}
+		if version, err = resolveIfMatch(r.Context(), h.service, updatedProductsRequest[0].ID, version); err != nil {
+			fmt.Printf("[ProductHandler][UpdateProducts][ERROR] %v\n", err)
+			http.Error(w, "Failed to update products: "+err.Error(), errorStatus(err))
+			return
+		}
+		updatedProductsRequest[0].Version = version
+	}
+
+	// In bulk mode every product is updated on its own and reported separately
+	if bulkMode(r) {
+		results := h.service.UpdateProductsBulk(r.Context(), updatedProductsRequest, sellerID)
+		response := bulkResponse(results, http.StatusOK)
+		fmt.Printf("[ProductHandler][UpdateProducts] Bulk updated %d of %d products for seller %s\n", response.Succeeded, len(results), sellerID)
+		writeBody(w, r, enc, http.StatusMultiStatus, response)
 		return
 	}
 
 	// Update products
-	if err := h.service.UpdateProducts(updatedProductsRequest, sellerID); err != nil {
+	if err := h.service.UpdateProducts(r.Context(), updatedProductsRequest, sellerID); err != nil {
 		fmt.Printf("[ProductHandler][UpdateProducts][ERROR] %v\n", err)
-		http.Error(w, "Failed to update products: "+err.Error(), http.StatusInternalServerError)
+		http.Error(w, "Failed to update products: "+err.Error(), errorStatus(err))
 		return
 	}
 
 	// Respond with success
 	fmt.Printf("[ProductHandler][UpdateProducts] Updated %d products for seller %s\n", len(updatedProductsRequest), sellerID)
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte("Products updated successfully"))
+	writeBody(w, r, enc, http.StatusOK, dto.MessageResponse{Message: "Products updated successfully"})
 }
 
 func (h *ProductHandler) CompareProducts(w http.ResponseWriter, r *http.Request) {
 	fmt.Printf("[ProductHandler][CompareProducts] %s %s\n", r.Method, r.URL.String())
-	// Decode product IDs from request body
-	var req struct {
-		IDs []int `json:"ids"`
+	// Resolve the response encoding before doing any work
+	enc, ok := negotiateFormat(w, r, listEncodings)
+	if !ok {
+		return
 	}
 
 	// Decode product IDs from request body
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	var req dto.CompareRequest
+	if err := decodeBody(r, &req); err != nil {
 		fmt.Printf("[ProductHandler][CompareProducts][ERROR] %v\n", err)
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		writeDecodeError(w, err)
 		return
 	}
 
@@ -119,22 +177,34 @@ func (h *ProductHandler) CompareProducts(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	// Validate the number of IDs against the configured limit
+	if len(req.IDs) > h.maxCompareIDs {
+		fmt.Printf("[ProductHandler][CompareProducts][ERROR] Too many product IDs: %d\n", len(req.IDs))
+		http.Error(w, fmt.Sprintf("Too many product IDs, at most %d allowed", h.maxCompareIDs), http.StatusBadRequest)
+		return
+	}
+
 	// Compare products by IDs
-	responseProducts, err := h.service.CompareProducts(req.IDs)
+	responseProducts, err := h.service.CompareProducts(r.Context(), req.IDs)
 	if err != nil {
 		fmt.Printf("[ProductHandler][CompareProducts][ERROR] %v\n", err)
-		http.Error(w, "Failed to compare products: "+err.Error(), http.StatusInternalServerError)
+		http.Error(w, "Failed to compare products: "+err.Error(), errorStatus(err))
 		return
 	}
 
-	// Respond with the compared products in JSON format
+	// Respond with the compared products in the requested format
 	fmt.Printf("[ProductHandler][CompareProducts] Compared %d products\n", len(responseProducts))
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(responseProducts)
+	writeBody(w, r, enc, http.StatusOK, responseProducts)
 }
 
 func (h *ProductHandler) GetProduct(w http.ResponseWriter, r *http.Request) {
 	fmt.Printf("[ProductHandler][GetProduct] %s %s\n", r.Method, r.URL.String())
+	// Resolve the response encoding before doing any work
+	enc, ok := negotiate(w, r, entityEncodings)
+	if !ok {
+		return
+	}
+
 	// Extract product ID from URL parameters
 	idParam := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
 	if idParam == "" {
@@ -152,10 +222,10 @@ func (h *ProductHandler) GetProduct(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get product by ID
-	responseProduct, err := h.service.GetProductByID(id)
+	responseProduct, err := h.service.GetProductByID(r.Context(), id)
 	if err != nil {
 		fmt.Printf("[ProductHandler][GetProduct][ERROR] %v\n", err)
-		http.Error(w, "Failed to get product: "+err.Error(), http.StatusInternalServerError)
+		http.Error(w, "Failed to get product: "+err.Error(), errorStatus(err))
 		return
 	}
 
@@ -166,19 +236,62 @@ func (h *ProductHandler) GetProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Respond with the product in JSON format
+	// Answer conditional requests from the client's cache
+	setValidators(w, responseProduct)
+	if notModified(r, responseProduct) {
+		fmt.Printf("[ProductHandler][GetProduct] Product ID %d not modified\n", id)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	// Respond with the product in the negotiated format
 	fmt.Printf("[ProductHandler][GetProduct] Returned product ID %d\n", id)
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(responseProduct)
+	writeBody(w, r, enc, http.StatusOK, responseProduct)
+}
+
+// GetProductByGTIN returns the product with the barcode number in the URL,
+// which may be given as GTIN-8, -12, -13 or -14
+func (h *ProductHandler) GetProductByGTIN(w http.ResponseWriter, r *http.Request) {
+	fmt.Printf("[ProductHandler][GetProductByGTIN] %s %s\n", r.Method, r.URL.String())
+	enc, ok := negotiate(w, r, entityEncodings)
+	if !ok {
+		return
+	}
+
+	// Extract the GTIN from URL parameters
+	code := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+	responseProduct, err := h.service.GetProductByGTIN(r.Context(), code)
+	if err != nil {
+		fmt.Printf("[ProductHandler][GetProductByGTIN][ERROR] %v\n", err)
+		http.Error(w, "Failed to get product: "+err.Error(), errorStatus(err))
+		return
+	}
+
+	// Answer conditional requests from the client's cache
+	setValidators(w, responseProduct)
+	if notModified(r, responseProduct) {
+		fmt.Printf("[ProductHandler][GetProductByGTIN] Product ID %d not modified\n", responseProduct.ID)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	fmt.Printf("[ProductHandler][GetProductByGTIN] Returned product ID %d for GTIN %s\n", responseProduct.ID, code)
+	writeBody(w, r, enc, http.StatusOK, responseProduct)
 }
 
 func (h *ProductHandler) DeleteProduct(w http.ResponseWriter, r *http.Request) {
 	fmt.Printf("[ProductHandler][DeleteProduct] %s %s\n", r.Method, r.URL.String())
-	// Validate if the x-seller-id header is present
-	sellerID := r.Header.Get("x-seller-id")
+	// Resolve the response encoding before doing any work
+	enc, ok := negotiate(w, r, entityEncodings)
+	if !ok {
+		return
+	}
+
+	// Validate that the request identifies a seller
+	sellerID := SellerFromContext(r.Context())
 	if sellerID == "" {
-		fmt.Printf("[ProductHandler][DeleteProduct][ERROR] Missing x-seller-id header\n")
-		http.Error(w, "Missing x-seller-id header", http.StatusBadRequest)
+		fmt.Printf("[ProductHandler][DeleteProduct][ERROR] Missing seller credentials\n")
+		http.Error(w, "Missing seller credentials", http.StatusBadRequest)
 		return
 	}
 
@@ -198,15 +311,95 @@ func (h *ProductHandler) DeleteProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Only delete the version the client has seen when If-Match is sent
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		fmt.Printf("[ProductHandler][DeleteProduct][ERROR] %v\n", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if version, err = resolveIfMatch(r.Context(), h.service, id, version); err != nil {
+		fmt.Printf("[ProductHandler][DeleteProduct][ERROR] %v\n", err)
+		http.Error(w, "Failed to delete product: "+err.Error(), errorStatus(err))
+		return
+	}
+
 	// Delete product by ID
-	if err := h.service.DeleteProductByID(id, sellerID); err != nil {
+	if err := h.service.DeleteProductByID(r.Context(), id, sellerID, version); err != nil {
 		fmt.Printf("[ProductHandler][DeleteProduct][ERROR] %v\n", err)
-		http.Error(w, "Failed to delete product: "+err.Error(), http.StatusInternalServerError)
+		http.Error(w, "Failed to delete product: "+err.Error(), errorStatus(err))
 		return
 	}
 
 	// Respond with success
 	fmt.Printf("[ProductHandler][DeleteProduct] Deleted product ID %d for seller %s\n", id, sellerID)
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte("Product deleted successfully"))
+	writeBody(w, r, enc, http.StatusOK, dto.MessageResponse{Message: "Product deleted successfully"})
+}
+
+func (h *ProductHandler) PatchProduct(w http.ResponseWriter, r *http.Request) {
+	fmt.Printf("[ProductHandler][PatchProduct] %s %s\n", r.Method, r.URL.String())
+	// Resolve the response encoding before doing any work
+	enc, ok := negotiate(w, r, entityEncodings)
+	if !ok {
+		return
+	}
+
+	// Validate that the request identifies a seller
+	sellerID := SellerFromContext(r.Context())
+	if sellerID == "" {
+		fmt.Printf("[ProductHandler][PatchProduct][ERROR] Missing seller credentials\n")
+		http.Error(w, "Missing seller credentials", http.StatusBadRequest)
+		return
+	}
+
+	// Extract product ID from URL parameters
+	idParam := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		fmt.Printf("[ProductHandler][PatchProduct][ERROR] Invalid product ID: %v\n", err)
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	// The content type selects merge patch or JSON patch
+	patchType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || (patchType != services.MergePatchType && patchType != services.JSONPatchType) {
+		fmt.Printf("[ProductHandler][PatchProduct][ERROR] Unsupported content type: %q\n", r.Header.Get("Content-Type"))
+		w.Header().Set("Accept-Patch", services.MergePatchType+", "+services.JSONPatchType)
+		http.Error(w, "Content-Type must be "+services.MergePatchType+" or "+services.JSONPatchType, http.StatusUnsupportedMediaType)
+		return
+	}
+
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		fmt.Printf("[ProductHandler][PatchProduct][ERROR] %v\n", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if version, err = resolveIfMatch(r.Context(), h.service, id, version); err != nil {
+		fmt.Printf("[ProductHandler][PatchProduct][ERROR] %v\n", err)
+		http.Error(w, "Failed to patch product: "+err.Error(), errorStatus(err))
+		return
+	}
+
+	// Read the patch document from request body
+	patch, err := io.ReadAll(r.Body)
+	if err != nil {
+		fmt.Printf("[ProductHandler][PatchProduct][ERROR] %v\n", err)
+		writeDecodeError(w, err)
+		return
+	}
+
+	// Patch product by ID
+	responseProduct, err := h.service.PatchProduct(r.Context(), id, sellerID, patchType, patch, version)
+	if err != nil {
+		fmt.Printf("[ProductHandler][PatchProduct][ERROR] %v\n", err)
+		http.Error(w, "Failed to patch product: "+err.Error(), errorStatus(err))
+		return
+	}
+
+	// Respond with the patched product in the negotiated format
+	fmt.Printf("[ProductHandler][PatchProduct] Patched product ID %d to version %d\n", id, responseProduct.Version)
+	setValidators(w, responseProduct)
+	writeBody(w, r, enc, http.StatusOK, responseProduct)
 }
@@ -0,0 +1,145 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"item-comparison-api/internal/dto"
+	"item-comparison-api/internal/models"
+	"item-comparison-api/internal/repository"
+	"item-comparison-api/internal/services"
+)
+
+// TestProductWritesNegotiate checks that writes and deletes answer in the
+// format Accept asks for, and with 406 before changing anything otherwise
+func TestProductWritesNegotiate(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		handle     func(h *ProductHandler) http.HandlerFunc
+		wantStatus int
+	}{
+		{"save", http.MethodPost, "/api/v1/products", `[{"id":2,"name":"Phone","price":10}]`,
+			func(h *ProductHandler) http.HandlerFunc { return h.SaveProducts }, http.StatusCreated},
+		{"update", http.MethodPut, "/api/v1/products", `[{"id":1,"name":"Phone","price":20}]`,
+			func(h *ProductHandler) http.HandlerFunc { return h.UpdateProducts }, http.StatusOK},
+		{"delete", http.MethodDelete, "/api/v1/products/1", "",
+			func(h *ProductHandler) http.HandlerFunc { return h.DeleteProduct }, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		for _, accept := range []string{"application/json", "text/plain"} {
+			t.Run(tt.name+" "+accept, func(t *testing.T) {
+				ctx := context.Background()
+				repo := repository.NewProductRepoMemory()
+				if err := repo.SaveProducts(ctx, []models.Product{{ID: 1, Name: "Phone", Price: 10, SellerID: "s1", Version: 1}}); err != nil {
+					t.Fatal(err)
+				}
+				h := NewProductHandler(services.NewProductService(repo), 10)
+
+				req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+				req.Header.Set("Content-Type", "application/json")
+				req.Header.Set("Accept", accept)
+				req = req.WithContext(context.WithValue(req.Context(), sellerContextKey{}, "s1"))
+				rec := httptest.NewRecorder()
+				tt.handle(h)(rec, req)
+
+				if accept == "text/plain" {
+					if rec.Code != http.StatusNotAcceptable {
+						t.Fatalf("status = %d, want %d", rec.Code, http.StatusNotAcceptable)
+					}
+					products, _ := repo.LoadProducts(ctx)
+					if len(products) != 1 || products[0].Version != 1 {
+						t.Errorf("products changed despite 406: %v", products)
+					}
+					return
+				}
+				if rec.Code != tt.wantStatus {
+					t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+				}
+				var message dto.MessageResponse
+				if err := json.Unmarshal(rec.Body.Bytes(), &message); err != nil || message.Message == "" {
+					t.Errorf("body %q is not a JSON confirmation: %v", rec.Body, err)
+				}
+			})
+		}
+	}
+}
+
+// TestIfMatch checks the preconditions of writes to product 1, which is at
+// version 1, and to the missing product 2
+func TestIfMatch(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		ifMatch    string
+		wantStatus int
+		// wantVersion of product 1 afterwards, 0 when it was deleted
+		wantVersion int
+		wantCreated bool
+	}{
+		{"put current version", http.MethodPut, "/api/v1/products", `[{"id":1,"name":"Phone","price":20}]`, `"1"`, http.StatusOK, 2, false},
+		{"put stale version", http.MethodPut, "/api/v1/products", `[{"id":1,"name":"Phone","price":20}]`, `"2"`, http.StatusPreconditionFailed, 1, false},
+		{"put weak tag", http.MethodPut, "/api/v1/products", `[{"id":1,"name":"Phone","price":20}]`, `W/"1"`, http.StatusBadRequest, 1, false},
+		{"put any existing", http.MethodPut, "/api/v1/products", `[{"id":1,"name":"Phone","price":20}]`, "*", http.StatusOK, 2, false},
+		{"put any missing", http.MethodPut, "/api/v1/products", `[{"id":2,"name":"Phone","price":20}]`, "*", http.StatusPreconditionFailed, 1, false},
+		{"put missing without precondition", http.MethodPut, "/api/v1/products", `[{"id":2,"name":"Phone","price":20}]`, "", http.StatusOK, 1, true},
+		{"patch current version", http.MethodPatch, "/api/v1/products/1", `{"price":20}`, `"1"`, http.StatusOK, 2, false},
+		{"patch stale version", http.MethodPatch, "/api/v1/products/1", `{"price":20}`, `"3"`, http.StatusPreconditionFailed, 1, false},
+		{"patch any missing", http.MethodPatch, "/api/v1/products/2", `{"price":20}`, "*", http.StatusPreconditionFailed, 1, false},
+		{"delete stale version", http.MethodDelete, "/api/v1/products/1", "", `"2"`, http.StatusPreconditionFailed, 1, false},
+		{"delete any existing", http.MethodDelete, "/api/v1/products/1", "", "*", http.StatusOK, 0, false},
+		{"delete any missing", http.MethodDelete, "/api/v1/products/2", "", "*", http.StatusPreconditionFailed, 1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := repository.NewProductRepoMemory()
+			if err := repo.SaveProducts(ctx, []models.Product{{ID: 1, Name: "Phone", Price: 10, SellerID: "s1", Version: 1}}); err != nil {
+				t.Fatal(err)
+			}
+			h := NewProductHandler(services.NewProductService(repo), 10)
+			handle := map[string]http.HandlerFunc{
+				http.MethodPut:    h.UpdateProducts,
+				http.MethodPatch:  h.PatchProduct,
+				http.MethodDelete: h.DeleteProduct,
+			}[tt.method]
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.method == http.MethodPatch {
+				req.Header.Set("Content-Type", services.MergePatchType)
+			}
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			req = req.WithContext(context.WithValue(req.Context(), sellerContextKey{}, "s1"))
+			rec := httptest.NewRecorder()
+			handle(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			version := 0
+			if stored, err := repo.GetProductByID(ctx, 1); err == nil {
+				version = stored.Version
+			}
+			if version != tt.wantVersion {
+				t.Errorf("product 1 at version %d, want %d", version, tt.wantVersion)
+			}
+			_, err := repo.GetProductByID(ctx, 2)
+			if created := err == nil; created != tt.wantCreated {
+				t.Errorf("product 2 created = %v, want %v", created, tt.wantCreated)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"fmt"
+	"item-comparison-api/internal/services"
+	"item-comparison-api/internal/similarity"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type SimilarityHandler struct {
+	service      services.ProductServiceInterface
+	index        *similarity.Index
+	defaultLimit int
+	maxLimit     int
+}
+
+// NewSimilarityHandler creates a new instance of SimilarityHandler
+func NewSimilarityHandler(s services.ProductServiceInterface, index *similarity.Index, defaultLimit, maxLimit int) *SimilarityHandler {
+	return &SimilarityHandler{service: s, index: index, defaultLimit: defaultLimit, maxLimit: maxLimit}
+}
+
+// GetSimilarProducts returns the products most similar to a product, best first
+func (h *SimilarityHandler) GetSimilarProducts(w http.ResponseWriter, r *http.Request) {
+	fmt.Printf("[SimilarityHandler][GetSimilarProducts] %s %s\n", r.Method, r.URL.String())
+	// Resolve the response encoding before doing any work
+	enc, ok := negotiate(w, r, entityEncodings)
+	if !ok {
+		return
+	}
+
+	// Convert ID to integer
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Printf("[SimilarityHandler][GetSimilarProducts][ERROR] Invalid product ID: %v\n", err)
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	// Validate the number of results against the configured limit
+	limit := h.defaultLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > h.maxLimit {
+			fmt.Printf("[SimilarityHandler][GetSimilarProducts][ERROR] Invalid limit: %q\n", value)
+			http.Error(w, fmt.Sprintf("Invalid limit, must be between 1 and %d", h.maxLimit), http.StatusBadRequest)
+			return
+		}
+	}
+
+	// The product is read from the service, so a just written product is
+	// found before the index has caught up
+	product, err := h.service.GetProductByID(r.Context(), id)
+	if err != nil {
+		fmt.Printf("[SimilarityHandler][GetSimilarProducts][ERROR] %v\n", err)
+		http.Error(w, "Failed to get product: "+err.Error(), errorStatus(err))
+		return
+	}
+
+	similar := h.index.Similar(*product, limit)
+	fmt.Printf("[SimilarityHandler][GetSimilarProducts] Found %d similar products for product %d\n", len(similar), id)
+	writeBody(w, r, enc, http.StatusOK, similar)
+}
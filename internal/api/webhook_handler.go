@@ -0,0 +1,230 @@
+package api
+
+import (
+	"fmt"
+	"item-comparison-api/internal/dto"
+	"item-comparison-api/internal/webhooks"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type WebhookHandler struct {
+	manager *webhooks.Manager
+}
+
+// NewWebhookHandler creates a new instance of WebhookHandler
+func NewWebhookHandler(m *webhooks.Manager) *WebhookHandler {
+	return &WebhookHandler{manager: m}
+}
+
+// CreateWebhook subscribes a URL to product events. The response is the
+// only one that includes the signing secret.
+func (h *WebhookHandler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	fmt.Printf("[WebhookHandler][CreateWebhook] %s %s\n", r.Method, r.URL.String())
+	enc, sellerID, ok := h.begin(w, r, "CreateWebhook")
+	if !ok {
+		return
+	}
+
+	// Decode the subscription from request body
+	var req dto.WebhookRequest
+	if err := decodeBody(r, &req); err != nil {
+		fmt.Printf("[WebhookHandler][CreateWebhook][ERROR] %v\n", err)
+		writeDecodeError(w, err)
+		return
+	}
+
+	webhook, err := h.manager.Create(sellerID, webhooks.RequestToWebhook(req))
+	if err != nil {
+		fmt.Printf("[WebhookHandler][CreateWebhook][ERROR] %v\n", err)
+		http.Error(w, "Failed to create webhook: "+err.Error(), errorStatus(err))
+		return
+	}
+
+	response := webhooks.WebhookToResponse(webhook)
+	response.Secret = webhook.Secret
+	w.Header().Set("Location", "/api/v1/webhooks/"+webhook.ID)
+	writeBody(w, r, enc, http.StatusCreated, response)
+}
+
+// ListWebhooks lists the webhooks of the seller
+func (h *WebhookHandler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	fmt.Printf("[WebhookHandler][ListWebhooks] %s %s\n", r.Method, r.URL.String())
+	enc, sellerID, ok := h.begin(w, r, "ListWebhooks")
+	if !ok {
+		return
+	}
+
+	hooks := h.manager.List(sellerID)
+	responses := make([]dto.WebhookResponse, 0, len(hooks))
+	for _, webhook := range hooks {
+		responses = append(responses, webhooks.WebhookToResponse(webhook))
+	}
+	writeBody(w, r, enc, http.StatusOK, responses)
+}
+
+// GetWebhook returns one webhook of the seller
+func (h *WebhookHandler) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	fmt.Printf("[WebhookHandler][GetWebhook] %s %s\n", r.Method, r.URL.String())
+	enc, sellerID, ok := h.begin(w, r, "GetWebhook")
+	if !ok {
+		return
+	}
+
+	webhook, err := h.manager.Get(chi.URLParam(r, "id"), sellerID)
+	if err != nil {
+		fmt.Printf("[WebhookHandler][GetWebhook][ERROR] %v\n", err)
+		http.Error(w, "Failed to get webhook: "+err.Error(), errorStatus(err))
+		return
+	}
+	writeBody(w, r, enc, http.StatusOK, webhooks.WebhookToResponse(webhook))
+}
+
+// UpdateWebhook replaces the URL, event types, filter and active flag of a
+// webhook, and its secret when one is given
+func (h *WebhookHandler) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	fmt.Printf("[WebhookHandler][UpdateWebhook] %s %s\n", r.Method, r.URL.String())
+	enc, sellerID, ok := h.begin(w, r, "UpdateWebhook")
+	if !ok {
+		return
+	}
+
+	// Decode the subscription from request body
+	var req dto.WebhookRequest
+	if err := decodeBody(r, &req); err != nil {
+		fmt.Printf("[WebhookHandler][UpdateWebhook][ERROR] %v\n", err)
+		writeDecodeError(w, err)
+		return
+	}
+
+	webhook, err := h.manager.Update(chi.URLParam(r, "id"), sellerID, webhooks.RequestToWebhook(req))
+	if err != nil {
+		fmt.Printf("[WebhookHandler][UpdateWebhook][ERROR] %v\n", err)
+		http.Error(w, "Failed to update webhook: "+err.Error(), errorStatus(err))
+		return
+	}
+	writeBody(w, r, enc, http.StatusOK, webhooks.WebhookToResponse(webhook))
+}
+
+// DeleteWebhook removes a webhook and its delivery history
+func (h *WebhookHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	fmt.Printf("[WebhookHandler][DeleteWebhook] %s %s\n", r.Method, r.URL.String())
+	sellerID := SellerFromContext(r.Context())
+	if sellerID == "" {
+		fmt.Printf("[WebhookHandler][DeleteWebhook][ERROR] Missing seller credentials\n")
+		http.Error(w, "Missing seller credentials", http.StatusBadRequest)
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	if err := h.manager.Delete(id, sellerID); err != nil {
+		fmt.Printf("[WebhookHandler][DeleteWebhook][ERROR] %v\n", err)
+		http.Error(w, "Failed to delete webhook: "+err.Error(), errorStatus(err))
+		return
+	}
+
+	// Respond with success
+	fmt.Printf("[WebhookHandler][DeleteWebhook] Deleted webhook %s for seller %s\n", id, sellerID)
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Webhook deleted successfully"))
+}
+
+// PingWebhook queues a test delivery to the webhook
+func (h *WebhookHandler) PingWebhook(w http.ResponseWriter, r *http.Request) {
+	fmt.Printf("[WebhookHandler][PingWebhook] %s %s\n", r.Method, r.URL.String())
+	enc, sellerID, ok := h.begin(w, r, "PingWebhook")
+	if !ok {
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	delivery, err := h.manager.Ping(id, sellerID)
+	if err != nil {
+		fmt.Printf("[WebhookHandler][PingWebhook][ERROR] %v\n", err)
+		http.Error(w, "Failed to ping webhook: "+err.Error(), errorStatus(err))
+		return
+	}
+	w.Header().Set("Location", "/api/v1/webhooks/"+id+"/deliveries/"+delivery.ID)
+	writeBody(w, r, enc, http.StatusAccepted, webhooks.DeliveryToResponse(delivery))
+}
+
+// ListDeliveries returns the delivery history of a webhook, newest first
+func (h *WebhookHandler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	fmt.Printf("[WebhookHandler][ListDeliveries] %s %s\n", r.Method, r.URL.String())
+	enc, sellerID, ok := h.begin(w, r, "ListDeliveries")
+	if !ok {
+		return
+	}
+
+	deliveries, err := h.manager.Deliveries(chi.URLParam(r, "id"), sellerID)
+	if err != nil {
+		fmt.Printf("[WebhookHandler][ListDeliveries][ERROR] %v\n", err)
+		http.Error(w, "Failed to list deliveries: "+err.Error(), errorStatus(err))
+		return
+	}
+	writeBody(w, r, enc, http.StatusOK, webhooks.DeliveriesToResponse(deliveries))
+}
+
+// GetDelivery returns one delivery with its attempts
+func (h *WebhookHandler) GetDelivery(w http.ResponseWriter, r *http.Request) {
+	fmt.Printf("[WebhookHandler][GetDelivery] %s %s\n", r.Method, r.URL.String())
+	enc, sellerID, ok := h.begin(w, r, "GetDelivery")
+	if !ok {
+		return
+	}
+
+	delivery, err := h.manager.Delivery(chi.URLParam(r, "id"), chi.URLParam(r, "deliveryID"), sellerID)
+	if err != nil {
+		fmt.Printf("[WebhookHandler][GetDelivery][ERROR] %v\n", err)
+		http.Error(w, "Failed to get delivery: "+err.Error(), errorStatus(err))
+		return
+	}
+	writeBody(w, r, enc, http.StatusOK, webhooks.DeliveryToResponse(delivery))
+}
+
+// ListDeadLetters returns the deliveries of the seller's webhooks that ran
+// out of attempts
+func (h *WebhookHandler) ListDeadLetters(w http.ResponseWriter, r *http.Request) {
+	fmt.Printf("[WebhookHandler][ListDeadLetters] %s %s\n", r.Method, r.URL.String())
+	enc, sellerID, ok := h.begin(w, r, "ListDeadLetters")
+	if !ok {
+		return
+	}
+	writeBody(w, r, enc, http.StatusOK, webhooks.DeliveriesToResponse(h.manager.DeadLetters(sellerID)))
+}
+
+// Redeliver queues a finished delivery again with a fresh set of attempts
+func (h *WebhookHandler) Redeliver(w http.ResponseWriter, r *http.Request) {
+	fmt.Printf("[WebhookHandler][Redeliver] %s %s\n", r.Method, r.URL.String())
+	enc, sellerID, ok := h.begin(w, r, "Redeliver")
+	if !ok {
+		return
+	}
+
+	delivery, err := h.manager.Redeliver(chi.URLParam(r, "id"), chi.URLParam(r, "deliveryID"), sellerID)
+	if err != nil {
+		fmt.Printf("[WebhookHandler][Redeliver][ERROR] %v\n", err)
+		http.Error(w, "Failed to redeliver: "+err.Error(), errorStatus(err))
+		return
+	}
+	writeBody(w, r, enc, http.StatusAccepted, webhooks.DeliveryToResponse(delivery))
+}
+
+// begin resolves the response encoding and the seller of a request, and
+// answers the request itself when either is missing
+func (h *WebhookHandler) begin(w http.ResponseWriter, r *http.Request, method string) (*encoding, string, bool) {
+	enc, ok := negotiate(w, r, entityEncodings)
+	if !ok {
+		return nil, "", false
+	}
+
+	// Validate that the request identifies a seller
+	sellerID := SellerFromContext(r.Context())
+	if sellerID == "" {
+		fmt.Printf("[WebhookHandler][%s][ERROR] Missing seller credentials\n", method)
+		http.Error(w, "Missing seller credentials", http.StatusBadRequest)
+		return nil, "", false
+	}
+	return enc, sellerID, true
+}
@@ -0,0 +1,93 @@
+package codec
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"item-comparison-api/internal/dto"
+)
+
+// Formats products can be exported and imported in
+const (
+	JSON   = "json"
+	CSV    = "csv"
+	NDJSON = "ndjson"
+)
+
+// Media types of the formats
+const (
+	JSONMediaType   = "application/json"
+	CSVMediaType    = "text/csv"
+	NDJSONMediaType = "application/x-ndjson"
+)
+
+// ErrInvalidRow is wrapped by Reader errors for a row that can't be decoded
+// into a product. Reading can go on with the next row.
+var ErrInvalidRow = errors.New("invalid row")
+
+// MediaType returns the media type of a format
+func MediaType(format string) string {
+	switch format {
+	case CSV:
+		return CSVMediaType
+	case NDJSON:
+		return NDJSONMediaType
+	default:
+		return JSONMediaType
+	}
+}
+
+// FormatOf returns the format of a media type without parameters
+func FormatOf(mediaType string) (string, bool) {
+	switch mediaType {
+	case JSONMediaType:
+		return JSON, true
+	case CSVMediaType:
+		return CSV, true
+	case NDJSONMediaType, "application/ndjson":
+		return NDJSON, true
+	default:
+		return "", false
+	}
+}
+
+// Valid reports whether format is a known format name
+func Valid(format string) bool {
+	return format == JSON || format == CSV || format == NDJSON
+}
+
+// Reader decodes products from an upload one row at a time, so uploads never
+// have to be held in memory as a whole
+type Reader interface {
+	// Next returns the next product or io.EOF after the last one. Errors
+	// wrapping ErrInvalidRow only affect that row, any other error means the
+	// rest of the upload can't be read.
+	Next() (dto.ProductRequest, error)
+}
+
+// NewReader creates a Reader for the format. columns maps CSV header names to
+// product fields and is ignored by the other formats.
+func NewReader(format string, r io.Reader, columns map[string]string) (Reader, error) {
+	switch format {
+	case JSON, "":
+		return newJSONReader(r)
+	case CSV:
+		return newCSVReader(r, columns)
+	case NDJSON:
+		return newNDJSONReader(r), nil
+	default:
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+}
+
+// Write encodes products in the format
+func Write(format string, w io.Writer, products []dto.ProductResponse) error {
+	switch format {
+	case CSV:
+		return WriteCSV(w, products)
+	case NDJSON:
+		return WriteNDJSON(w, products)
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+}
@@ -0,0 +1,106 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/csv"
+	"io"
+	"maps"
+	"strings"
+	"testing"
+
+	"item-comparison-api/internal/dto"
+)
+
+func TestEscapeCell(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"Phone", "Phone"},
+		{"", ""},
+		{"=1+1", "'=1+1"},
+		{"+49 30 1234", "'+49 30 1234"},
+		{"-5", "'-5"},
+		{"@SUM(A1)", "'@SUM(A1)"},
+		{"\t=cmd", "'\t=cmd"},
+		{"\r=cmd", "'\r=cmd"},
+		{"'quoted", "'quoted"},
+		{"'=already quoted", "''=already quoted"},
+		{"a=b", "a=b"},
+	}
+
+	for _, tt := range tests {
+		got := escapeCell(tt.value)
+		if got != tt.want {
+			t.Errorf("escapeCell(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+		if back := unescapeCell(got); back != tt.value {
+			t.Errorf("unescapeCell(%q) = %q, want %q", got, back, tt.value)
+		}
+	}
+}
+
+// testProducts covers cells that need escaping and specifications only some
+// products have
+func testProducts() []dto.ProductResponse {
+	return []dto.ProductResponse{
+		{ID: 1, Name: "=HYPERLINK(\"http://example.com\")", Description: "Line one, \"two\"\nline three", Price: 9.99,
+			Brand: "@brand", GTIN: "4006381333931", MPN: "-X1", ImageUrl: "http://example.com/1.jpg", Rating: 4.5,
+			Specifications: dto.Specifications{"color": "+red"}, SellerID: "s1", Version: 2},
+		{ID: 2, Name: "Plain", Price: 100, Brand: "Acme", Rating: 3,
+			Specifications: dto.Specifications{"storage": "128GB", "category": "phones"}, SellerID: "s1", Version: 1},
+	}
+}
+
+// TestRoundTrip writes products as CSV and NDJSON and reads them back as an
+// import would
+func TestRoundTrip(t *testing.T) {
+	for _, format := range []string{CSV, NDJSON} {
+		t.Run(format, func(t *testing.T) {
+			products := testProducts()
+			var buf bytes.Buffer
+			if err := Write(format, &buf, products); err != nil {
+				t.Fatal(err)
+			}
+			reader, err := NewReader(format, &buf, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, want := range products {
+				got, err := reader.Next()
+				if err != nil {
+					t.Fatalf("Next() error = %v", err)
+				}
+				if got.ID != want.ID || got.Name != want.Name || got.Description != want.Description ||
+					got.Price != want.Price || got.Brand != want.Brand || got.GTIN != want.GTIN || got.MPN != want.MPN ||
+					got.ImageUrl != want.ImageUrl || got.Rating != want.Rating || got.Version != want.Version {
+					t.Errorf("read %+v, want %+v", got, want)
+				}
+				if !maps.Equal(got.Specifications, want.Specifications) {
+					t.Errorf("read specifications %v, want %v", got.Specifications, want.Specifications)
+				}
+			}
+			if _, err := reader.Next(); err != io.EOF {
+				t.Errorf("Next() after the last product = %v, want io.EOF", err)
+			}
+		})
+	}
+}
+
+// TestWriteCSVEscapesFormulas checks the cells as a spreadsheet sees them
+func TestWriteCSVEscapesFormulas(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, testProducts()[:1]); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, cell := range records[1] {
+		if isFormula(cell) && !strings.HasPrefix(cell, "'") {
+			t.Errorf("column %s = %q is not escaped", records[0][i], cell)
+		}
+	}
+}
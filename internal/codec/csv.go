@@ -0,0 +1,252 @@
+package codec
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"item-comparison-api/internal/dto"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SpecPrefix prefixes the columns holding specification values, e.g. spec.color
+const SpecPrefix = "spec."
+
+// Ignore is the column mapping target that skips a column
+const Ignore = "-"
+
+// productColumns are the CSV columns of a product, in export order
+var productColumns = []string{"id", "name", "description", "price", "brand", "gtin", "mpn", "image_url", "rating", "seller_id", "version", "updated_at"}
+
+// readOnlyColumns are exported but ignored on import, so exports can be re-imported
+var readOnlyColumns = map[string]bool{"seller_id": true, "updated_at": true}
+
+// formulaPrefixes start cells spreadsheets evaluate as formulas
+const formulaPrefixes = "=+-@\t\r"
+
+// WriteCSV writes a header row and one row per product. Specifications are
+// flattened into one spec.<key> column per key found in any product. Text
+// cells that a spreadsheet would run as a formula are prefixed with '.
+func WriteCSV(w io.Writer, products []dto.ProductResponse) error {
+	// Collect the specification keys of every product
+	keySet := make(map[string]bool)
+	for _, p := range products {
+		for key := range p.Specifications {
+			keySet[key] = true
+		}
+	}
+	keys := make([]string, 0, len(keySet))
+	for key := range keySet {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	cw := csv.NewWriter(w)
+	header := append([]string(nil), productColumns...)
+	for _, key := range keys {
+		header = append(header, SpecPrefix+key)
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	record := make([]string, len(header))
+	for _, p := range products {
+		record = append(record[:0],
+			strconv.Itoa(p.ID),
+			escapeCell(p.Name),
+			escapeCell(p.Description),
+			formatFloat(p.Price),
+			escapeCell(p.Brand),
+			escapeCell(p.GTIN),
+			escapeCell(p.MPN),
+			escapeCell(p.ImageUrl),
+			formatFloat(p.Rating),
+			escapeCell(p.SellerID),
+			strconv.Itoa(p.Version),
+			p.UpdatedAt,
+		)
+		for _, key := range keys {
+			record = append(record, escapeCell(p.Specifications[key]))
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvReader decodes rows using the header row to find each column's field
+type csvReader struct {
+	r       *csv.Reader
+	header  []string
+	targets []string
+}
+
+// newCSVReader reads the header row and resolves the field of every column.
+// Columns named like a product field or spec.<key> map to themselves unless
+// columns says otherwise; any other column must be mapped or ignored with "-".
+func newCSVReader(r io.Reader, columns map[string]string) (*csvReader, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, errors.New("upload has no header row")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header row: %w", err)
+	}
+
+	// Drop a UTF-8 byte order mark left by spreadsheet exports
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+
+	targets := make([]string, len(header))
+	seen := make(map[string]string)
+	for i, column := range header {
+		column = strings.TrimSpace(column)
+		header[i] = column
+
+		target, mapped := columns[column]
+		if !mapped {
+			switch {
+			case readOnlyColumns[column]:
+				target = Ignore
+			default:
+				target = column
+			}
+		}
+		if target != Ignore && !validTarget(target) {
+			return nil, fmt.Errorf("column %q is not a product field, map it to one or to %q to ignore it", column, Ignore)
+		}
+		if other, ok := seen[target]; ok && target != Ignore {
+			return nil, fmt.Errorf("columns %q and %q both map to %s", other, column, target)
+		}
+		seen[target] = column
+		targets[i] = target
+	}
+
+	// Mappings for columns that aren't in the header are most likely typos
+	for column := range columns {
+		if !contains(header, column) {
+			return nil, fmt.Errorf("mapped column %q is not in the header row", column)
+		}
+	}
+
+	cr.ReuseRecord = true
+	return &csvReader{r: cr, header: header, targets: targets}, nil
+}
+
+// Next decodes the next row. Rows with the wrong number of fields or values
+// that don't parse only fail their row.
+func (c *csvReader) Next() (dto.ProductRequest, error) {
+	var product dto.ProductRequest
+	record, err := c.r.Read()
+	if err == io.EOF {
+		return product, io.EOF
+	}
+	if errors.Is(err, csv.ErrFieldCount) {
+		return product, fmt.Errorf("%w: %v", ErrInvalidRow, err)
+	}
+	if err != nil {
+		return product, err
+	}
+
+	for i, value := range record {
+		if err := setField(&product, c.targets[i], unescapeCell(strings.TrimSpace(value))); err != nil {
+			return product, fmt.Errorf("%w: column %q: %v", ErrInvalidRow, c.header[i], err)
+		}
+	}
+	return product, nil
+}
+
+// setField stores a CSV value in the product field named by target. Empty
+// values leave the field unset.
+func setField(p *dto.ProductRequest, target, value string) error {
+	if target == Ignore || value == "" {
+		return nil
+	}
+
+	var err error
+	switch target {
+	case "id":
+		p.ID, err = strconv.Atoi(value)
+	case "name":
+		p.Name = value
+	case "description":
+		p.Description = value
+	case "price":
+		p.Price, err = parseFloat(value)
+	case "brand":
+		p.Brand = value
+	case "gtin":
+		p.GTIN = value
+	case "mpn":
+		p.MPN = value
+	case "image_url":
+		p.ImageUrl = value
+	case "rating":
+		p.Rating, err = parseFloat(value)
+	case "version":
+		p.Version, err = strconv.Atoi(value)
+	default:
+		if p.Specifications == nil {
+			p.Specifications = make(map[string]string)
+		}
+		p.Specifications[strings.TrimPrefix(target, SpecPrefix)] = value
+	}
+	return err
+}
+
+// validTarget reports whether target is a writable product field or spec key
+func validTarget(target string) bool {
+	if strings.HasPrefix(target, SpecPrefix) {
+		return len(target) > len(SpecPrefix)
+	}
+	return contains(productColumns, target) && !readOnlyColumns[target]
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// escapeCell prefixes a cell that starts like a formula with ', after any
+// quotes it already starts with, so unescapeCell can restore every value
+func escapeCell(value string) string {
+	if isFormula(value) {
+		return "'" + value
+	}
+	return value
+}
+
+// unescapeCell removes the quote escapeCell added
+func unescapeCell(value string) string {
+	if strings.HasPrefix(value, "'") && isFormula(value[1:]) {
+		return value[1:]
+	}
+	return value
+}
+
+func isFormula(value string) bool {
+	value = strings.TrimLeft(value, "'")
+	return value != "" && strings.ContainsRune(formulaPrefixes, rune(value[0]))
+}
+
+func formatFloat(f float32) string {
+	return strconv.FormatFloat(float64(f), 'f', -1, 32)
+}
+
+func parseFloat(s string) (float32, error) {
+	f, err := strconv.ParseFloat(s, 32)
+	return float32(f), err
+}
@@ -0,0 +1,46 @@
+package codec
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"item-comparison-api/internal/dto"
+)
+
+// jsonReader streams the elements of a JSON array
+type jsonReader struct {
+	dec *json.Decoder
+	row int
+}
+
+// newJSONReader positions the decoder on the first element of the array
+func newJSONReader(r io.Reader) (*jsonReader, error) {
+	dec := json.NewDecoder(r)
+	token, err := dec.Token()
+	if err != nil {
+		return nil, fmt.Errorf("upload is not a JSON array: %w", err)
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return nil, fmt.Errorf("upload is not a JSON array")
+	}
+	return &jsonReader{dec: dec}, nil
+}
+
+// Next decodes the next element. Malformed JSON fails the whole upload; an
+// element that doesn't fit a product only fails its row.
+func (j *jsonReader) Next() (dto.ProductRequest, error) {
+	var product dto.ProductRequest
+	if !j.dec.More() {
+		return product, io.EOF
+	}
+	j.row++
+
+	var raw json.RawMessage
+	if err := j.dec.Decode(&raw); err != nil {
+		return product, fmt.Errorf("row %d: %w", j.row, err)
+	}
+	if err := json.Unmarshal(raw, &product); err != nil {
+		return product, fmt.Errorf("%w: %v", ErrInvalidRow, err)
+	}
+	return product, nil
+}
@@ -0,0 +1,54 @@
+package codec
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"item-comparison-api/internal/dto"
+)
+
+// ndjsonReader decodes one product per line, blank lines are skipped
+type ndjsonReader struct {
+	r *bufio.Reader
+}
+
+func newNDJSONReader(r io.Reader) *ndjsonReader {
+	return &ndjsonReader{r: bufio.NewReader(r)}
+}
+
+// Next decodes the next non-blank line. Every line stands on its own, so a
+// malformed line only fails its row.
+func (n *ndjsonReader) Next() (dto.ProductRequest, error) {
+	var product dto.ProductRequest
+	for {
+		line, err := n.r.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return product, err
+		}
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			if err == io.EOF {
+				return product, io.EOF
+			}
+			continue
+		}
+
+		if err := json.Unmarshal(line, &product); err != nil {
+			return product, fmt.Errorf("%w: %v", ErrInvalidRow, err)
+		}
+		return product, nil
+	}
+}
+
+// WriteNDJSON encodes one product per line
+func WriteNDJSON(w io.Writer, products []dto.ProductResponse) error {
+	enc := json.NewEncoder(w)
+	for _, p := range products {
+		if err := enc.Encode(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package comparisons
+
+import (
+	"errors"
+	"item-comparison-api/internal/dto"
+	"maps"
+	"slices"
+	"time"
+)
+
+var (
+	// ErrComparisonNotFound is returned for unknown or expired comparison IDs
+	ErrComparisonNotFound = errors.New("comparison not found")
+	// ErrForbidden is returned when a caller deletes a comparison of someone else
+	ErrForbidden = errors.New("unauthorized: you do not own this comparison")
+	// ErrInvalidComparison is returned for comparisons with invalid IDs, weights or notes
+	ErrInvalidComparison = errors.New("invalid comparison")
+)
+
+// Comparison is a saved comparison. Snapshot holds the products as they were
+// when it was saved. Comparisons without an owner expire at ExpiresAt.
+type Comparison struct {
+	ID         string                `json:"id"`
+	OwnerID    string                `json:"owner_id,omitempty"`
+	ProductIDs []int                 `json:"product_ids"`
+	Weights    dto.Weights           `json:"weights,omitempty"`
+	Notes      string                `json:"notes,omitempty"`
+	Snapshot   []dto.ProductResponse `json:"snapshot"`
+	CreatedAt  string                `json:"created_at"`
+	ExpiresAt  string                `json:"expires_at,omitempty"`
+}
+
+// expired reports whether the comparison is past its expiry
+func (c *Comparison) expired(now time.Time) bool {
+	if c.ExpiresAt == "" {
+		return false
+	}
+	expiresAt, err := time.Parse(time.RFC3339, c.ExpiresAt)
+	return err == nil && !expiresAt.After(now)
+}
+
+// clone returns a copy that shares no slices or maps with c
+func (c *Comparison) clone() *Comparison {
+	copied := *c
+	copied.ProductIDs = slices.Clone(c.ProductIDs)
+	copied.Weights = maps.Clone(c.Weights)
+	copied.Snapshot = slices.Clone(c.Snapshot)
+	return &copied
+}
@@ -0,0 +1,73 @@
+package comparisons
+
+import (
+	"item-comparison-api/internal/dto"
+	"slices"
+	"strconv"
+)
+
+// Diff statuses of a compared product
+const (
+	DiffUnchanged = "unchanged"
+	DiffChanged   = "changed"
+	DiffDeleted   = "deleted"
+)
+
+// Diff compares the snapshot of every product with its current state.
+// Products missing from current were deleted since the snapshot.
+func Diff(snapshot, current []dto.ProductResponse) []dto.ProductDiff {
+	byID := make(map[int]dto.ProductResponse, len(current))
+	for _, p := range current {
+		byID[p.ID] = p
+	}
+
+	diffs := make([]dto.ProductDiff, 0, len(snapshot))
+	for _, old := range snapshot {
+		diff := dto.ProductDiff{ProductID: old.ID, SnapshotVersion: old.Version}
+		now, ok := byID[old.ID]
+		switch {
+		case !ok:
+			diff.Status = DiffDeleted
+		default:
+			diff.CurrentVersion = now.Version
+			diff.Fields = diffFields(old, now)
+			diff.Status = DiffUnchanged
+			if len(diff.Fields) > 0 {
+				diff.Status = DiffChanged
+			}
+		}
+		diffs = append(diffs, diff)
+	}
+	return diffs
+}
+
+// diffFields lists the fields that differ between two states of a product
+func diffFields(old, now dto.ProductResponse) []dto.FieldDiff {
+	var fields []dto.FieldDiff
+	add := func(field, before, after string) {
+		if before != after {
+			fields = append(fields, dto.FieldDiff{Field: field, Old: before, New: after})
+		}
+	}
+	formatFloat := func(f float32) string {
+		return strconv.FormatFloat(float64(f), 'f', -1, 32)
+	}
+
+	add("name", old.Name, now.Name)
+	add("description", old.Description, now.Description)
+	add("price", formatFloat(old.Price), formatFloat(now.Price))
+	add("brand", old.Brand, now.Brand)
+	add("gtin", old.GTIN, now.GTIN)
+	add("mpn", old.MPN, now.MPN)
+	add("image_url", old.ImageUrl, now.ImageUrl)
+	add("rating", formatFloat(old.Rating), formatFloat(now.Rating))
+	add("seller_id", old.SellerID, now.SellerID)
+
+	// Specification keys of both states, each once and in order
+	keys := append(old.Specifications.Keys(), now.Specifications.Keys()...)
+	slices.Sort(keys)
+	for _, key := range slices.Compact(keys) {
+		add("specifications."+key, old.Specifications[key], now.Specifications[key])
+	}
+	return fields
+}
@@ -0,0 +1,269 @@
+// Package comparisons saves product comparisons under short IDs that can be
+// shared. A saved comparison keeps a snapshot of its products, so it can be
+// shown with the current data and what changed since. Comparisons saved
+// without credentials expire after a while.
+package comparisons
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"item-comparison-api/internal/dto"
+	"item-comparison-api/internal/repository"
+	"item-comparison-api/internal/services"
+	"math"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// idAlphabet and idLength define the short comparison IDs
+const (
+	idAlphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	idLength   = 8
+)
+
+// Options tune saved comparisons. Comparisons hold at most MaxIDs products
+// and MaxNotesLength bytes of notes. Anonymous comparisons expire after
+// AnonymousTTL and are removed every CleanupInterval.
+type Options struct {
+	MaxIDs          int
+	MaxNotesLength  int
+	AnonymousTTL    time.Duration
+	CleanupInterval time.Duration
+}
+
+// Manager stores saved comparisons and removes expired ones
+type Manager struct {
+	dir      string
+	products services.ProductServiceInterface
+	opts     Options
+
+	mu          sync.Mutex
+	comparisons map[string]*Comparison
+	wg          sync.WaitGroup
+}
+
+// NewManager creates a Manager keeping its comparisons in dir and loads the
+// comparisons saved there
+func NewManager(dir string, products services.ProductServiceInterface, opts Options) (*Manager, error) {
+	fmt.Printf("[ComparisonManager][NewManager] Initializing with directory: %s\n", dir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create comparisons directory: %w", err)
+	}
+	comparisons, err := loadFiles[Comparison](dir)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Manager{
+		dir:         dir,
+		products:    products,
+		opts:        opts,
+		comparisons: make(map[string]*Comparison, len(comparisons)),
+	}
+	for _, c := range comparisons {
+		m.comparisons[c.ID] = c
+	}
+	fmt.Printf("[ComparisonManager][NewManager] Loaded %d comparisons\n", len(comparisons))
+	return m, nil
+}
+
+// Start launches the removal of expired comparisons until ctx is canceled
+func (m *Manager) Start(ctx context.Context) {
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		ticker := time.NewTicker(m.opts.CleanupInterval)
+		defer ticker.Stop()
+		for {
+			m.cleanup(time.Now())
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Wait blocks until the cleanup has stopped
+func (m *Manager) Wait() {
+	m.wg.Wait()
+}
+
+// Create saves a comparison with a snapshot of its products. Comparisons
+// without an owner get an expiry.
+func (m *Manager) Create(ctx context.Context, ownerID string, c Comparison) (*Comparison, error) {
+	fmt.Printf("[ComparisonManager][Create] Called with ownerID: %q, IDs: %v\n", ownerID, c.ProductIDs)
+	if err := m.validate(&c); err != nil {
+		return nil, err
+	}
+
+	snapshot, err := m.products.CompareProducts(ctx, c.ProductIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	c.OwnerID = ownerID
+	c.Snapshot = snapshot
+	c.CreatedAt = now.Format(time.RFC3339)
+	if ownerID == "" {
+		c.ExpiresAt = now.Add(m.opts.AnonymousTTL).Format(time.RFC3339)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for {
+		id, err := newID()
+		if err != nil {
+			return nil, err
+		}
+		if _, taken := m.comparisons[id]; !taken {
+			c.ID = id
+			break
+		}
+	}
+	if err := saveFile(m.dir, c.ID, &c); err != nil {
+		return nil, err
+	}
+	m.comparisons[c.ID] = &c
+
+	fmt.Printf("[ComparisonManager][Create] Saved comparison %s\n", c.ID)
+	return c.clone(), nil
+}
+
+// Get returns a comparison, which anyone knowing its ID may see, together
+// with the current state of its products. Deleted products are left out.
+func (m *Manager) Get(ctx context.Context, id string) (*Comparison, []dto.ProductResponse, error) {
+	m.mu.Lock()
+	c, ok := m.comparisons[id]
+	if ok {
+		c = c.clone()
+	}
+	m.mu.Unlock()
+	if !ok || c.expired(time.Now()) {
+		return nil, nil, fmt.Errorf("%w: %s", ErrComparisonNotFound, id)
+	}
+
+	current := make([]dto.ProductResponse, 0, len(c.ProductIDs))
+	for _, productID := range c.ProductIDs {
+		product, err := m.products.GetProductByID(ctx, productID)
+		if errors.Is(err, repository.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		current = append(current, *product)
+	}
+	return c, current, nil
+}
+
+// List returns the owner's comparisons, newest first
+func (m *Manager) List(ownerID string) []*Comparison {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	comparisons := []*Comparison{}
+	for _, c := range m.comparisons {
+		if c.OwnerID == ownerID {
+			comparisons = append(comparisons, c.clone())
+		}
+	}
+	sort.Slice(comparisons, func(i, j int) bool {
+		if comparisons[i].CreatedAt != comparisons[j].CreatedAt {
+			return comparisons[i].CreatedAt > comparisons[j].CreatedAt
+		}
+		return comparisons[i].ID > comparisons[j].ID
+	})
+	return comparisons
+}
+
+// Delete removes a comparison of the owner
+func (m *Manager) Delete(id, ownerID string) error {
+	fmt.Printf("[ComparisonManager][Delete] Called with ID: %s, ownerID: %s\n", id, ownerID)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, ok := m.comparisons[id]
+	if !ok || c.expired(time.Now()) {
+		return fmt.Errorf("%w: %s", ErrComparisonNotFound, id)
+	}
+	if c.OwnerID != ownerID {
+		return ErrForbidden
+	}
+	if err := removeFile(m.dir, id); err != nil {
+		return err
+	}
+	delete(m.comparisons, id)
+	return nil
+}
+
+// cleanup removes the expired comparisons
+func (m *Manager) cleanup(now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	removed := 0
+	for id, c := range m.comparisons {
+		if !c.expired(now) {
+			continue
+		}
+		if err := removeFile(m.dir, id); err != nil {
+			fmt.Printf("[ComparisonManager][cleanup][ERROR] %v\n", err)
+			continue
+		}
+		delete(m.comparisons, id)
+		removed++
+	}
+	if removed > 0 {
+		fmt.Printf("[ComparisonManager][cleanup] Removed %d expired comparisons\n", removed)
+	}
+}
+
+// validate checks the product IDs, weights and notes of a new comparison
+func (m *Manager) validate(c *Comparison) error {
+	if len(c.ProductIDs) == 0 {
+		return fmt.Errorf("%w: no product IDs provided", ErrInvalidComparison)
+	}
+	if len(c.ProductIDs) > m.opts.MaxIDs {
+		return fmt.Errorf("%w: too many product IDs, at most %d allowed", ErrInvalidComparison, m.opts.MaxIDs)
+	}
+	seen := make(map[int]bool, len(c.ProductIDs))
+	for _, id := range c.ProductIDs {
+		if seen[id] {
+			return fmt.Errorf("%w: product ID %d is listed twice", ErrInvalidComparison, id)
+		}
+		seen[id] = true
+	}
+
+	for attribute, weight := range c.Weights {
+		if attribute == "" {
+			return fmt.Errorf("%w: weights need an attribute name", ErrInvalidComparison)
+		}
+		if weight < 0 || math.IsNaN(weight) || math.IsInf(weight, 0) {
+			return fmt.Errorf("%w: weight of %q must be a non-negative number", ErrInvalidComparison, attribute)
+		}
+	}
+	if len(c.Notes) > m.opts.MaxNotesLength {
+		return fmt.Errorf("%w: notes exceed %d bytes", ErrInvalidComparison, m.opts.MaxNotesLength)
+	}
+	return nil
+}
+
+// newID returns a random short comparison ID
+func newID() (string, error) {
+	b := make([]byte, idLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate comparison ID: %w", err)
+	}
+	for i := range b {
+		// 256 is not a multiple of 62, the slight bias doesn't matter here
+		b[i] = idAlphabet[int(b[i])%len(idAlphabet)]
+	}
+	return string(b), nil
+}
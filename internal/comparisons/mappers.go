@@ -0,0 +1,37 @@
+package comparisons
+
+import (
+	"item-comparison-api/internal/dto"
+	"slices"
+)
+
+// RequestToComparison converts a ComparisonRequest DTO to a Comparison
+func RequestToComparison(req dto.ComparisonRequest) Comparison {
+	return Comparison{
+		ProductIDs: req.IDs,
+		Weights:    req.Weights,
+		Notes:      req.Notes,
+	}
+}
+
+// ComparisonToResponse converts a Comparison to a ComparisonResponse DTO
+// without product data
+func ComparisonToResponse(c *Comparison) dto.ComparisonResponse {
+	return dto.ComparisonResponse{
+		ID:        c.ID,
+		IDs:       slices.Clone(c.ProductIDs),
+		Weights:   c.Weights,
+		Notes:     c.Notes,
+		CreatedAt: c.CreatedAt,
+		ExpiresAt: c.ExpiresAt,
+	}
+}
+
+// ComparisonToDetailResponse converts a Comparison to a ComparisonResponse
+// DTO with the current products and their changes since the snapshot
+func ComparisonToDetailResponse(c *Comparison, current []dto.ProductResponse) dto.ComparisonResponse {
+	response := ComparisonToResponse(c)
+	response.Products = current
+	response.Changes = Diff(c.Snapshot, current)
+	return response
+}
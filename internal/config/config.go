@@ -0,0 +1,578 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Storage backends supported by the API
+const (
+	BackendJSON   = "json"
+	BackendMemory = "memory"
+)
+
+// Authentication modes for resolving the seller of a request
+const (
+	AuthModeHeader = "header"
+	AuthModeAPIKey = "api_key"
+)
+
+// Config holds every runtime setting of the API. Values are resolved in this
+// order, later sources overriding earlier ones: defaults, config file,
+// environment variables, command-line flags.
+type Config struct {
+	Server       ServerConfig       `yaml:"server" toml:"server"`
+	TLS          TLSConfig          `yaml:"tls" toml:"tls"`
+	Storage      StorageConfig      `yaml:"storage" toml:"storage"`
+	Auth         AuthConfig         `yaml:"auth" toml:"auth"`
+	Limits       LimitsConfig       `yaml:"limits" toml:"limits"`
+	Logging      LoggingConfig      `yaml:"logging" toml:"logging"`
+	Tracing      TracingConfig      `yaml:"tracing" toml:"tracing"`
+	Idempotency  IdempotencyConfig  `yaml:"idempotency" toml:"idempotency"`
+	Imports      ImportsConfig      `yaml:"imports" toml:"imports"`
+	GRPC         GRPCConfig         `yaml:"grpc" toml:"grpc"`
+	GraphQL      GraphQLConfig      `yaml:"graphql" toml:"graphql"`
+	Events       EventsConfig       `yaml:"events" toml:"events"`
+	Webhooks     WebhooksConfig     `yaml:"webhooks" toml:"webhooks"`
+	Outbox       OutboxConfig       `yaml:"outbox" toml:"outbox"`
+	Alerts       AlertsConfig       `yaml:"alerts" toml:"alerts"`
+	Comparisons  ComparisonsConfig  `yaml:"comparisons" toml:"comparisons"`
+	Similarity   SimilarityConfig   `yaml:"similarity" toml:"similarity"`
+	Cooccurrence CooccurrenceConfig `yaml:"cooccurrence" toml:"cooccurrence"`
+	Offers       OffersConfig       `yaml:"offers" toml:"offers"`
+	Duplicates   DuplicatesConfig   `yaml:"duplicates" toml:"duplicates"`
+}
+
+// ServerConfig configures the HTTP listener
+type ServerConfig struct {
+	Addr              string        `yaml:"addr" toml:"addr" env:"SERVER_ADDR" flag:"addr" usage:"listen address, e.g. :8080"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" toml:"read_header_timeout" env:"SERVER_READ_HEADER_TIMEOUT" flag:"read-header-timeout" usage:"max time to read request headers"`
+	ReadTimeout       time.Duration `yaml:"read_timeout" toml:"read_timeout" env:"SERVER_READ_TIMEOUT" flag:"read-timeout" usage:"max time to read the whole request"`
+	WriteTimeout      time.Duration `yaml:"write_timeout" toml:"write_timeout" env:"SERVER_WRITE_TIMEOUT" flag:"write-timeout" usage:"max time to write the response"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout" toml:"idle_timeout" env:"SERVER_IDLE_TIMEOUT" flag:"idle-timeout" usage:"keep-alive idle timeout"`
+	ShutdownTimeout   time.Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout" env:"SERVER_SHUTDOWN_TIMEOUT" flag:"shutdown-timeout" usage:"max time to drain in-flight requests"`
+}
+
+// TLSConfig enables HTTPS on the listener
+type TLSConfig struct {
+	Enabled  bool   `yaml:"enabled" toml:"enabled" env:"TLS_ENABLED" flag:"tls" usage:"serve HTTPS"`
+	CertFile string `yaml:"cert_file" toml:"cert_file" env:"TLS_CERT_FILE" flag:"tls-cert" usage:"TLS certificate file"`
+	KeyFile  string `yaml:"key_file" toml:"key_file" env:"TLS_KEY_FILE" flag:"tls-key" usage:"TLS private key file"`
+}
+
+// StorageConfig selects the product storage backend
+type StorageConfig struct {
+	Backend string `yaml:"backend" toml:"backend" env:"STORAGE_BACKEND" flag:"storage-backend" usage:"storage backend: json or memory"`
+	Path    string `yaml:"path" toml:"path" env:"STORAGE_PATH" flag:"storage-path" usage:"directory for the json backend"`
+}
+
+// AuthConfig controls how the seller of a write request is identified.
+// In header mode the seller header is trusted as-is; in api_key mode the
+// X-API-Key header is looked up in APIKeys to find the seller.
+type AuthConfig struct {
+	Mode         string            `yaml:"mode" toml:"mode" env:"AUTH_MODE" flag:"auth-mode" usage:"seller authentication: header or api_key"`
+	SellerHeader string            `yaml:"seller_header" toml:"seller_header" env:"AUTH_SELLER_HEADER" flag:"auth-seller-header" usage:"header carrying the seller ID in header mode"`
+	APIKeys      map[string]string `yaml:"api_keys" toml:"api_keys" env:"AUTH_API_KEYS" flag:"auth-api-keys" usage:"API keys as key=seller pairs separated by commas"`
+}
+
+// LimitsConfig bounds request sizes and handler durations
+type LimitsConfig struct {
+	MaxBodyBytes  int64         `yaml:"max_body_bytes" toml:"max_body_bytes" env:"LIMITS_MAX_BODY_BYTES" flag:"max-body-bytes" usage:"max request body size in bytes"`
+	MaxCompareIDs int           `yaml:"max_compare_ids" toml:"max_compare_ids" env:"LIMITS_MAX_COMPARE_IDS" flag:"max-compare-ids" usage:"max product IDs per compare request"`
+	ReadTimeout   time.Duration `yaml:"read_timeout" toml:"read_timeout" env:"LIMITS_READ_TIMEOUT" flag:"handler-read-timeout" usage:"timeout for read routes"`
+	WriteTimeout  time.Duration `yaml:"write_timeout" toml:"write_timeout" env:"LIMITS_WRITE_TIMEOUT" flag:"handler-write-timeout" usage:"timeout for write routes"`
+}
+
+// LoggingConfig controls request logging
+type LoggingConfig struct {
+	Requests bool `yaml:"requests" toml:"requests" env:"LOG_REQUESTS" flag:"log-requests" usage:"log every HTTP request"`
+}
+
+// TracingConfig selects the OpenTelemetry span exporter
+type TracingConfig struct {
+	Exporter     string `yaml:"exporter" toml:"exporter" env:"TRACING_EXPORTER" flag:"tracing-exporter" usage:"span exporter: none, stdout or otlp"`
+	OTLPEndpoint string `yaml:"otlp_endpoint" toml:"otlp_endpoint" env:"TRACING_OTLP_ENDPOINT" flag:"tracing-otlp-endpoint" usage:"OTLP/HTTP collector endpoint, e.g. localhost:4318"`
+}
+
+// IdempotencyConfig controls how long responses to requests with an
+// Idempotency-Key are kept for replay
+type IdempotencyConfig struct {
+	Window time.Duration `yaml:"window" toml:"window" env:"IDEMPOTENCY_WINDOW" flag:"idempotency-window" usage:"how long idempotent responses are replayed"`
+}
+
+// GRPCConfig configures the gRPC listener. It shares the TLS, auth and limits
+// settings with the HTTP listener.
+type GRPCConfig struct {
+	Enabled bool   `yaml:"enabled" toml:"enabled" env:"GRPC_ENABLED" flag:"grpc" usage:"serve the gRPC API"`
+	Addr    string `yaml:"addr" toml:"addr" env:"GRPC_ADDR" flag:"grpc-addr" usage:"gRPC listen address, e.g. :9090"`
+}
+
+// GraphQLConfig bounds the operations accepted by the GraphQL endpoint.
+// Complexity counts one per field, multiplied by the expected size of the
+// lists above it.
+type GraphQLConfig struct {
+	MaxDepth      int `yaml:"max_depth" toml:"max_depth" env:"GRAPHQL_MAX_DEPTH" flag:"graphql-max-depth" usage:"max nesting depth of a GraphQL operation"`
+	MaxComplexity int `yaml:"max_complexity" toml:"max_complexity" env:"GRAPHQL_MAX_COMPLEXITY" flag:"graphql-max-complexity" usage:"max estimated complexity of a GraphQL operation"`
+}
+
+// EventsConfig controls the product change event stream. LogSize bounds how
+// many recent events a reconnecting client can resume from.
+type EventsConfig struct {
+	LogSize   int           `yaml:"log_size" toml:"log_size" env:"EVENTS_LOG_SIZE" flag:"events-log-size" usage:"number of recent events kept for Last-Event-ID resume"`
+	Heartbeat time.Duration `yaml:"heartbeat" toml:"heartbeat" env:"EVENTS_HEARTBEAT" flag:"events-heartbeat" usage:"interval of keep-alive comments on idle event streams"`
+}
+
+// OutboxConfig controls the relay of the changes recorded with every product
+// write. Changes always go to the in-process bus feeding the event stream
+// and webhooks; FilePath and NATSURL add a file and a NATS sink.
+type OutboxConfig struct {
+	BatchSize    int           `yaml:"batch_size" toml:"batch_size" env:"OUTBOX_BATCH_SIZE" flag:"outbox-batch-size" usage:"outbox entries relayed per batch"`
+	PollInterval time.Duration `yaml:"poll_interval" toml:"poll_interval" env:"OUTBOX_POLL_INTERVAL" flag:"outbox-poll-interval" usage:"interval of outbox checks between write notifications"`
+	RetryBase    time.Duration `yaml:"retry_base" toml:"retry_base" env:"OUTBOX_RETRY_BASE" flag:"outbox-retry-base" usage:"wait before retrying a failing sink, doubled for every further failure"`
+	RetryMax     time.Duration `yaml:"retry_max" toml:"retry_max" env:"OUTBOX_RETRY_MAX" flag:"outbox-retry-max" usage:"longest wait before retrying a failing sink"`
+	FilePath     string        `yaml:"file_path" toml:"file_path" env:"OUTBOX_FILE_PATH" flag:"outbox-file-path" usage:"file events are appended to as NDJSON, disabled when empty"`
+	NATSURL      string        `yaml:"nats_url" toml:"nats_url" env:"OUTBOX_NATS_URL" flag:"outbox-nats-url" usage:"nats:// or tls://host:port of a NATS JetStream server events are published to, disabled when empty"`
+	NATSSubject  string        `yaml:"nats_subject" toml:"nats_subject" env:"OUTBOX_NATS_SUBJECT" flag:"outbox-nats-subject" usage:"NATS subject events are published on, bound to a JetStream stream"`
+}
+
+// WebhooksConfig controls outbound webhook deliveries. Failed deliveries are
+// retried after BackoffBase, doubling up to BackoffMax, until MaxAttempts is
+// reached. Webhooks and deliveries are kept in Dir, which defaults to the
+// webhooks folder under the storage path. Deliveries to loopback, private and
+// link-local addresses, and alert notifications to them, are refused unless
+// AllowPrivateTargets is set.
+type WebhooksConfig struct {
+	Dir                 string        `yaml:"dir" toml:"dir" env:"WEBHOOKS_DIR" flag:"webhooks-dir" usage:"directory for webhooks and deliveries, defaults to <storage path>/webhooks"`
+	Workers             int           `yaml:"workers" toml:"workers" env:"WEBHOOKS_WORKERS" flag:"webhooks-workers" usage:"number of deliveries sent concurrently"`
+	Timeout             time.Duration `yaml:"timeout" toml:"timeout" env:"WEBHOOKS_TIMEOUT" flag:"webhooks-timeout" usage:"timeout of one delivery attempt"`
+	MaxAttempts         int           `yaml:"max_attempts" toml:"max_attempts" env:"WEBHOOKS_MAX_ATTEMPTS" flag:"webhooks-max-attempts" usage:"attempts before a delivery becomes a dead letter"`
+	BackoffBase         time.Duration `yaml:"backoff_base" toml:"backoff_base" env:"WEBHOOKS_BACKOFF_BASE" flag:"webhooks-backoff-base" usage:"wait before the first retry, doubled for every further retry"`
+	BackoffMax          time.Duration `yaml:"backoff_max" toml:"backoff_max" env:"WEBHOOKS_BACKOFF_MAX" flag:"webhooks-backoff-max" usage:"longest wait between retries"`
+	HistorySize         int           `yaml:"history_size" toml:"history_size" env:"WEBHOOKS_HISTORY_SIZE" flag:"webhooks-history-size" usage:"succeeded deliveries kept per webhook"`
+	AllowPrivateTargets bool          `yaml:"allow_private_targets" toml:"allow_private_targets" env:"WEBHOOKS_ALLOW_PRIVATE_TARGETS" flag:"webhooks-allow-private-targets" usage:"allow webhook deliveries and alert notifications to loopback, private and link-local addresses, for local testing only"`
+}
+
+// AlertsConfig controls price drop alert notifications. Failed notifications
+// are retried after RetryBase, doubling up to RetryMax, until MaxAttempts is
+// reached. Alerts are kept in Dir, which defaults to the alerts folder under
+// the storage path.
+type AlertsConfig struct {
+	Dir           string        `yaml:"dir" toml:"dir" env:"ALERTS_DIR" flag:"alerts-dir" usage:"directory for price drop alerts, defaults to <storage path>/alerts"`
+	NotifyTimeout time.Duration `yaml:"notify_timeout" toml:"notify_timeout" env:"ALERTS_NOTIFY_TIMEOUT" flag:"alerts-notify-timeout" usage:"timeout of one notification attempt"`
+	MaxAttempts   int           `yaml:"max_attempts" toml:"max_attempts" env:"ALERTS_MAX_ATTEMPTS" flag:"alerts-max-attempts" usage:"attempts before an alert notification fails"`
+	RetryBase     time.Duration `yaml:"retry_base" toml:"retry_base" env:"ALERTS_RETRY_BASE" flag:"alerts-retry-base" usage:"wait before the first notification retry, doubled for every further retry"`
+	RetryMax      time.Duration `yaml:"retry_max" toml:"retry_max" env:"ALERTS_RETRY_MAX" flag:"alerts-retry-max" usage:"longest wait between notification retries"`
+}
+
+// ComparisonsConfig controls saved comparisons. Comparisons saved without
+// credentials expire after AnonymousTTL. Comparisons are kept in Dir, which
+// defaults to the comparisons folder under the storage path.
+type ComparisonsConfig struct {
+	Dir             string        `yaml:"dir" toml:"dir" env:"COMPARISONS_DIR" flag:"comparisons-dir" usage:"directory for saved comparisons, defaults to <storage path>/comparisons"`
+	AnonymousTTL    time.Duration `yaml:"anonymous_ttl" toml:"anonymous_ttl" env:"COMPARISONS_ANONYMOUS_TTL" flag:"comparisons-anonymous-ttl" usage:"lifetime of comparisons saved without credentials"`
+	CleanupInterval time.Duration `yaml:"cleanup_interval" toml:"cleanup_interval" env:"COMPARISONS_CLEANUP_INTERVAL" flag:"comparisons-cleanup-interval" usage:"interval of expired comparison removal"`
+	MaxNotesLength  int           `yaml:"max_notes_length" toml:"max_notes_length" env:"COMPARISONS_MAX_NOTES_LENGTH" flag:"comparisons-max-notes-length" usage:"max size of comparison notes in bytes"`
+}
+
+// SimilarityConfig controls similar product recommendations
+type SimilarityConfig struct {
+	DefaultLimit int `yaml:"default_limit" toml:"default_limit" env:"SIMILARITY_DEFAULT_LIMIT" flag:"similarity-default-limit" usage:"similar products returned when no limit is given"`
+	MaxLimit     int `yaml:"max_limit" toml:"max_limit" env:"SIMILARITY_MAX_LIMIT" flag:"similarity-max-limit" usage:"max similar products per request"`
+}
+
+// CooccurrenceConfig controls the tracking of products compared together.
+// A comparison's weight halves every HalfLife; entries below MinScore are
+// dropped. The state is saved to Dir, which defaults to the cooccurrence
+// folder under the storage path.
+type CooccurrenceConfig struct {
+	Dir           string        `yaml:"dir" toml:"dir" env:"COOCCURRENCE_DIR" flag:"cooccurrence-dir" usage:"directory for co-occurrence counts, defaults to <storage path>/cooccurrence"`
+	HalfLife      time.Duration `yaml:"half_life" toml:"half_life" env:"COOCCURRENCE_HALF_LIFE" flag:"cooccurrence-half-life" usage:"time after which a comparison counts half"`
+	MinScore      float64       `yaml:"min_score" toml:"min_score" env:"COOCCURRENCE_MIN_SCORE" flag:"cooccurrence-min-score" usage:"decayed score below which an entry is dropped"`
+	FlushInterval time.Duration `yaml:"flush_interval" toml:"flush_interval" env:"COOCCURRENCE_FLUSH_INTERVAL" flag:"cooccurrence-flush-interval" usage:"interval of saving changed counts"`
+	DefaultLimit  int           `yaml:"default_limit" toml:"default_limit" env:"COOCCURRENCE_DEFAULT_LIMIT" flag:"cooccurrence-default-limit" usage:"results returned when no limit is given"`
+	MaxLimit      int           `yaml:"max_limit" toml:"max_limit" env:"COOCCURRENCE_MAX_LIMIT" flag:"cooccurrence-max-limit" usage:"max results per request"`
+}
+
+// OffersConfig controls seller offers. Offers are kept in Dir, which
+// defaults to the offers folder under the storage path.
+type OffersConfig struct {
+	Dir string `yaml:"dir" toml:"dir" env:"OFFERS_DIR" flag:"offers-dir" usage:"directory for seller offers, defaults to <storage path>/offers"`
+}
+
+// DuplicatesConfig controls duplicate product detection. Pairs scoring at
+// least Threshold are flagged for review. Reviewers may review and merge
+// every candidate, sellers only the ones of their own products. Candidates
+// and merges are kept in Dir, which defaults to the duplicates folder under
+// the storage path.
+type DuplicatesConfig struct {
+	Dir       string   `yaml:"dir" toml:"dir" env:"DUPLICATES_DIR" flag:"duplicates-dir" usage:"directory for duplicate candidates and merges, defaults to <storage path>/duplicates"`
+	Threshold float64  `yaml:"threshold" toml:"threshold" env:"DUPLICATES_THRESHOLD" flag:"duplicates-threshold" usage:"score between 0 and 1 from which products are flagged as duplicates"`
+	Reviewers []string `yaml:"reviewers" toml:"reviewers" env:"DUPLICATES_REVIEWERS" flag:"duplicates-reviewers" usage:"seller IDs allowed to review every duplicate, separated by commas"`
+}
+
+// ImportsConfig controls asynchronous product imports. Jobs and their
+// uploads are kept in Dir, which defaults to the imports folder under the
+// storage path.
+type ImportsConfig struct {
+	Dir            string `yaml:"dir" toml:"dir" env:"IMPORTS_DIR" flag:"imports-dir" usage:"directory for import jobs, defaults to <storage path>/imports"`
+	Workers        int    `yaml:"workers" toml:"workers" env:"IMPORTS_WORKERS" flag:"imports-workers" usage:"number of import jobs processed concurrently"`
+	ChunkSize      int    `yaml:"chunk_size" toml:"chunk_size" env:"IMPORTS_CHUNK_SIZE" flag:"imports-chunk-size" usage:"products written per import step"`
+	MaxUploadBytes int64  `yaml:"max_upload_bytes" toml:"max_upload_bytes" env:"IMPORTS_MAX_UPLOAD_BYTES" flag:"imports-max-upload-bytes" usage:"max import upload size in bytes"`
+}
+
+// ImportsDir returns the directory import jobs are kept in
+func (c *Config) ImportsDir() string {
+	if c.Imports.Dir != "" {
+		return c.Imports.Dir
+	}
+	return filepath.Join(c.Storage.Path, "imports")
+}
+
+// WebhooksDir returns the directory webhooks and deliveries are kept in
+func (c *Config) WebhooksDir() string {
+	if c.Webhooks.Dir != "" {
+		return c.Webhooks.Dir
+	}
+	return filepath.Join(c.Storage.Path, "webhooks")
+}
+
+// AlertsDir returns the directory price drop alerts are kept in
+func (c *Config) AlertsDir() string {
+	if c.Alerts.Dir != "" {
+		return c.Alerts.Dir
+	}
+	return filepath.Join(c.Storage.Path, "alerts")
+}
+
+// ComparisonsDir returns the directory saved comparisons are kept in
+func (c *Config) ComparisonsDir() string {
+	if c.Comparisons.Dir != "" {
+		return c.Comparisons.Dir
+	}
+	return filepath.Join(c.Storage.Path, "comparisons")
+}
+
+// CooccurrencePath returns the file co-occurrence counts are saved to
+func (c *Config) CooccurrencePath() string {
+	dir := c.Cooccurrence.Dir
+	if dir == "" {
+		dir = filepath.Join(c.Storage.Path, "cooccurrence")
+	}
+	return filepath.Join(dir, "state.json")
+}
+
+// OffersDir returns the directory seller offers are kept in
+func (c *Config) OffersDir() string {
+	if c.Offers.Dir != "" {
+		return c.Offers.Dir
+	}
+	return filepath.Join(c.Storage.Path, "offers")
+}
+
+// DuplicatesDir returns the directory duplicate candidates and merges are kept in
+func (c *Config) DuplicatesDir() string {
+	if c.Duplicates.Dir != "" {
+		return c.Duplicates.Dir
+	}
+	return filepath.Join(c.Storage.Path, "duplicates")
+}
+
+// Default returns the configuration used when nothing else is provided
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Addr:              ":8080",
+			ReadHeaderTimeout: 5 * time.Second,
+			ReadTimeout:       15 * time.Second,
+			WriteTimeout:      30 * time.Second,
+			IdleTimeout:       60 * time.Second,
+			ShutdownTimeout:   20 * time.Second,
+		},
+		Storage: StorageConfig{
+			Backend: BackendJSON,
+			Path:    "data",
+		},
+		Auth: AuthConfig{
+			Mode:         AuthModeHeader,
+			SellerHeader: "x-seller-id",
+		},
+		Limits: LimitsConfig{
+			MaxBodyBytes:  10 << 20,
+			MaxCompareIDs: 50,
+			ReadTimeout:   5 * time.Second,
+			WriteTimeout:  10 * time.Second,
+		},
+		Logging: LoggingConfig{
+			Requests: true,
+		},
+		Tracing: TracingConfig{
+			Exporter: "none",
+		},
+		Idempotency: IdempotencyConfig{
+			Window: 24 * time.Hour,
+		},
+		Imports: ImportsConfig{
+			Workers:        2,
+			ChunkSize:      100,
+			MaxUploadBytes: 256 << 20,
+		},
+		GRPC: GRPCConfig{
+			Enabled: true,
+			Addr:    ":9090",
+		},
+		GraphQL: GraphQLConfig{
+			MaxDepth:      8,
+			MaxComplexity: 1000,
+		},
+		Events: EventsConfig{
+			LogSize:   1000,
+			Heartbeat: 15 * time.Second,
+		},
+		Outbox: OutboxConfig{
+			BatchSize:    100,
+			PollInterval: 5 * time.Second,
+			RetryBase:    time.Second,
+			RetryMax:     time.Minute,
+			NATSSubject:  "products.events",
+		},
+		Webhooks: WebhooksConfig{
+			Workers:     4,
+			Timeout:     10 * time.Second,
+			MaxAttempts: 8,
+			BackoffBase: 10 * time.Second,
+			BackoffMax:  time.Hour,
+			HistorySize: 100,
+		},
+		Alerts: AlertsConfig{
+			NotifyTimeout: 10 * time.Second,
+			MaxAttempts:   5,
+			RetryBase:     10 * time.Second,
+			RetryMax:      time.Hour,
+		},
+		Comparisons: ComparisonsConfig{
+			AnonymousTTL:    7 * 24 * time.Hour,
+			CleanupInterval: time.Hour,
+			MaxNotesLength:  2000,
+		},
+		Similarity: SimilarityConfig{
+			DefaultLimit: 10,
+			MaxLimit:     50,
+		},
+		Cooccurrence: CooccurrenceConfig{
+			HalfLife:      7 * 24 * time.Hour,
+			MinScore:      0.01,
+			FlushInterval: time.Minute,
+			DefaultLimit:  10,
+			MaxLimit:      50,
+		},
+		Duplicates: DuplicatesConfig{
+			Threshold: 0.8,
+		},
+	}
+}
+
+// Validate checks the configuration and reports every problem found
+func (c *Config) Validate() error {
+	var errs []error
+
+	if _, _, err := net.SplitHostPort(c.Server.Addr); err != nil {
+		errs = append(errs, fmt.Errorf("server.addr %q: %w", c.Server.Addr, err))
+	}
+	if c.GRPC.Enabled {
+		if _, _, err := net.SplitHostPort(c.GRPC.Addr); err != nil {
+			errs = append(errs, fmt.Errorf("grpc.addr %q: %w", c.GRPC.Addr, err))
+		} else if c.GRPC.Addr == c.Server.Addr {
+			errs = append(errs, fmt.Errorf("grpc.addr %q must differ from server.addr", c.GRPC.Addr))
+		}
+	}
+	for _, timeout := range []struct {
+		name  string
+		value time.Duration
+	}{
+		{"server.read_header_timeout", c.Server.ReadHeaderTimeout},
+		{"server.read_timeout", c.Server.ReadTimeout},
+		{"server.write_timeout", c.Server.WriteTimeout},
+		{"server.idle_timeout", c.Server.IdleTimeout},
+		{"server.shutdown_timeout", c.Server.ShutdownTimeout},
+		{"limits.read_timeout", c.Limits.ReadTimeout},
+		{"limits.write_timeout", c.Limits.WriteTimeout},
+		{"idempotency.window", c.Idempotency.Window},
+		{"events.heartbeat", c.Events.Heartbeat},
+		{"outbox.poll_interval", c.Outbox.PollInterval},
+		{"outbox.retry_base", c.Outbox.RetryBase},
+		{"outbox.retry_max", c.Outbox.RetryMax},
+		{"webhooks.timeout", c.Webhooks.Timeout},
+		{"webhooks.backoff_base", c.Webhooks.BackoffBase},
+		{"webhooks.backoff_max", c.Webhooks.BackoffMax},
+		{"alerts.notify_timeout", c.Alerts.NotifyTimeout},
+		{"alerts.retry_base", c.Alerts.RetryBase},
+		{"alerts.retry_max", c.Alerts.RetryMax},
+		{"comparisons.anonymous_ttl", c.Comparisons.AnonymousTTL},
+		{"comparisons.cleanup_interval", c.Comparisons.CleanupInterval},
+		{"cooccurrence.half_life", c.Cooccurrence.HalfLife},
+		{"cooccurrence.flush_interval", c.Cooccurrence.FlushInterval},
+	} {
+		if timeout.value <= 0 {
+			errs = append(errs, fmt.Errorf("%s must be positive, got %v", timeout.name, timeout.value))
+		}
+	}
+
+	if c.TLS.Enabled {
+		if c.TLS.CertFile == "" || c.TLS.KeyFile == "" {
+			errs = append(errs, errors.New("tls.cert_file and tls.key_file are required when tls is enabled"))
+		}
+		for _, file := range []string{c.TLS.CertFile, c.TLS.KeyFile} {
+			if file == "" {
+				continue
+			}
+			if _, err := os.Stat(file); err != nil {
+				errs = append(errs, fmt.Errorf("tls file %s: %w", file, err))
+			}
+		}
+	}
+
+	switch c.Storage.Backend {
+	case BackendJSON:
+		if c.Storage.Path == "" {
+			errs = append(errs, errors.New("storage.path is required for the json backend"))
+		}
+	case BackendMemory:
+	default:
+		errs = append(errs, fmt.Errorf("storage.backend %q must be %s or %s", c.Storage.Backend, BackendJSON, BackendMemory))
+	}
+
+	switch c.Auth.Mode {
+	case AuthModeHeader:
+		if c.Auth.SellerHeader == "" {
+			errs = append(errs, errors.New("auth.seller_header is required in header mode"))
+		}
+	case AuthModeAPIKey:
+		if len(c.Auth.APIKeys) == 0 {
+			errs = append(errs, errors.New("auth.api_keys must contain at least one key in api_key mode"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("auth.mode %q must be %s or %s", c.Auth.Mode, AuthModeHeader, AuthModeAPIKey))
+	}
+
+	if c.Limits.MaxBodyBytes <= 0 {
+		errs = append(errs, fmt.Errorf("limits.max_body_bytes must be positive, got %d", c.Limits.MaxBodyBytes))
+	}
+	if c.Limits.MaxCompareIDs <= 0 {
+		errs = append(errs, fmt.Errorf("limits.max_compare_ids must be positive, got %d", c.Limits.MaxCompareIDs))
+	}
+
+	if c.Imports.Workers <= 0 {
+		errs = append(errs, fmt.Errorf("imports.workers must be positive, got %d", c.Imports.Workers))
+	}
+	if c.Imports.ChunkSize <= 0 {
+		errs = append(errs, fmt.Errorf("imports.chunk_size must be positive, got %d", c.Imports.ChunkSize))
+	}
+	if c.Imports.MaxUploadBytes <= 0 {
+		errs = append(errs, fmt.Errorf("imports.max_upload_bytes must be positive, got %d", c.Imports.MaxUploadBytes))
+	}
+	if c.GraphQL.MaxDepth <= 0 {
+		errs = append(errs, fmt.Errorf("graphql.max_depth must be positive, got %d", c.GraphQL.MaxDepth))
+	}
+	if c.GraphQL.MaxComplexity <= 0 {
+		errs = append(errs, fmt.Errorf("graphql.max_complexity must be positive, got %d", c.GraphQL.MaxComplexity))
+	}
+	if c.Events.LogSize <= 0 {
+		errs = append(errs, fmt.Errorf("events.log_size must be positive, got %d", c.Events.LogSize))
+	}
+	if c.Imports.Dir == "" && c.Storage.Path == "" {
+		errs = append(errs, errors.New("imports.dir is required when storage.path is empty"))
+	}
+	if c.Outbox.BatchSize <= 0 {
+		errs = append(errs, fmt.Errorf("outbox.batch_size must be positive, got %d", c.Outbox.BatchSize))
+	}
+	if c.Outbox.RetryMax < c.Outbox.RetryBase {
+		errs = append(errs, fmt.Errorf("outbox.retry_max %v must not be shorter than outbox.retry_base %v", c.Outbox.RetryMax, c.Outbox.RetryBase))
+	}
+	if c.Outbox.NATSURL != "" {
+		if u, err := url.Parse(c.Outbox.NATSURL); err != nil || (u.Scheme != "nats" && u.Scheme != "tls") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("outbox.nats_url %q must be a nats://host:port or tls://host:port URL", c.Outbox.NATSURL))
+		}
+		if c.Outbox.NATSSubject == "" {
+			errs = append(errs, errors.New("outbox.nats_subject is required when outbox.nats_url is set"))
+		} else if !validSubject(c.Outbox.NATSSubject) {
+			errs = append(errs, fmt.Errorf("outbox.nats_subject %q must be dot-separated tokens without wildcards or whitespace", c.Outbox.NATSSubject))
+		}
+	}
+	if c.Webhooks.Workers <= 0 {
+		errs = append(errs, fmt.Errorf("webhooks.workers must be positive, got %d", c.Webhooks.Workers))
+	}
+	if c.Webhooks.MaxAttempts <= 0 {
+		errs = append(errs, fmt.Errorf("webhooks.max_attempts must be positive, got %d", c.Webhooks.MaxAttempts))
+	}
+	if c.Webhooks.HistorySize <= 0 {
+		errs = append(errs, fmt.Errorf("webhooks.history_size must be positive, got %d", c.Webhooks.HistorySize))
+	}
+	if c.Webhooks.BackoffMax < c.Webhooks.BackoffBase {
+		errs = append(errs, fmt.Errorf("webhooks.backoff_max %v must not be shorter than webhooks.backoff_base %v", c.Webhooks.BackoffMax, c.Webhooks.BackoffBase))
+	}
+	if c.Webhooks.Dir == "" && c.Storage.Path == "" {
+		errs = append(errs, errors.New("webhooks.dir is required when storage.path is empty"))
+	}
+	if c.Alerts.MaxAttempts <= 0 {
+		errs = append(errs, fmt.Errorf("alerts.max_attempts must be positive, got %d", c.Alerts.MaxAttempts))
+	}
+	if c.Alerts.RetryMax < c.Alerts.RetryBase {
+		errs = append(errs, fmt.Errorf("alerts.retry_max %v must not be shorter than alerts.retry_base %v", c.Alerts.RetryMax, c.Alerts.RetryBase))
+	}
+	if c.Alerts.Dir == "" && c.Storage.Path == "" {
+		errs = append(errs, errors.New("alerts.dir is required when storage.path is empty"))
+	}
+	if c.Comparisons.MaxNotesLength < 0 {
+		errs = append(errs, fmt.Errorf("comparisons.max_notes_length must not be negative, got %d", c.Comparisons.MaxNotesLength))
+	}
+	if c.Comparisons.Dir == "" && c.Storage.Path == "" {
+		errs = append(errs, errors.New("comparisons.dir is required when storage.path is empty"))
+	}
+	if c.Similarity.DefaultLimit <= 0 || c.Similarity.DefaultLimit > c.Similarity.MaxLimit {
+		errs = append(errs, fmt.Errorf("similarity.default_limit must be between 1 and similarity.max_limit %d, got %d", c.Similarity.MaxLimit, c.Similarity.DefaultLimit))
+	}
+	if c.Cooccurrence.MinScore <= 0 {
+		errs = append(errs, fmt.Errorf("cooccurrence.min_score must be positive, got %v", c.Cooccurrence.MinScore))
+	}
+	if c.Cooccurrence.DefaultLimit <= 0 || c.Cooccurrence.DefaultLimit > c.Cooccurrence.MaxLimit {
+		errs = append(errs, fmt.Errorf("cooccurrence.default_limit must be between 1 and cooccurrence.max_limit %d, got %d", c.Cooccurrence.MaxLimit, c.Cooccurrence.DefaultLimit))
+	}
+	if c.Cooccurrence.Dir == "" && c.Storage.Path == "" {
+		errs = append(errs, errors.New("cooccurrence.dir is required when storage.path is empty"))
+	}
+	if c.Offers.Dir == "" && c.Storage.Path == "" {
+		errs = append(errs, errors.New("offers.dir is required when storage.path is empty"))
+	}
+	if c.Duplicates.Threshold <= 0 || c.Duplicates.Threshold > 1 {
+		errs = append(errs, fmt.Errorf("duplicates.threshold must be above 0 and at most 1, got %v", c.Duplicates.Threshold))
+	}
+	if c.Duplicates.Dir == "" && c.Storage.Path == "" {
+		errs = append(errs, errors.New("duplicates.dir is required when storage.path is empty"))
+	}
+
+	switch c.Tracing.Exporter {
+	case "none", "stdout", "otlp":
+	default:
+		errs = append(errs, fmt.Errorf("tracing.exporter %q must be none, stdout or otlp", c.Tracing.Exporter))
+	}
+
+	return errors.Join(errs...)
+}
+
+// validSubject reports whether s is a NATS subject messages can be published
+// on: non-empty tokens separated by dots, without wildcards or whitespace
+func validSubject(s string) bool {
+	for _, token := range strings.Split(s, ".") {
+		if token == "" || token == "*" || token == ">" || strings.ContainsFunc(token, unicode.IsSpace) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,232 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Options are the command-line flags that control loading itself rather
+// than a configuration value
+type Options struct {
+	File  string
+	Print bool
+}
+
+// Load resolves the configuration from defaults, an optional YAML or TOML
+// file, environment variables and command-line flags, in that order of
+// precedence, and validates the result.
+func Load(args []string) (*Config, Options, error) {
+	cfg := Default()
+	var opts Options
+
+	// Flags are parsed first so -config can point at the file, but their
+	// values are applied last so they win over every other source
+	fs := flag.NewFlagSet("item-comparison-api", flag.ContinueOnError)
+	fs.StringVar(&opts.File, "config", os.Getenv("CONFIG_FILE"), "path to a YAML or TOML config file")
+	fs.BoolVar(&opts.Print, "print-config", false, "print the effective configuration and exit")
+
+	var flagValues []func() error
+	eachField(reflect.ValueOf(cfg).Elem(), func(field reflect.StructField, value reflect.Value) {
+		name := field.Tag.Get("flag")
+		if name == "" {
+			return
+		}
+		set := func(s string) error {
+			// Validate eagerly so flag errors point at the flag
+			if err := setValue(reflect.New(value.Type()).Elem(), s); err != nil {
+				return err
+			}
+			flagValues = append(flagValues, func() error { return setValue(value, s) })
+			return nil
+		}
+		// Boolean flags may be given without a value, e.g. -tls
+		if value.Kind() == reflect.Bool {
+			fs.BoolFunc(name, field.Tag.Get("usage"), set)
+			return
+		}
+		fs.Func(name, field.Tag.Get("usage"), set)
+	})
+	if err := fs.Parse(args); err != nil {
+		return nil, opts, err
+	}
+
+	if opts.File != "" {
+		fmt.Printf("[Config][Load] Reading config file: %s\n", opts.File)
+		if err := loadFile(cfg, opts.File); err != nil {
+			return nil, opts, err
+		}
+	}
+
+	if err := loadEnv(cfg); err != nil {
+		return nil, opts, err
+	}
+
+	for _, apply := range flagValues {
+		if err := apply(); err != nil {
+			return nil, opts, err
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, opts, fmt.Errorf("invalid configuration:\n%w", err)
+	}
+	return cfg, opts, nil
+}
+
+// loadFile decodes a YAML or TOML file over cfg, chosen by file extension
+func loadFile(cfg *Config, path string) error {
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(strings.NewReader(string(bytes)))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(cfg); err != nil {
+			return fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+	case ".toml":
+		meta, err := toml.Decode(string(bytes), cfg)
+		if err != nil {
+			return fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+		if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+			return fmt.Errorf("unknown keys in config file %s: %v", path, undecoded)
+		}
+	default:
+		return fmt.Errorf("unsupported config file extension %q, use .yaml, .yml or .toml", filepath.Ext(path))
+	}
+	return nil
+}
+
+// loadEnv applies every environment variable named in an env tag
+func loadEnv(cfg *Config) error {
+	var err error
+	eachField(reflect.ValueOf(cfg).Elem(), func(field reflect.StructField, value reflect.Value) {
+		name := field.Tag.Get("env")
+		raw, ok := os.LookupEnv(name)
+		if name == "" || !ok || err != nil {
+			return
+		}
+		if setErr := setValue(value, raw); setErr != nil {
+			err = fmt.Errorf("environment variable %s: %w", name, setErr)
+		}
+	})
+	return err
+}
+
+// eachField calls fn for every leaf field of the nested config structs
+func eachField(v reflect.Value, fn func(reflect.StructField, reflect.Value)) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field, value := t.Field(i), v.Field(i)
+		if field.Type.Kind() == reflect.Struct && field.Type != reflect.TypeOf(time.Duration(0)) {
+			eachField(value, fn)
+			continue
+		}
+		fn(field, value)
+	}
+}
+
+// setValue parses s into the field according to its type
+func setValue(value reflect.Value, s string) error {
+	if value.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		value.SetInt(int64(d))
+		return nil
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		value.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return err
+		}
+		value.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		value.SetFloat(f)
+	case reflect.Map:
+		// key=value pairs separated by commas
+		m := make(map[string]string)
+		for _, pair := range strings.Split(s, ",") {
+			if strings.TrimSpace(pair) == "" {
+				continue
+			}
+			k, v, ok := strings.Cut(pair, "=")
+			if !ok {
+				return fmt.Errorf("expected key=value, got %q", pair)
+			}
+			m[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+		value.Set(reflect.ValueOf(m))
+	case reflect.Slice:
+		// values separated by commas
+		var list []string
+		for _, item := range strings.Split(s, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		value.Set(reflect.ValueOf(list))
+	default:
+		return fmt.Errorf("unsupported config field type %s", value.Type())
+	}
+	return nil
+}
+
+// Print writes the configuration as YAML with secrets redacted
+func (c *Config) Print(w io.Writer) error {
+	redacted := *c
+	if len(c.Auth.APIKeys) > 0 {
+		// Keys are replaced by numbered placeholders, ordered by seller, so
+		// no part of a key is printed and every entry stays in the output
+		keys := make([]string, 0, len(c.Auth.APIKeys))
+		for key := range c.Auth.APIKeys {
+			keys = append(keys, key)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			a, b := c.Auth.APIKeys[keys[i]], c.Auth.APIKeys[keys[j]]
+			if a != b {
+				return a < b
+			}
+			return keys[i] < keys[j]
+		})
+		redacted.Auth.APIKeys = make(map[string]string, len(keys))
+		for i, key := range keys {
+			redacted.Auth.APIKeys[fmt.Sprintf("key-%d", i+1)] = c.Auth.APIKeys[key]
+		}
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	defer encoder.Close()
+	return encoder.Encode(&redacted)
+}
@@ -0,0 +1,34 @@
+package cooccurrence
+
+import (
+	"context"
+	"item-comparison-api/internal/dto"
+	"item-comparison-api/internal/services"
+)
+
+// recordingService decorates a ProductServiceInterface, recording the ID
+// set of every successful comparison. The IDs of the compared products are
+// recorded rather than the requested ones, which may be IDs of products
+// merged into others. Other calls pass through.
+type recordingService struct {
+	services.ProductServiceInterface
+	tracker *Tracker
+}
+
+// RecordService wraps the given service so its comparisons are recorded,
+// whichever API they come from
+func RecordService(next services.ProductServiceInterface, tracker *Tracker) services.ProductServiceInterface {
+	return &recordingService{ProductServiceInterface: next, tracker: tracker}
+}
+
+func (s *recordingService) CompareProducts(ctx context.Context, ids []int) ([]dto.ProductResponse, error) {
+	products, err := s.ProductServiceInterface.CompareProducts(ctx, ids)
+	if err == nil {
+		compared := make([]int, 0, len(products))
+		for _, p := range products {
+			compared = append(compared, p.ID)
+		}
+		s.tracker.Record(compared)
+	}
+	return products, err
+}
@@ -0,0 +1,79 @@
+package cooccurrence
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"item-comparison-api/internal/dto"
+	"item-comparison-api/internal/services"
+)
+
+// mergingService answers comparisons like the service behind the duplicate
+// redirects: products merged into others come back under their new ID
+type mergingService struct {
+	services.ProductServiceInterface
+	merged map[int]int
+	err    error
+}
+
+func (s *mergingService) CompareProducts(ctx context.Context, ids []int) ([]dto.ProductResponse, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	products := make([]dto.ProductResponse, 0, len(ids))
+	for _, id := range ids {
+		if target, ok := s.merged[id]; ok {
+			id = target
+		}
+		products = append(products, dto.ProductResponse{ID: id})
+	}
+	return products, nil
+}
+
+// TestRecordServiceRecordsComparedIDs checks that comparisons are recorded
+// under the IDs of the products compared, not the requested ones
+func TestRecordServiceRecordsComparedIDs(t *testing.T) {
+	tests := []struct {
+		name     string
+		merged   map[int]int
+		err      error
+		ids      []int
+		wantPair []int
+		wantSets [][]int
+	}{
+		{"plain comparison", nil, nil, []int{1, 3}, []int{3}, [][]int{{1, 3}}},
+		{"merged product", map[int]int{2: 1}, nil, []int{2, 3}, []int{3}, [][]int{{1, 3}}},
+		{"product compared with its duplicate", map[int]int{2: 1}, nil, []int{1, 2}, nil, nil},
+		{"failed comparison", nil, errors.New("storage unavailable"), []int{1, 3}, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := testTracker(t)
+			service := RecordService(&mergingService{merged: tt.merged, err: tt.err}, tracker)
+			if _, err := service.CompareProducts(context.Background(), tt.ids); !errors.Is(err, tt.err) {
+				t.Fatalf("CompareProducts error = %v, want %v", err, tt.err)
+			}
+
+			var pairs []int
+			for _, p := range tracker.FrequentlyCompared(1, 10) {
+				pairs = append(pairs, p.ProductID)
+			}
+			if !slices.Equal(pairs, tt.wantPair) {
+				t.Errorf("compared with 1 = %v, want %v", pairs, tt.wantPair)
+			}
+			var sets [][]int
+			for _, s := range tracker.Popular(10) {
+				sets = append(sets, s.IDs)
+			}
+			if !slices.EqualFunc(sets, tt.wantSets, slices.Equal[[]int]) {
+				t.Errorf("recorded sets = %v, want %v", sets, tt.wantSets)
+			}
+			if pairs := tracker.FrequentlyCompared(2, 10); tt.merged != nil && len(pairs) > 0 {
+				t.Errorf("merged product 2 was recorded: %+v", pairs)
+			}
+		})
+	}
+}
@@ -0,0 +1,379 @@
+// Package cooccurrence learns which products are compared together. Every
+// compared ID set counts for the set itself and for each pair in it. Counts
+// decay exponentially, so recent comparisons weigh more, and are saved to a
+// file so they survive restarts.
+package cooccurrence
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"item-comparison-api/internal/dto"
+	"item-comparison-api/internal/models"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Options tune the tracker. A comparison's weight halves every HalfLife.
+// Entries whose weight fell below MinScore are dropped, and the state is
+// saved every FlushInterval when it changed.
+type Options struct {
+	HalfLife      time.Duration
+	MinScore      float64
+	FlushInterval time.Duration
+}
+
+// counter is a decayed count. Score is as of UpdatedAt and decays from there.
+type counter struct {
+	Score     float64   `json:"score"`
+	Count     int       `json:"count"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// pairKey identifies two products, the lower ID first
+type pairKey [2]int
+
+// pairEntry and setEntry are the persisted forms of the counters
+type pairEntry struct {
+	A int `json:"a"`
+	B int `json:"b"`
+	counter
+}
+
+type setEntry struct {
+	IDs []int `json:"ids"`
+	counter
+}
+
+// state is the content of the state file
+type state struct {
+	Pairs []pairEntry `json:"pairs"`
+	Sets  []setEntry  `json:"sets"`
+}
+
+// Pair is a product compared with another one
+type Pair struct {
+	ProductID int
+	Score     float64
+	Count     int
+	LastAt    time.Time
+}
+
+// Set is an ID set compared as a whole
+type Set struct {
+	IDs    []int
+	Score  float64
+	Count  int
+	LastAt time.Time
+}
+
+// Tracker keeps decayed co-occurrence counts of compared products
+type Tracker struct {
+	path string
+	opts Options
+
+	mu    sync.Mutex
+	pairs map[pairKey]*counter
+	sets  map[string]*setEntry
+	dirty bool
+	wg    sync.WaitGroup
+}
+
+// NewTracker creates a Tracker saving its state to the file at path and
+// loads the state saved there
+func NewTracker(path string, opts Options) (*Tracker, error) {
+	fmt.Printf("[CooccurrenceTracker][NewTracker] Initializing with state file: %s\n", path)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create co-occurrence directory: %w", err)
+	}
+
+	t := &Tracker{
+		path:  path,
+		opts:  opts,
+		pairs: make(map[pairKey]*counter),
+		sets:  make(map[string]*setEntry),
+	}
+	bytes, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return t, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read co-occurrence state: %w", err)
+	}
+	var saved state
+	if err := json.Unmarshal(bytes, &saved); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal co-occurrence state: %w", err)
+	}
+	for _, p := range saved.Pairs {
+		c := p.counter
+		t.pairs[newPairKey(p.A, p.B)] = &c
+	}
+	for _, s := range saved.Sets {
+		entry := s
+		t.sets[setKey(entry.IDs)] = &entry
+	}
+	fmt.Printf("[CooccurrenceTracker][NewTracker] Loaded %d pairs and %d sets\n", len(t.pairs), len(t.sets))
+	return t, nil
+}
+
+// Start launches the periodic pruning and saving. Once ctx is canceled the
+// state is saved one last time.
+func (t *Tracker) Start(ctx context.Context) {
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		ticker := time.NewTicker(t.opts.FlushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				t.flush(time.Now())
+				return
+			case <-ticker.C:
+				t.flush(time.Now())
+			}
+		}
+	}()
+}
+
+// Wait blocks until the final save is done
+func (t *Tracker) Wait() {
+	t.wg.Wait()
+}
+
+// Record counts one comparison of the given products. Sets of fewer than
+// two distinct products are ignored.
+func (t *Tracker) Record(ids []int) {
+	ids = slices.Clone(ids)
+	slices.Sort(ids)
+	ids = slices.Compact(ids)
+	if len(ids) < 2 {
+		return
+	}
+
+	now := time.Now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for i, a := range ids {
+		for _, b := range ids[i+1:] {
+			key := newPairKey(a, b)
+			c, ok := t.pairs[key]
+			if !ok {
+				c = &counter{}
+				t.pairs[key] = c
+			}
+			t.add(c, now)
+		}
+	}
+
+	key := setKey(ids)
+	s, ok := t.sets[key]
+	if !ok {
+		s = &setEntry{IDs: ids}
+		t.sets[key] = s
+	}
+	t.add(&s.counter, now)
+	t.dirty = true
+}
+
+// FrequentlyCompared returns up to limit products most often compared with
+// the product, highest decayed score first
+func (t *Tracker) FrequentlyCompared(id, limit int) []Pair {
+	now := time.Now()
+	t.mu.Lock()
+	var pairs []Pair
+	for key, c := range t.pairs {
+		other := key[0]
+		switch id {
+		case key[0]:
+			other = key[1]
+		case key[1]:
+		default:
+			continue
+		}
+		if score := t.decayed(c, now); score >= t.opts.MinScore {
+			pairs = append(pairs, Pair{ProductID: other, Score: round(score), Count: c.Count, LastAt: c.UpdatedAt})
+		}
+	}
+	t.mu.Unlock()
+
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].Score != pairs[j].Score {
+			return pairs[i].Score > pairs[j].Score
+		}
+		return pairs[i].ProductID < pairs[j].ProductID
+	})
+	if len(pairs) > limit {
+		pairs = pairs[:limit]
+	}
+	return pairs
+}
+
+// Popular returns up to limit ID sets compared most often, highest decayed
+// score first
+func (t *Tracker) Popular(limit int) []Set {
+	now := time.Now()
+	t.mu.Lock()
+	var sets []Set
+	for _, s := range t.sets {
+		if score := t.decayed(&s.counter, now); score >= t.opts.MinScore {
+			sets = append(sets, Set{IDs: slices.Clone(s.IDs), Score: round(score), Count: s.Count, LastAt: s.UpdatedAt})
+		}
+	}
+	t.mu.Unlock()
+
+	sort.Slice(sets, func(i, j int) bool {
+		if sets[i].Score != sets[j].Score {
+			return sets[i].Score > sets[j].Score
+		}
+		return slices.Compare(sets[i].IDs, sets[j].IDs) < 0
+	})
+	if len(sets) > limit {
+		sets = sets[:limit]
+	}
+	return sets
+}
+
+// Name identifies the tracker as an outbox sink
+func (t *Tracker) Name() string {
+	return "cooccurrence"
+}
+
+// Send forgets deleted products, so they are no longer suggested
+func (t *Tracker) Send(ctx context.Context, events []dto.ProductEvent) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, event := range events {
+		if event.Type != models.ChangeDeleted {
+			continue
+		}
+		for key := range t.pairs {
+			if key[0] == event.ProductID || key[1] == event.ProductID {
+				delete(t.pairs, key)
+				t.dirty = true
+			}
+		}
+		for key, s := range t.sets {
+			if slices.Contains(s.IDs, event.ProductID) {
+				delete(t.sets, key)
+				t.dirty = true
+			}
+		}
+	}
+	return nil
+}
+
+// add decays a counter to now and counts one comparison. Callers hold mu.
+func (t *Tracker) add(c *counter, now time.Time) {
+	c.Score = t.decayed(c, now) + 1
+	c.Count++
+	c.UpdatedAt = now
+}
+
+// decayed returns the score of a counter as of now
+func (t *Tracker) decayed(c *counter, now time.Time) float64 {
+	elapsed := now.Sub(c.UpdatedAt)
+	if elapsed <= 0 {
+		return c.Score
+	}
+	return c.Score * math.Exp2(-elapsed.Seconds()/t.opts.HalfLife.Seconds())
+}
+
+// flush drops the entries that decayed below MinScore and saves the state
+// when it changed
+func (t *Tracker) flush(now time.Time) {
+	t.mu.Lock()
+	for key, c := range t.pairs {
+		if t.decayed(c, now) < t.opts.MinScore {
+			delete(t.pairs, key)
+			t.dirty = true
+		}
+	}
+	for key, s := range t.sets {
+		if t.decayed(&s.counter, now) < t.opts.MinScore {
+			delete(t.sets, key)
+			t.dirty = true
+		}
+	}
+	if !t.dirty {
+		t.mu.Unlock()
+		return
+	}
+
+	saved := state{Pairs: make([]pairEntry, 0, len(t.pairs)), Sets: make([]setEntry, 0, len(t.sets))}
+	for key, c := range t.pairs {
+		saved.Pairs = append(saved.Pairs, pairEntry{A: key[0], B: key[1], counter: *c})
+	}
+	for _, s := range t.sets {
+		saved.Sets = append(saved.Sets, setEntry{IDs: slices.Clone(s.IDs), counter: s.counter})
+	}
+	t.dirty = false
+	t.mu.Unlock()
+
+	if err := t.save(saved); err != nil {
+		fmt.Printf("[CooccurrenceTracker][flush][ERROR] %v\n", err)
+		t.mu.Lock()
+		t.dirty = true
+		t.mu.Unlock()
+		return
+	}
+	fmt.Printf("[CooccurrenceTracker][flush] Saved %d pairs and %d sets\n", len(saved.Pairs), len(saved.Sets))
+}
+
+// save writes the state atomically, so a crash never leaves a half-written
+// file behind
+func (t *Tracker) save(saved state) error {
+	bytes, err := json.Marshal(saved)
+	if err != nil {
+		return fmt.Errorf("failed to marshal co-occurrence state: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(t.path), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write co-occurrence state: %w", err)
+	}
+	if _, err := tmp.Write(bytes); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write co-occurrence state: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write co-occurrence state: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), t.path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write co-occurrence state: %w", err)
+	}
+	return nil
+}
+
+// newPairKey orders the IDs of a pair
+func newPairKey(a, b int) pairKey {
+	if a > b {
+		a, b = b, a
+	}
+	return pairKey{a, b}
+}
+
+// setKey identifies a sorted ID set
+func setKey(ids []int) string {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.Itoa(id)
+	}
+	return strings.Join(parts, ",")
+}
+
+// round keeps scores readable
+func round(f float64) float64 {
+	return math.Round(f*1000) / 1000
+}
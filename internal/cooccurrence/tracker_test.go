@@ -0,0 +1,156 @@
+package cooccurrence
+
+import (
+	"context"
+	"math"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"item-comparison-api/internal/dto"
+	"item-comparison-api/internal/models"
+)
+
+func testTracker(t *testing.T) *Tracker {
+	t.Helper()
+	tracker, err := NewTracker(filepath.Join(t.TempDir(), "cooccurrence.json"), Options{HalfLife: time.Hour, MinScore: 0.1, FlushInterval: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tracker
+}
+
+func TestFrequentlyCompared(t *testing.T) {
+	tests := []struct {
+		name       string
+		recorded   [][]int
+		id         int
+		limit      int
+		wantIDs    []int
+		wantScores []float64
+	}{
+		{"pairs of a set", [][]int{{1, 2, 3}}, 1, 10, []int{2, 3}, []float64{1, 1}},
+		{"most compared first", [][]int{{1, 2}, {1, 3}, {3, 1}}, 1, 10, []int{3, 2}, []float64{2, 1}},
+		{"from either side", [][]int{{1, 2}, {2, 3}}, 2, 10, []int{1, 3}, []float64{1, 1}},
+		{"limited", [][]int{{1, 2}, {1, 3}, {1, 3}}, 1, 1, []int{3}, []float64{2}},
+		{"duplicate IDs count once", [][]int{{1, 1, 2, 2}}, 1, 10, []int{2}, []float64{1}},
+		{"single products are ignored", [][]int{{1}, {1, 1}}, 1, 10, nil, nil},
+		{"unknown product", [][]int{{1, 2}}, 4, 10, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := testTracker(t)
+			for _, ids := range tt.recorded {
+				tracker.Record(ids)
+			}
+			var ids []int
+			var scores []float64
+			for _, p := range tracker.FrequentlyCompared(tt.id, tt.limit) {
+				ids = append(ids, p.ProductID)
+				scores = append(scores, p.Score)
+			}
+			if !slices.Equal(ids, tt.wantIDs) || !slices.Equal(scores, tt.wantScores) {
+				t.Errorf("FrequentlyCompared(%d) = %v with scores %v, want %v with %v", tt.id, ids, scores, tt.wantIDs, tt.wantScores)
+			}
+		})
+	}
+}
+
+func TestPopular(t *testing.T) {
+	tracker := testTracker(t)
+	for _, ids := range [][]int{{3, 1}, {1, 3}, {1, 2, 3}, {2, 1}, {1, 3}} {
+		tracker.Record(ids)
+	}
+
+	var got [][]int
+	for _, s := range tracker.Popular(2) {
+		got = append(got, s.IDs)
+	}
+	want := [][]int{{1, 3}, {1, 2}}
+	if !slices.EqualFunc(got, want, slices.Equal[[]int]) {
+		t.Errorf("Popular = %v, want %v", got, want)
+	}
+}
+
+// TestDecay ages the recorded comparisons by whole half-lives
+func TestDecay(t *testing.T) {
+	tests := []struct {
+		name      string
+		age       time.Duration
+		wantScore float64
+	}{
+		{"fresh", 0, 2},
+		{"one half-life", time.Hour, 1},
+		{"two half-lives", 2 * time.Hour, 0.5},
+		{"below the minimum score", 5 * time.Hour, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := testTracker(t)
+			tracker.Record([]int{1, 2})
+			tracker.Record([]int{1, 2})
+			for _, c := range tracker.pairs {
+				c.UpdatedAt = c.UpdatedAt.Add(-tt.age)
+			}
+
+			var score float64
+			if pairs := tracker.FrequentlyCompared(1, 10); len(pairs) > 0 {
+				score = pairs[0].Score
+			}
+			if math.Abs(score-tt.wantScore) > 0.001 {
+				t.Errorf("score = %v, want %v", score, tt.wantScore)
+			}
+
+			// A new comparison adds to the decayed score
+			tracker.Record([]int{1, 2})
+			if got := tracker.FrequentlyCompared(1, 10)[0]; got.Count != 3 {
+				t.Errorf("count = %d, want 3", got.Count)
+			}
+		})
+	}
+}
+
+func TestSendForgetsDeletedProducts(t *testing.T) {
+	tracker := testTracker(t)
+	tracker.Record([]int{1, 2, 3})
+	tracker.Record([]int{1, 3})
+	if err := tracker.Send(context.Background(), []dto.ProductEvent{{Type: models.ChangeDeleted, ProductID: 2}}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, p := range tracker.FrequentlyCompared(1, 10) {
+		if p.ProductID == 2 {
+			t.Errorf("deleted product 2 is still suggested")
+		}
+	}
+	for _, s := range tracker.Popular(10) {
+		if slices.Contains(s.IDs, 2) {
+			t.Errorf("set %v with deleted product 2 is still popular", s.IDs)
+		}
+	}
+}
+
+func TestStateSurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cooccurrence.json")
+	opts := Options{HalfLife: time.Hour, MinScore: 0.1, FlushInterval: time.Minute}
+	tracker, err := NewTracker(path, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracker.Record([]int{1, 2})
+	tracker.flush(time.Now())
+
+	reloaded, err := NewTracker(path, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pairs := reloaded.FrequentlyCompared(1, 10); len(pairs) != 1 || pairs[0].ProductID != 2 || pairs[0].Count != 1 {
+		t.Errorf("reloaded pairs = %+v, want product 2 compared once", pairs)
+	}
+	if sets := reloaded.Popular(10); len(sets) != 1 || !slices.Equal(sets[0].IDs, []int{1, 2}) {
+		t.Errorf("reloaded sets = %+v, want [1 2]", sets)
+	}
+}
@@ -0,0 +1,57 @@
+package dto
+
+import "encoding/xml"
+
+// AlertRequest represents a price drop alert to create. Exactly one of
+// TargetPrice and DropPercent is set.
+type AlertRequest struct {
+	XMLName     xml.Name     `json:"-" xml:"alert"`
+	ProductID   int          `json:"product_id" xml:"product_id"`
+	TargetPrice float32      `json:"target_price,omitempty" xml:"target_price,omitempty"`
+	DropPercent float32      `json:"drop_percent,omitempty" xml:"drop_percent,omitempty"`
+	Channel     AlertChannel `json:"channel" xml:"channel"`
+}
+
+// AlertChannel represents how an alert is delivered. The secret is never
+// returned.
+type AlertChannel struct {
+	XMLName xml.Name `json:"-" xml:"channel"`
+	Type    string   `json:"type" xml:"type"`
+	URL     string   `json:"url,omitempty" xml:"url,omitempty"`
+	Secret  string   `json:"secret,omitempty" xml:"secret,omitempty"`
+}
+
+// AlertResponse represents a price drop alert and its state
+type AlertResponse struct {
+	XMLName        xml.Name     `json:"-" xml:"alert"`
+	ID             string       `json:"id" xml:"id"`
+	ProductID      int          `json:"product_id" xml:"product_id"`
+	TargetPrice    float32      `json:"target_price,omitempty" xml:"target_price,omitempty"`
+	DropPercent    float32      `json:"drop_percent,omitempty" xml:"drop_percent,omitempty"`
+	BasePrice      float32      `json:"base_price" xml:"base_price"`
+	Threshold      float32      `json:"threshold" xml:"threshold"`
+	LastPrice      float32      `json:"last_price" xml:"last_price"`
+	Channel        AlertChannel `json:"channel" xml:"channel"`
+	Status         string       `json:"status" xml:"status"`
+	TriggeredPrice float32      `json:"triggered_price,omitempty" xml:"triggered_price,omitempty"`
+	TriggeredAt    string       `json:"triggered_at,omitempty" xml:"triggered_at,omitempty"`
+	Attempts       int          `json:"attempts,omitempty" xml:"attempts,omitempty"`
+	Error          string       `json:"error,omitempty" xml:"error,omitempty"`
+	NotifiedAt     string       `json:"notified_at,omitempty" xml:"notified_at,omitempty"`
+	CreatedAt      string       `json:"created_at" xml:"created_at"`
+	UpdatedAt      string       `json:"updated_at" xml:"updated_at"`
+}
+
+// AlertNotification is the body sent when a price drop alert triggers
+type AlertNotification struct {
+	XMLName     xml.Name `json:"-" xml:"alert_notification"`
+	Type        string   `json:"type" xml:"type"`
+	AlertID     string   `json:"alert_id" xml:"alert_id"`
+	ProductID   int      `json:"product_id" xml:"product_id"`
+	ProductName string   `json:"product_name" xml:"product_name"`
+	BasePrice   float32  `json:"base_price" xml:"base_price"`
+	Price       float32  `json:"price" xml:"price"`
+	TargetPrice float32  `json:"target_price,omitempty" xml:"target_price,omitempty"`
+	DropPercent float32  `json:"drop_percent,omitempty" xml:"drop_percent,omitempty"`
+	TriggeredAt string   `json:"triggered_at" xml:"triggered_at"`
+}
@@ -0,0 +1,22 @@
+package dto
+
+import "encoding/xml"
+
+// BulkItemResult represents the outcome of one product in a bulk write
+type BulkItemResult struct {
+	XMLName xml.Name         `json:"-" xml:"result"`
+	Index   int              `json:"index" xml:"index"`
+	ID      int              `json:"id" xml:"id"`
+	Status  int              `json:"status" xml:"status"`
+	Code    string           `json:"code,omitempty" xml:"code,omitempty"`
+	Error   string           `json:"error,omitempty" xml:"error,omitempty"`
+	Product *ProductResponse `json:"product,omitempty" xml:"product,omitempty"`
+}
+
+// BulkResponse represents the per-item results of a bulk write
+type BulkResponse struct {
+	XMLName   xml.Name         `json:"-" xml:"bulk_response"`
+	Succeeded int              `json:"succeeded" xml:"succeeded"`
+	Failed    int              `json:"failed" xml:"failed"`
+	Results   []BulkItemResult `json:"results" xml:"results>result"`
+}
@@ -0,0 +1,9 @@
+package dto
+
+import "encoding/xml"
+
+// CompareRequest represents the structure of a product comparison request
+type CompareRequest struct {
+	XMLName xml.Name `json:"-" xml:"compare"`
+	IDs     []int    `json:"ids" xml:"ids>id"`
+}
@@ -0,0 +1,95 @@
+package dto
+
+import (
+	"encoding/xml"
+	"sort"
+	"strconv"
+)
+
+// Weights holds how much each attribute counts in a comparison, keyed by
+// attribute name. In XML each entry is written as <weight attribute="price">0.5</weight>.
+type Weights map[string]float64
+
+// xmlWeight is one weight entry in XML
+type xmlWeight struct {
+	Attribute string `xml:"attribute,attr"`
+	Value     string `xml:",chardata"`
+}
+
+// MarshalXML writes the entries sorted by attribute, so the output is stable
+func (w Weights) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	attributes := make([]string, 0, len(w))
+	for attribute := range w {
+		attributes = append(attributes, attribute)
+	}
+	sort.Strings(attributes)
+
+	entries := struct {
+		Weights []xmlWeight `xml:"weight"`
+	}{}
+	for _, attribute := range attributes {
+		entries.Weights = append(entries.Weights, xmlWeight{Attribute: attribute, Value: strconv.FormatFloat(w[attribute], 'g', -1, 64)})
+	}
+	return e.EncodeElement(entries, start)
+}
+
+// UnmarshalXML reads the <weight> entries of the element
+func (w *Weights) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var entries struct {
+		Weights []xmlWeight `xml:"weight"`
+	}
+	if err := d.DecodeElement(&entries, &start); err != nil {
+		return err
+	}
+	*w = make(Weights, len(entries.Weights))
+	for _, entry := range entries.Weights {
+		value, err := strconv.ParseFloat(entry.Value, 64)
+		if err != nil {
+			return err
+		}
+		(*w)[entry.Attribute] = value
+	}
+	return nil
+}
+
+// ComparisonRequest represents a comparison to save
+type ComparisonRequest struct {
+	XMLName xml.Name `json:"-" xml:"comparison"`
+	IDs     []int    `json:"ids" xml:"ids>id"`
+	Weights Weights  `json:"weights,omitempty" xml:"weights,omitempty"`
+	Notes   string   `json:"notes,omitempty" xml:"notes,omitempty"`
+}
+
+// ComparisonResponse represents a saved comparison. Products and Changes are
+// only filled in when a single comparison is retrieved.
+type ComparisonResponse struct {
+	XMLName   xml.Name          `json:"-" xml:"comparison"`
+	ID        string            `json:"id" xml:"id"`
+	IDs       []int             `json:"ids" xml:"ids>id"`
+	Weights   Weights           `json:"weights,omitempty" xml:"weights,omitempty"`
+	Notes     string            `json:"notes,omitempty" xml:"notes,omitempty"`
+	CreatedAt string            `json:"created_at" xml:"created_at"`
+	ExpiresAt string            `json:"expires_at,omitempty" xml:"expires_at,omitempty"`
+	Products  []ProductResponse `json:"products,omitempty" xml:"products>product,omitempty"`
+	Changes   []ProductDiff     `json:"changes,omitempty" xml:"changes>change,omitempty"`
+}
+
+// ProductDiff describes how a compared product changed since the comparison
+// was saved. Status is unchanged, changed or deleted.
+type ProductDiff struct {
+	XMLName         xml.Name    `json:"-" xml:"change"`
+	ProductID       int         `json:"product_id" xml:"product_id"`
+	Status          string      `json:"status" xml:"status"`
+	SnapshotVersion int         `json:"snapshot_version" xml:"snapshot_version"`
+	CurrentVersion  int         `json:"current_version,omitempty" xml:"current_version,omitempty"`
+	Fields          []FieldDiff `json:"fields,omitempty" xml:"fields>field,omitempty"`
+}
+
+// FieldDiff is one changed field, with both values in text form.
+// Specification entries are named specifications.<key>.
+type FieldDiff struct {
+	XMLName xml.Name `json:"-" xml:"field"`
+	Field   string   `json:"field" xml:"name,attr"`
+	Old     string   `json:"old" xml:"old"`
+	New     string   `json:"new" xml:"new"`
+}
@@ -0,0 +1,23 @@
+package dto
+
+import "encoding/xml"
+
+// ComparedProduct represents a product often compared with another one.
+// Score is the decayed number of comparisons, Count the total.
+type ComparedProduct struct {
+	XMLName        xml.Name        `json:"-" xml:"compared_product"`
+	Product        ProductResponse `json:"product" xml:"product"`
+	Score          float64         `json:"score" xml:"score"`
+	Count          int             `json:"count" xml:"count"`
+	LastComparedAt string          `json:"last_compared_at" xml:"last_compared_at"`
+}
+
+// PopularComparison represents an ID set often compared as a whole.
+// Score is the decayed number of comparisons, Count the total.
+type PopularComparison struct {
+	XMLName        xml.Name `json:"-" xml:"popular_comparison"`
+	IDs            []int    `json:"ids" xml:"ids>id"`
+	Score          float64  `json:"score" xml:"score"`
+	Count          int      `json:"count" xml:"count"`
+	LastComparedAt string   `json:"last_compared_at" xml:"last_compared_at"`
+}
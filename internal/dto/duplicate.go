@@ -0,0 +1,55 @@
+package dto
+
+import "encoding/xml"
+
+// DuplicateCandidate represents a pair of likely duplicate products in the
+// review queue, with its score between 0 and 1
+type DuplicateCandidate struct {
+	XMLName    xml.Name           `json:"-" xml:"candidate"`
+	ID         string             `json:"id" xml:"id"`
+	ProductIDs []int              `json:"product_ids" xml:"product_ids>id"`
+	Score      float64            `json:"score" xml:"score"`
+	Breakdown  DuplicateBreakdown `json:"breakdown" xml:"breakdown"`
+	Status     string             `json:"status" xml:"status"`
+	MergeID    string             `json:"merge_id,omitempty" xml:"merge_id,omitempty"`
+	CreatedAt  string             `json:"created_at" xml:"created_at"`
+	UpdatedAt  string             `json:"updated_at" xml:"updated_at"`
+}
+
+// DuplicateBreakdown holds the signals of a candidate's score. GTIN is set
+// when both products carry the same GTIN.
+type DuplicateBreakdown struct {
+	Name           float64 `json:"name" xml:"name"`
+	Brand          float64 `json:"brand" xml:"brand"`
+	Specifications float64 `json:"specifications" xml:"specifications"`
+	GTIN           bool    `json:"gtin" xml:"gtin"`
+}
+
+// DuplicateScanResponse represents the result of a duplicate scan
+type DuplicateScanResponse struct {
+	XMLName xml.Name `json:"-" xml:"scan"`
+	Scanned int      `json:"scanned" xml:"scanned"`
+	Pending int      `json:"pending" xml:"pending"`
+}
+
+// MergeRequest names the product of a candidate to keep
+type MergeRequest struct {
+	XMLName xml.Name `json:"-" xml:"merge"`
+	KeepID  int      `json:"keep_id" xml:"keep_id"`
+}
+
+// MergeResponse represents a merge with the last revisions of both
+// products before it and the revision it produced
+type MergeResponse struct {
+	XMLName     xml.Name        `json:"-" xml:"merge"`
+	ID          string          `json:"id" xml:"id"`
+	CandidateID string          `json:"candidate_id" xml:"candidate_id"`
+	TargetID    int             `json:"target_id" xml:"target_id"`
+	SourceID    int             `json:"source_id" xml:"source_id"`
+	Target      ProductResponse `json:"target" xml:"target>product"`
+	Source      ProductResponse `json:"source" xml:"source>product"`
+	Result      ProductResponse `json:"result" xml:"result>product"`
+	Redirect    bool            `json:"redirect" xml:"redirect"`
+	MergedBy    string          `json:"merged_by" xml:"merged_by"`
+	MergedAt    string          `json:"merged_at" xml:"merged_at"`
+}
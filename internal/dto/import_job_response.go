@@ -0,0 +1,30 @@
+package dto
+
+import "encoding/xml"
+
+// ImportJobResponse represents the progress and results of an import job
+type ImportJobResponse struct {
+	XMLName    xml.Name         `json:"-" xml:"import_job"`
+	ID         string           `json:"id" xml:"id"`
+	Mode       string           `json:"mode" xml:"mode"`
+	Format     string           `json:"format" xml:"format"`
+	Status     string           `json:"status" xml:"status"`
+	Total      int              `json:"total" xml:"total"`
+	Processed  int              `json:"processed" xml:"processed"`
+	Succeeded  int              `json:"succeeded" xml:"succeeded"`
+	Failed     int              `json:"failed" xml:"failed"`
+	Errors     []ImportRowError `json:"errors" xml:"errors>row_error"`
+	Error      string           `json:"error,omitempty" xml:"error,omitempty"`
+	CreatedAt  string           `json:"created_at" xml:"created_at"`
+	UpdatedAt  string           `json:"updated_at" xml:"updated_at"`
+	FinishedAt string           `json:"finished_at,omitempty" xml:"finished_at,omitempty"`
+}
+
+// ImportRowError represents a row of an import that failed
+type ImportRowError struct {
+	XMLName xml.Name `json:"-" xml:"row_error"`
+	Row     int      `json:"row" xml:"row"`
+	ID      int      `json:"id,omitempty" xml:"id,omitempty"`
+	Code    string   `json:"code" xml:"code"`
+	Error   string   `json:"error" xml:"error"`
+}
@@ -0,0 +1,9 @@
+package dto
+
+import "encoding/xml"
+
+// MessageResponse represents the confirmation of a write without a body of its own
+type MessageResponse struct {
+	XMLName xml.Name `json:"-" xml:"message"`
+	Message string   `json:"message" xml:",chardata"`
+}
@@ -0,0 +1,33 @@
+package dto
+
+import "encoding/xml"
+
+// OfferRequest represents a seller's offer for a product. The product and
+// seller come from the route and the credentials.
+type OfferRequest struct {
+	XMLName   xml.Name      `json:"-" xml:"offer"`
+	Price     float32       `json:"price" xml:"price"`
+	Stock     int           `json:"stock" xml:"stock"`
+	Condition string        `json:"condition,omitempty" xml:"condition,omitempty"`
+	Shipping  OfferShipping `json:"shipping" xml:"shipping"`
+}
+
+// OfferShipping represents the shipping cost and delivery days of an offer
+type OfferShipping struct {
+	Cost float32 `json:"cost" xml:"cost"`
+	Days int     `json:"days" xml:"days"`
+}
+
+// OfferResponse represents a seller's offer. TotalPrice includes shipping.
+type OfferResponse struct {
+	XMLName    xml.Name      `json:"-" xml:"offer"`
+	ProductID  int           `json:"product_id" xml:"product_id"`
+	SellerID   string        `json:"seller_id" xml:"seller_id"`
+	Price      float32       `json:"price" xml:"price"`
+	Stock      int           `json:"stock" xml:"stock"`
+	Condition  string        `json:"condition" xml:"condition"`
+	Shipping   OfferShipping `json:"shipping" xml:"shipping"`
+	TotalPrice float32       `json:"total_price" xml:"total_price"`
+	CreatedAt  string        `json:"created_at" xml:"created_at"`
+	UpdatedAt  string        `json:"updated_at" xml:"updated_at"`
+}
@@ -0,0 +1,21 @@
+package dto
+
+// Types of product change events
+const (
+	EventProductCreated = "product.created"
+	EventProductUpdated = "product.updated"
+	EventProductDeleted = "product.deleted"
+)
+
+// ProductEvent describes a product change after a successful write.
+// Product holds the new state and is omitted for deletions.
+type ProductEvent struct {
+	ID         string           `json:"id"`
+	Type       string           `json:"type"`
+	ProductID  int              `json:"product_id"`
+	SellerID   string           `json:"seller_id"`
+	Category   string           `json:"category,omitempty"`
+	Version    int              `json:"version"`
+	Product    *ProductResponse `json:"product,omitempty"`
+	OccurredAt string           `json:"occurred_at"`
+}
@@ -1,13 +1,22 @@
 package dto
 
-// ProductRequest represents the structure for product data received in requests
+import "encoding/xml"
+
+// ProductRequest represents the structure for product data received in
+// requests. GTIN is the barcode number, an EAN-8, UPC-A, EAN-13 or GTIN-14,
+// and MPN the manufacturer part number. Both are unique, the MPN per brand.
 type ProductRequest struct {
-	ID             int               `json:"id"`
-	Name           string            `json:"name" validate:"required"`
-	Description    string            `json:"description,omitempty"`
-	Price          float32           `json:"price" validate:"required,gt=0"`
-	Brand          string            `json:"brand"`
-	ImageUrl       string            `json:"image_url"`
-	Rating         float32           `json:"rating"`
-	Specifications map[string]string `json:"specifications"`
+	XMLName        xml.Name       `json:"-" xml:"product"`
+	ID             int            `json:"id" xml:"id"`
+	Name           string         `json:"name" xml:"name" validate:"required"`
+	Description    string         `json:"description,omitempty" xml:"description,omitempty"`
+	Price          float32        `json:"price" xml:"price" validate:"required,gt=0"`
+	Brand          string         `json:"brand" xml:"brand"`
+	GTIN           string         `json:"gtin,omitempty" xml:"gtin,omitempty"`
+	MPN            string         `json:"mpn,omitempty" xml:"mpn,omitempty"`
+	ImageUrl       string         `json:"image_url" xml:"image_url"`
+	Rating         float32        `json:"rating" xml:"rating"`
+	Specifications Specifications `json:"specifications" xml:"specifications,omitempty"`
+	// Version is the version an update is based on, 0 skips the check
+	Version int `json:"version,omitempty" xml:"version,omitempty"`
 }
@@ -1,14 +1,24 @@
 package dto
 
-// ProductResponse represents the structure for product data sent in responses
+import "encoding/xml"
+
+// ProductResponse represents the structure for product data sent in
+// responses. Comparisons add the best seller offer, which is the offer
+// element in XML.
 type ProductResponse struct {
-	ID             int               `json:"id"`
-	Name           string            `json:"name"`
-	Description    string            `json:"description,omitempty"`
-	Price          float32           `json:"price"`
-	Brand          string            `json:"brand"`
-	ImageUrl       string            `json:"image_url"`
-	Rating         float32           `json:"rating"`
-	Specifications map[string]string `json:"specifications"`
-	SellerID       string            `json:"seller_id"`
+	XMLName        xml.Name       `json:"-" xml:"product"`
+	ID             int            `json:"id" xml:"id"`
+	Name           string         `json:"name" xml:"name"`
+	Description    string         `json:"description,omitempty" xml:"description,omitempty"`
+	Price          float32        `json:"price" xml:"price"`
+	Brand          string         `json:"brand" xml:"brand"`
+	GTIN           string         `json:"gtin,omitempty" xml:"gtin,omitempty"`
+	MPN            string         `json:"mpn,omitempty" xml:"mpn,omitempty"`
+	ImageUrl       string         `json:"image_url" xml:"image_url"`
+	Rating         float32        `json:"rating" xml:"rating"`
+	Specifications Specifications `json:"specifications" xml:"specifications,omitempty"`
+	SellerID       string         `json:"seller_id" xml:"seller_id"`
+	Version        int            `json:"version" xml:"version"`
+	UpdatedAt      string         `json:"updated_at,omitempty" xml:"updated_at,omitempty"`
+	BestOffer      *OfferResponse `json:"best_offer,omitempty" xml:"offer,omitempty"`
 }
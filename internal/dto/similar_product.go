@@ -0,0 +1,20 @@
+package dto
+
+import "encoding/xml"
+
+// SimilarProduct represents a product recommended as an alternative, with
+// its overall similarity score between 0 and 1
+type SimilarProduct struct {
+	XMLName   xml.Name            `json:"-" xml:"similar"`
+	Product   ProductResponse     `json:"product" xml:"product"`
+	Score     float64             `json:"score" xml:"score"`
+	Breakdown SimilarityBreakdown `json:"breakdown" xml:"breakdown"`
+}
+
+// SimilarityBreakdown holds the score of each signal, between 0 and 1
+type SimilarityBreakdown struct {
+	Brand          float64 `json:"brand" xml:"brand"`
+	Category       float64 `json:"category" xml:"category"`
+	Price          float64 `json:"price" xml:"price"`
+	Specifications float64 `json:"specifications" xml:"specifications"`
+}
@@ -0,0 +1,62 @@
+package dto
+
+import (
+	"encoding/xml"
+	"sort"
+)
+
+// Specifications holds the free-form attributes of a product. In XML each
+// entry is written as <spec key="color">red</spec>, since maps have no XML form.
+type Specifications map[string]string
+
+// CategorySpec is the specification key holding a product's category
+const CategorySpec = "category"
+
+// Category returns the product category, empty when none is set
+func (s Specifications) Category() string {
+	return s[CategorySpec]
+}
+
+// Keys returns the specification keys in sorted order
+func (s Specifications) Keys() []string {
+	keys := make([]string, 0, len(s))
+	for key := range s {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// xmlSpec is one specification entry in XML
+type xmlSpec struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// MarshalXML writes the entries sorted by key, so the output is stable
+func (s Specifications) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	keys := s.Keys()
+
+	entries := struct {
+		Specs []xmlSpec `xml:"spec"`
+	}{}
+	for _, key := range keys {
+		entries.Specs = append(entries.Specs, xmlSpec{Key: key, Value: s[key]})
+	}
+	return e.EncodeElement(entries, start)
+}
+
+// UnmarshalXML reads the <spec> entries of the element
+func (s *Specifications) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var entries struct {
+		Specs []xmlSpec `xml:"spec"`
+	}
+	if err := d.DecodeElement(&entries, &start); err != nil {
+		return err
+	}
+	*s = make(Specifications, len(entries.Specs))
+	for _, spec := range entries.Specs {
+		(*s)[spec.Key] = spec.Value
+	}
+	return nil
+}
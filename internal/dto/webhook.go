@@ -0,0 +1,66 @@
+package dto
+
+import (
+	"encoding/json"
+	"encoding/xml"
+)
+
+// WebhookRequest represents a webhook subscription to create or replace.
+// Empty event types subscribe to every product event.
+type WebhookRequest struct {
+	XMLName    xml.Name      `json:"-" xml:"webhook"`
+	URL        string        `json:"url" xml:"url"`
+	EventTypes []string      `json:"event_types" xml:"event_types>event_type"`
+	Filter     WebhookFilter `json:"filter" xml:"filter"`
+	// Secret signs the deliveries, one is generated when empty
+	Secret string `json:"secret,omitempty" xml:"secret,omitempty"`
+	// Active defaults to true
+	Active *bool `json:"active,omitempty" xml:"active,omitempty"`
+}
+
+// WebhookFilter restricts the events a webhook receives
+type WebhookFilter struct {
+	XMLName    xml.Name `json:"-" xml:"filter"`
+	ProductIDs []int    `json:"product_ids,omitempty" xml:"product_ids>id,omitempty"`
+	SellerID   string   `json:"seller_id,omitempty" xml:"seller_id,omitempty"`
+	Category   string   `json:"category,omitempty" xml:"category,omitempty"`
+}
+
+// WebhookResponse represents a webhook subscription. The secret is only
+// returned when the webhook is created.
+type WebhookResponse struct {
+	XMLName    xml.Name      `json:"-" xml:"webhook"`
+	ID         string        `json:"id" xml:"id"`
+	URL        string        `json:"url" xml:"url"`
+	EventTypes []string      `json:"event_types" xml:"event_types>event_type"`
+	Filter     WebhookFilter `json:"filter" xml:"filter"`
+	Secret     string        `json:"secret,omitempty" xml:"secret,omitempty"`
+	Active     bool          `json:"active" xml:"active"`
+	CreatedAt  string        `json:"created_at" xml:"created_at"`
+	UpdatedAt  string        `json:"updated_at" xml:"updated_at"`
+}
+
+// WebhookDeliveryResponse represents one event sent to a webhook with its attempts
+type WebhookDeliveryResponse struct {
+	XMLName       xml.Name         `json:"-" xml:"delivery"`
+	ID            string           `json:"id" xml:"id"`
+	WebhookID     string           `json:"webhook_id" xml:"webhook_id"`
+	EventID       string           `json:"event_id,omitempty" xml:"event_id,omitempty"`
+	EventType     string           `json:"event_type" xml:"event_type"`
+	Payload       json.RawMessage  `json:"payload" xml:"payload"`
+	Status        string           `json:"status" xml:"status"`
+	Tries         int              `json:"tries" xml:"tries"`
+	Attempts      []WebhookAttempt `json:"attempts" xml:"attempts>attempt"`
+	NextAttemptAt string           `json:"next_attempt_at,omitempty" xml:"next_attempt_at,omitempty"`
+	CreatedAt     string           `json:"created_at" xml:"created_at"`
+	UpdatedAt     string           `json:"updated_at" xml:"updated_at"`
+}
+
+// WebhookAttempt represents one HTTP request of a delivery
+type WebhookAttempt struct {
+	XMLName    xml.Name `json:"-" xml:"attempt"`
+	At         string   `json:"at" xml:"at"`
+	StatusCode int      `json:"status_code,omitempty" xml:"status_code,omitempty"`
+	Error      string   `json:"error,omitempty" xml:"error,omitempty"`
+	DurationMs int64    `json:"duration_ms" xml:"duration_ms"`
+}
@@ -0,0 +1,80 @@
+package duplicates
+
+import (
+	"errors"
+	"item-comparison-api/internal/dto"
+	"slices"
+)
+
+// Candidate statuses. Pending candidates wait in the review queue until
+// they are dismissed or merged.
+const (
+	StatusPending   = "pending"
+	StatusDismissed = "dismissed"
+	StatusMerged    = "merged"
+)
+
+var (
+	// ErrCandidateNotFound is returned for unknown candidate IDs
+	ErrCandidateNotFound = errors.New("duplicate candidate not found")
+	// ErrForbidden is returned when a caller reviews products of someone else
+	ErrForbidden = errors.New("unauthorized: you do not own these products")
+	// ErrInvalidMerge is returned when the product to keep is not part of the candidate
+	ErrInvalidMerge = errors.New("invalid merge")
+	// ErrCandidateClosed is returned when reviewing a candidate that is no longer pending
+	ErrCandidateClosed = errors.New("duplicate candidate is no longer pending")
+)
+
+// Candidate is a pair of products that are likely duplicates, the lower ID
+// first. Score is between 0 and 1.
+type Candidate struct {
+	ID         string    `json:"id"`
+	ProductIDs []int     `json:"product_ids"`
+	Score      float64   `json:"score"`
+	Breakdown  Breakdown `json:"breakdown"`
+	Status     string    `json:"status"`
+	MergeID    string    `json:"merge_id,omitempty"`
+	CreatedAt  string    `json:"created_at"`
+	UpdatedAt  string    `json:"updated_at"`
+}
+
+// Breakdown holds the signals of a candidate's score. GTIN is set when both
+// products carry the same GTIN, which makes them duplicates on its own.
+type Breakdown struct {
+	Name           float64 `json:"name"`
+	Brand          float64 `json:"brand"`
+	Specifications float64 `json:"specifications"`
+	GTIN           bool    `json:"gtin"`
+}
+
+// clone returns a copy that shares no slices with c
+func (c *Candidate) clone() *Candidate {
+	copied := *c
+	copied.ProductIDs = slices.Clone(c.ProductIDs)
+	return &copied
+}
+
+// Merge records a product merged into another one. Target and Source are
+// the last revisions before the merge, Result the revision it produced, or
+// while pending the revision it writes.
+// Requests for the source ID are redirected to the target while Redirect is
+// set; it is cleared when the ID is taken by a new product. A merge is
+// recorded as Pending before any product changes and is finished, again
+// after a crash if need be, before it counts. MergedVersion is the version
+// the merged target is written at; with Result it tells finishing whether
+// that write happened.
+type Merge struct {
+	ID          string              `json:"id"`
+	CandidateID string              `json:"candidate_id"`
+	TargetID    int                 `json:"target_id"`
+	SourceID    int                 `json:"source_id"`
+	Target      dto.ProductResponse `json:"target"`
+	Source      dto.ProductResponse `json:"source"`
+	Result      dto.ProductResponse `json:"result"`
+	Redirect    bool                `json:"redirect"`
+	MergedBy    string              `json:"merged_by"`
+	MergedAt    string              `json:"merged_at"`
+	Pending     bool                `json:"pending,omitempty"`
+
+	MergedVersion int `json:"merged_version,omitempty"`
+}
@@ -0,0 +1,204 @@
+package duplicates
+
+import (
+	"item-comparison-api/internal/dto"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Weights of the signals in the score. Brand and specifications only count
+// when both products have them.
+const (
+	weightName           = 0.5
+	weightBrand          = 0.2
+	weightSpecifications = 0.3
+)
+
+// gtinSpecs are the specification keys a GTIN may be given under by products
+// without a GTIN
+var gtinSpecs = []string{"gtin", "ean", "upc"}
+
+// document is a product with its normalized signals
+type document struct {
+	product dto.ProductResponse
+	name    []string
+	brand   string
+	gtin    string
+	specs   map[string]string
+}
+
+// newDocument normalizes the signals of a product
+func newDocument(p dto.ProductResponse) *document {
+	doc := &document{
+		product: p,
+		name:    tokenSet(p.Name),
+		brand:   normalize(p.Brand),
+		gtin:    normalizeGTIN(p.GTIN),
+		specs:   make(map[string]string, len(p.Specifications)),
+	}
+	for key, value := range p.Specifications {
+		key = normalize(key)
+		if slices.Contains(gtinSpecs, key) {
+			if doc.gtin == "" {
+				doc.gtin = normalizeGTIN(value)
+			}
+			continue
+		}
+		if value = strings.Join(words(value), " "); value != "" {
+			doc.specs[key] = value
+		}
+	}
+	return doc
+}
+
+// similarity scores how likely two products are duplicates. Equal GTINs
+// make them duplicates, different GTINs rule it out.
+func similarity(a, b *document) (float64, Breakdown) {
+	breakdown := Breakdown{Name: jaccard(a.name, b.name)}
+	score, weights := weightName*breakdown.Name, weightName
+	if a.brand != "" && b.brand != "" {
+		breakdown.Brand = match(a.brand, b.brand)
+		score += weightBrand * breakdown.Brand
+		weights += weightBrand
+	}
+	if len(a.specs) > 0 && len(b.specs) > 0 {
+		breakdown.Specifications = specSimilarity(a.specs, b.specs)
+		score += weightSpecifications * breakdown.Specifications
+		weights += weightSpecifications
+	}
+	breakdown.Name = round(breakdown.Name)
+	breakdown.Specifications = round(breakdown.Specifications)
+
+	if a.gtin != "" && b.gtin != "" {
+		if a.gtin != b.gtin {
+			return 0, breakdown
+		}
+		breakdown.GTIN = true
+		return 1, breakdown
+	}
+	return round(score / weights), breakdown
+}
+
+// specSimilarity is the share of specification keys of either product
+// whose values are equal in both
+func specSimilarity(a, b map[string]string) float64 {
+	union := len(a)
+	equal := 0
+	for key, value := range b {
+		other, ok := a[key]
+		if !ok {
+			union++
+			continue
+		}
+		if other == value {
+			equal++
+		}
+	}
+	return float64(equal) / float64(union)
+}
+
+// jaccard returns the share of tokens two sorted token sets have in common
+func jaccard(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	common := 0
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] == b[j]:
+			common++
+			i++
+			j++
+		case a[i] < b[j]:
+			i++
+		default:
+			j++
+		}
+	}
+	return float64(common) / float64(len(a)+len(b)-common)
+}
+
+// tokenSet returns the sorted distinct words of a value
+func tokenSet(value string) []string {
+	seen := make(map[string]bool)
+	var tokens []string
+	for _, word := range words(value) {
+		if !seen[word] {
+			seen[word] = true
+			tokens = append(tokens, word)
+		}
+	}
+	sort.Strings(tokens)
+	return tokens
+}
+
+// words splits a value into lower case runs of letters and of digits, so
+// "WH-1000XM5" and "wh 1000 xm5" give the same words
+func words(value string) []string {
+	var words []string
+	var current []rune
+	kind := 0
+	flush := func() {
+		if len(current) > 0 {
+			words = append(words, string(current))
+			current = current[:0]
+		}
+	}
+	for _, r := range strings.ToLower(value) {
+		switch {
+		case unicode.IsLetter(r):
+			if kind != 1 {
+				flush()
+			}
+			kind = 1
+		case unicode.IsDigit(r):
+			if kind != 2 {
+				flush()
+			}
+			kind = 2
+		default:
+			flush()
+			kind = 0
+			continue
+		}
+		current = append(current, r)
+	}
+	flush()
+	return words
+}
+
+// normalizeGTIN keeps the digits of a GTIN, padded to 14 so UPC, EAN and
+// GTIN-14 forms of the same number are equal
+func normalizeGTIN(value string) string {
+	var digits []rune
+	for _, r := range value {
+		if r >= '0' && r <= '9' {
+			digits = append(digits, r)
+		}
+	}
+	if len(digits) == 0 || len(digits) > 14 {
+		return ""
+	}
+	return strings.Repeat("0", 14-len(digits)) + string(digits)
+}
+
+// normalize lower-cases and trims a value for exact comparison
+func normalize(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
+
+// match scores 1 for equal values
+func match(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+	return 0
+}
+
+// round keeps scores readable
+func round(f float64) float64 {
+	return math.Round(f*1000) / 1000
+}
@@ -0,0 +1,721 @@
+// Package duplicates detects products that are likely the same item,
+// created more than once. Products are compared by normalized name, brand,
+// GTIN and specifications when they are written and in a batch scan.
+// Likely duplicates wait in a review queue, where they are dismissed or
+// merged into one product. Requests for a merged product's ID are
+// redirected to the product it was merged into.
+package duplicates
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"item-comparison-api/internal/dto"
+	"item-comparison-api/internal/models"
+	"item-comparison-api/internal/offers"
+	"item-comparison-api/internal/repository"
+	"item-comparison-api/internal/services"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// idAlphabet and idLength define the merge IDs
+const (
+	idAlphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	idLength   = 8
+)
+
+// Options tune the detection. Pairs scoring at least Threshold are flagged.
+// Reviewers may review every candidate; other sellers only review
+// candidates whose products they own.
+type Options struct {
+	Threshold float64
+	Reviewers []string
+}
+
+// Manager keeps the products indexed for detection, the review queue and
+// the merges
+type Manager struct {
+	dir    string
+	repo   repository.ProductRepo
+	offers *offers.Manager
+	opts   Options
+
+	mu   sync.Mutex
+	docs map[int]*document
+	// blocks maps name tokens and GTINs to the products having them, only
+	// products sharing one are compared
+	blocks     map[string]map[int]bool
+	candidates map[string]*Candidate
+	merges     map[string]*Merge
+	redirects  map[int]int
+}
+
+// NewManager creates a Manager keeping its candidates and merges in dir and
+// loads the ones saved there. Merges write through repo and move the
+// merged product's offers.
+func NewManager(dir string, repo repository.ProductRepo, offers *offers.Manager, opts Options) (*Manager, error) {
+	fmt.Printf("[DuplicateManager][NewManager] Initializing with directory: %s\n", dir)
+	for _, sub := range []string{"candidates", "merges"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create duplicates directory: %w", err)
+		}
+	}
+	candidates, err := loadFiles[Candidate](filepath.Join(dir, "candidates"))
+	if err != nil {
+		return nil, err
+	}
+	merges, err := loadFiles[Merge](filepath.Join(dir, "merges"))
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Manager{
+		dir:        dir,
+		repo:       repo,
+		offers:     offers,
+		opts:       opts,
+		docs:       make(map[int]*document),
+		blocks:     make(map[string]map[int]bool),
+		candidates: make(map[string]*Candidate, len(candidates)),
+		merges:     make(map[string]*Merge, len(merges)),
+		redirects:  make(map[int]int),
+	}
+	for _, c := range candidates {
+		m.candidates[c.ID] = c
+	}
+	for _, merge := range merges {
+		m.merges[merge.ID] = merge
+		if merge.Redirect && !merge.Pending {
+			m.redirects[merge.SourceID] = merge.TargetID
+		}
+	}
+	fmt.Printf("[DuplicateManager][NewManager] Loaded %d candidates and %d merges\n", len(candidates), len(merges))
+
+	// Finish the merges a crash interrupted, the rest are retried on the
+	// next start or when the candidate is merged again
+	for _, merge := range merges {
+		if !merge.Pending {
+			continue
+		}
+		if _, err := m.finish(context.Background(), merge); err != nil {
+			fmt.Printf("[DuplicateManager][NewManager][ERROR] Failed to finish merge %s: %v\n", merge.ID, err)
+			continue
+		}
+		fmt.Printf("[DuplicateManager][NewManager] Finished merge %s of product %d into product %d\n", merge.ID, merge.SourceID, merge.TargetID)
+	}
+	return m, nil
+}
+
+// Load indexes every product of the repository. Nothing is flagged until
+// the products change or a scan runs.
+func (m *Manager) Load(ctx context.Context) error {
+	fmt.Printf("[DuplicateManager][Load] Loading products from repository\n")
+	products, err := m.repo.LoadProducts(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load products for duplicate detection: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, p := range products {
+		m.put(services.ProductToResponse(p))
+	}
+	fmt.Printf("[DuplicateManager][Load] Indexed %d products\n", len(m.docs))
+	return nil
+}
+
+// Name identifies the manager as an outbox sink
+func (m *Manager) Name() string {
+	return "duplicates"
+}
+
+// Send checks every created or updated product against the others. The
+// candidates of deleted products are dropped, except for merged ones.
+func (m *Manager) Send(ctx context.Context, events []dto.ProductEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now().Format(time.RFC3339)
+	for _, event := range events {
+		switch {
+		case event.Type == models.ChangeDeleted:
+			m.remove(event.ProductID)
+			for id, c := range m.candidates {
+				// The source of a pending merge is deleted before the merge is done
+				if c.Status != StatusMerged && slices.Contains(c.ProductIDs, event.ProductID) && m.merging(id) == nil {
+					if err := m.dropCandidate(id); err != nil {
+						return err
+					}
+				}
+			}
+		case event.Product != nil:
+			// A new product taking a merged product's ID ends its redirect
+			if event.Type == models.ChangeCreated {
+				if err := m.endRedirect(event.ProductID); err != nil {
+					return err
+				}
+			}
+			m.put(*event.Product)
+			if _, err := m.evaluate(m.docs[event.ProductID], now, false); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Scan checks every pair of products sharing a name token or GTIN and
+// returns the number of products scanned and of pending candidates found
+func (m *Manager) Scan(ctx context.Context) (int, int, error) {
+	fmt.Printf("[DuplicateManager][Scan] Scanning for duplicates\n")
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now().Format(time.RFC3339)
+	ids := slices.Sorted(maps.Keys(m.docs))
+	flagged := 0
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return 0, 0, err
+		}
+		n, err := m.evaluate(m.docs[id], now, true)
+		if err != nil {
+			return 0, 0, err
+		}
+		flagged += n
+	}
+	fmt.Printf("[DuplicateManager][Scan] Scanned %d products, %d pending candidates\n", len(ids), flagged)
+	return len(ids), flagged, nil
+}
+
+// List returns the candidates with the status the caller may review,
+// highest score first
+func (m *Manager) List(callerID, status string) []*Candidate {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	candidates := []*Candidate{}
+	for _, c := range m.candidates {
+		if c.Status != status || !m.visible(callerID, c) {
+			continue
+		}
+		candidates = append(candidates, c.clone())
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Score != candidates[j].Score {
+			return candidates[i].Score > candidates[j].Score
+		}
+		return candidates[i].ID < candidates[j].ID
+	})
+	return candidates
+}
+
+// Dismiss marks a pending candidate as not a duplicate. It is not flagged
+// again.
+func (m *Manager) Dismiss(ctx context.Context, id, callerID string) (*Candidate, error) {
+	fmt.Printf("[DuplicateManager][Dismiss] Called with ID: %s, callerID: %s\n", id, callerID)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, err := m.pending(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.authorize(ctx, callerID, c.ProductIDs...); err != nil {
+		return nil, err
+	}
+
+	updated := c.clone()
+	updated.Status = StatusDismissed
+	updated.UpdatedAt = time.Now().Format(time.RFC3339)
+	if err := saveFile(filepath.Join(m.dir, "candidates"), id, updated); err != nil {
+		return nil, err
+	}
+	m.candidates[id] = updated
+	return updated.clone(), nil
+}
+
+// Merge merges the other product of a pending candidate into the product
+// to keep. The kept product takes over the fields and specifications it
+// lacks and the offers, and its version goes up. The other product is
+// deleted and its ID redirected to the kept one.
+func (m *Manager) Merge(ctx context.Context, id string, keepID int, callerID string) (*Merge, error) {
+	fmt.Printf("[DuplicateManager][Merge] Called with ID: %s, keepID: %d, callerID: %s\n", id, keepID, callerID)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, err := m.pending(id)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(c.ProductIDs, keepID) {
+		return nil, fmt.Errorf("%w: product %d is not part of candidate %s", ErrInvalidMerge, keepID, id)
+	}
+	sourceID := c.ProductIDs[0]
+	if sourceID == keepID {
+		sourceID = c.ProductIDs[1]
+	}
+	if err := m.authorize(ctx, callerID, c.ProductIDs...); err != nil {
+		return nil, err
+	}
+
+	// A merge that failed half way is finished rather than started again
+	merge := m.merging(id)
+	if merge != nil && merge.TargetID != keepID {
+		return nil, fmt.Errorf("%w: candidate %s is being merged into product %d", ErrInvalidMerge, id, merge.TargetID)
+	}
+	if merge == nil {
+		target, err := m.repo.GetProductByID(ctx, keepID)
+		if err != nil {
+			return nil, err
+		}
+		source, err := m.repo.GetProductByID(ctx, sourceID)
+		if err != nil {
+			return nil, err
+		}
+		mergeID, err := newID()
+		if err != nil {
+			return nil, err
+		}
+		mergedAt := time.Now().Format(time.RFC3339)
+		merged := mergedRevision(*target, *source, mergedAt)
+		merge = &Merge{
+			ID:          mergeID,
+			CandidateID: id,
+			TargetID:    keepID,
+			SourceID:    sourceID,
+			Target:      services.ProductToResponse(*target),
+			Source:      services.ProductToResponse(*source),
+			Result:      services.ProductToResponse(merged),
+			Redirect:    true,
+			MergedBy:    callerID,
+			MergedAt:    mergedAt,
+			Pending:     true,
+
+			MergedVersion: merged.Version,
+		}
+
+		// Record the merge before any product changes, so it can be
+		// finished after a crash
+		if err := saveFile(filepath.Join(m.dir, "merges"), merge.ID, merge); err != nil {
+			return nil, err
+		}
+		m.merges[merge.ID] = merge
+	}
+
+	done, err := m.finish(ctx, merge)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Printf("[DuplicateManager][Merge] Merged product %d into product %d\n", sourceID, keepID)
+	copied := *done
+	return &copied, nil
+}
+
+// finish carries out a pending merge and records it as done. Each step
+// checks whether it already happened, so a merge interrupted by an error or
+// a crash is finished by running it again. A target changed by someone else
+// before the merged write fails the merge with ErrVersionConflict; a source
+// changed before it is deleted is merged again. Callers hold mu.
+func (m *Manager) finish(ctx context.Context, merge *Merge) (*Merge, error) {
+	target, err := m.repo.GetProductByID(ctx, merge.TargetID)
+	if err != nil {
+		return nil, err
+	}
+	source, err := m.repo.GetProductByID(ctx, merge.SourceID)
+	sourceGone := errors.Is(err, repository.ErrNotFound)
+	if err != nil && !sourceGone {
+		return nil, err
+	}
+
+	// Write the merged product first, the source stays until its offers moved
+	switch {
+	case target.Version == merge.MergedVersion && isMergeResult(*target, merge.Result):
+		// Written before the merge was interrupted
+	case sourceGone && target.Version > merge.MergedVersion:
+		// The source is only deleted once the merged product is written
+	case target.Version == merge.Target.Version:
+		if sourceGone {
+			return nil, fmt.Errorf("product with ID %d %w, it can't be merged", merge.SourceID, repository.ErrNotFound)
+		}
+		if source.Version != merge.Source.Version {
+			// Nothing is written yet, merge the source as it is now
+			if merge, err = m.restart(merge, *target, *source); err != nil {
+				return nil, err
+			}
+		}
+		merged := mergedRevision(*target, *source, merge.MergedAt)
+		if err := m.repo.UpdateProducts(ctx, []models.Product{merged}); err != nil {
+			return nil, err
+		}
+		target = &merged
+	default:
+		return nil, fmt.Errorf("product with ID %d changed during merge %s, it is at version %d: %w",
+			merge.TargetID, merge.ID, target.Version, repository.ErrVersionConflict)
+	}
+
+	// A source changed after the merged write is merged again, so none of
+	// its changes are lost when it is deleted
+	if !sourceGone && source.Version != merge.Source.Version {
+		restarted, err := m.restart(merge, *target, *source)
+		if err != nil {
+			return nil, err
+		}
+		return m.finish(ctx, restarted)
+	}
+
+	if err := m.offers.Move(merge.SourceID, merge.TargetID); err != nil {
+		return nil, err
+	}
+	if !sourceGone {
+		if err := m.repo.DeleteByID(ctx, merge.SourceID, merge.Source.Version); err != nil {
+			return nil, err
+		}
+	}
+
+	// Identifiers are unique, so the target can only take over those of the
+	// source once the source is gone. The merge stands if that fails.
+	if adopted, ok := adoptIdentifiers(*target, merge.Source); ok {
+		adopted.Version++
+		if err := m.repo.UpdateProducts(ctx, []models.Product{adopted}); err != nil {
+			fmt.Printf("[DuplicateManager][finish][ERROR] Product %d keeps its identifiers: %v\n", merge.TargetID, err)
+		} else {
+			target = &adopted
+		}
+	}
+
+	if c, ok := m.candidates[merge.CandidateID]; ok && c.Status != StatusMerged {
+		updated := c.clone()
+		updated.Status = StatusMerged
+		updated.MergeID = merge.ID
+		updated.UpdatedAt = merge.MergedAt
+		if err := saveFile(filepath.Join(m.dir, "candidates"), updated.ID, updated); err != nil {
+			return nil, err
+		}
+		m.candidates[updated.ID] = updated
+	}
+
+	done := *merge
+	done.Result = services.ProductToResponse(*target)
+	done.Pending = false
+	if err := saveFile(filepath.Join(m.dir, "merges"), done.ID, &done); err != nil {
+		return nil, err
+	}
+	m.merges[done.ID] = &done
+	m.redirects[done.SourceID] = done.TargetID
+	return &done, nil
+}
+
+// restart records a pending merge again from the current target and source,
+// so finishing it merges them as they are now. Callers hold mu.
+func (m *Manager) restart(merge *Merge, target, source models.Product) (*Merge, error) {
+	fmt.Printf("[DuplicateManager][restart] Product %d changed during merge %s, merging it again\n", merge.SourceID, merge.ID)
+	merged := mergedRevision(target, source, merge.MergedAt)
+	restarted := *merge
+	restarted.Target = services.ProductToResponse(target)
+	restarted.Source = services.ProductToResponse(source)
+	restarted.Result = services.ProductToResponse(merged)
+	restarted.MergedVersion = merged.Version
+	if err := saveFile(filepath.Join(m.dir, "merges"), restarted.ID, &restarted); err != nil {
+		return nil, err
+	}
+	m.merges[restarted.ID] = &restarted
+	return &restarted, nil
+}
+
+// merging returns the pending merge of a candidate, or nil. Callers hold mu.
+func (m *Manager) merging(candidateID string) *Merge {
+	for _, merge := range m.merges {
+		if merge.Pending && merge.CandidateID == candidateID {
+			return merge
+		}
+	}
+	return nil
+}
+
+// Merges returns the merges involving a product, or every merge for 0,
+// newest first
+func (m *Manager) Merges(productID int) []*Merge {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	merges := []*Merge{}
+	for _, merge := range m.merges {
+		if merge.Pending || (productID != 0 && merge.TargetID != productID && merge.SourceID != productID) {
+			continue
+		}
+		copied := *merge
+		merges = append(merges, &copied)
+	}
+	sort.Slice(merges, func(i, j int) bool {
+		if merges[i].MergedAt != merges[j].MergedAt {
+			return merges[i].MergedAt > merges[j].MergedAt
+		}
+		return merges[i].ID > merges[j].ID
+	})
+	return merges
+}
+
+// Resolve returns the ID a product ID redirects to, following merges of
+// merged products, or the ID itself
+func (m *Manager) Resolve(id int) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// A chain is never longer than the number of redirects
+	for range len(m.redirects) {
+		next, ok := m.redirects[id]
+		if !ok {
+			break
+		}
+		id = next
+	}
+	return id
+}
+
+// evaluate compares a product with the products sharing a name token or
+// GTIN. Pairs at or above the threshold become pending candidates, pending
+// candidates below it are dropped. With higherOnly set, only products with
+// a higher ID are compared, so a scan looks at each pair once. It returns
+// the number of pending candidates found. Callers hold mu.
+func (m *Manager) evaluate(doc *document, now string, higherOnly bool) (int, error) {
+	others := make(map[int]bool)
+	for _, key := range blockKeys(doc) {
+		for id := range m.blocks[key] {
+			if id != doc.product.ID && (!higherOnly || id > doc.product.ID) {
+				others[id] = true
+			}
+		}
+	}
+
+	flagged := 0
+	for _, otherID := range slices.Sorted(maps.Keys(others)) {
+		score, breakdown := similarity(doc, m.docs[otherID])
+		ids := []int{min(doc.product.ID, otherID), max(doc.product.ID, otherID)}
+		id := fmt.Sprintf("%d-%d", ids[0], ids[1])
+		existing, ok := m.candidates[id]
+
+		if score < m.opts.Threshold {
+			if ok && existing.Status == StatusPending && m.merging(id) == nil {
+				if err := m.dropCandidate(id); err != nil {
+					return flagged, err
+				}
+			}
+			continue
+		}
+		// Reviewed candidates stay as they were reviewed
+		if ok && existing.Status != StatusPending {
+			continue
+		}
+
+		c := &Candidate{ID: id, ProductIDs: ids, Score: score, Breakdown: breakdown, Status: StatusPending, CreatedAt: now, UpdatedAt: now}
+		if ok {
+			c.CreatedAt = existing.CreatedAt
+		}
+		if err := saveFile(filepath.Join(m.dir, "candidates"), id, c); err != nil {
+			return flagged, err
+		}
+		if !ok {
+			fmt.Printf("[DuplicateManager][evaluate] Flagged products %d and %d as likely duplicates, score %v\n", ids[0], ids[1], score)
+		}
+		m.candidates[id] = c
+		flagged++
+	}
+	return flagged, nil
+}
+
+// put adds or replaces an indexed product. Callers hold mu.
+func (m *Manager) put(p dto.ProductResponse) {
+	m.remove(p.ID)
+	doc := newDocument(p)
+	for _, key := range blockKeys(doc) {
+		if m.blocks[key] == nil {
+			m.blocks[key] = make(map[int]bool)
+		}
+		m.blocks[key][p.ID] = true
+	}
+	m.docs[p.ID] = doc
+}
+
+// remove drops an indexed product. Callers hold mu.
+func (m *Manager) remove(id int) {
+	doc, ok := m.docs[id]
+	if !ok {
+		return
+	}
+	for _, key := range blockKeys(doc) {
+		if delete(m.blocks[key], id); len(m.blocks[key]) == 0 {
+			delete(m.blocks, key)
+		}
+	}
+	delete(m.docs, id)
+}
+
+// dropCandidate removes a candidate. Callers hold mu.
+func (m *Manager) dropCandidate(id string) error {
+	if err := removeFile(filepath.Join(m.dir, "candidates"), id); err != nil {
+		return err
+	}
+	delete(m.candidates, id)
+	return nil
+}
+
+// endRedirect stops redirecting a product ID. Callers hold mu.
+func (m *Manager) endRedirect(productID int) error {
+	if _, ok := m.redirects[productID]; !ok {
+		return nil
+	}
+	for _, merge := range m.merges {
+		if merge.SourceID != productID || !merge.Redirect {
+			continue
+		}
+		updated := *merge
+		updated.Redirect = false
+		if err := saveFile(filepath.Join(m.dir, "merges"), merge.ID, &updated); err != nil {
+			return err
+		}
+		m.merges[merge.ID] = &updated
+	}
+	delete(m.redirects, productID)
+	fmt.Printf("[DuplicateManager][endRedirect] Product ID %d is taken again, no longer redirected\n", productID)
+	return nil
+}
+
+// pending returns a candidate that waits for review. Callers hold mu.
+func (m *Manager) pending(id string) (*Candidate, error) {
+	c, ok := m.candidates[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrCandidateNotFound, id)
+	}
+	if c.Status != StatusPending {
+		return nil, fmt.Errorf("%w: %s is %s", ErrCandidateClosed, id, c.Status)
+	}
+	return c, nil
+}
+
+// authorize lets reviewers and the owner of all the products review them
+func (m *Manager) authorize(ctx context.Context, callerID string, productIDs ...int) error {
+	if slices.Contains(m.opts.Reviewers, callerID) {
+		return nil
+	}
+	for _, id := range productIDs {
+		product, err := m.repo.GetProductByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if product.SellerID != callerID {
+			return ErrForbidden
+		}
+	}
+	return nil
+}
+
+// visible reports whether the caller may see a candidate: reviewers see
+// all, sellers the ones with a product they own. Callers hold mu.
+func (m *Manager) visible(callerID string, c *Candidate) bool {
+	if slices.Contains(m.opts.Reviewers, callerID) {
+		return true
+	}
+	for _, id := range c.ProductIDs {
+		if doc, ok := m.docs[id]; ok && doc.product.SellerID == callerID {
+			return true
+		}
+	}
+	return false
+}
+
+// blockKeys returns the name tokens and GTIN of a product
+func blockKeys(doc *document) []string {
+	keys := slices.Clone(doc.name)
+	if doc.gtin != "" {
+		keys = append(keys, "gtin:"+doc.gtin)
+	}
+	return keys
+}
+
+// mergeProducts returns the target with the fields and specifications it
+// lacks taken from the source
+func mergeProducts(target, source models.Product) models.Product {
+	merged := target
+	if merged.Description == "" {
+		merged.Description = source.Description
+	}
+	if merged.Brand == "" {
+		merged.Brand = source.Brand
+	}
+	if merged.ImageUrl == "" {
+		merged.ImageUrl = source.ImageUrl
+	}
+	if merged.Rating == 0 {
+		merged.Rating = source.Rating
+	}
+	merged.Specifications = maps.Clone(target.Specifications)
+	if merged.Specifications == nil && len(source.Specifications) > 0 {
+		merged.Specifications = make(map[string]string, len(source.Specifications))
+	}
+	for key, value := range source.Specifications {
+		if _, ok := merged.Specifications[key]; !ok {
+			merged.Specifications[key] = value
+		}
+	}
+	return merged
+}
+
+// mergedRevision returns the revision of the target a merge writes
+func mergedRevision(target, source models.Product, mergedAt string) models.Product {
+	merged := mergeProducts(target, source)
+	merged.Version = target.Version + 1
+	merged.UpdatedAt = mergedAt
+	return merged
+}
+
+// isMergeResult reports whether the stored target is the revision the merge
+// writes, rather than one a seller wrote at the same version
+func isMergeResult(target models.Product, result dto.ProductResponse) bool {
+	written := services.ProductToResponse(target)
+	return written.Version == result.Version &&
+		written.UpdatedAt == result.UpdatedAt &&
+		written.Name == result.Name &&
+		written.Description == result.Description &&
+		written.Price == result.Price &&
+		written.Brand == result.Brand &&
+		written.ImageUrl == result.ImageUrl &&
+		written.Rating == result.Rating &&
+		maps.Equal(written.Specifications, result.Specifications)
+}
+
+// adoptIdentifiers gives the merged product the GTIN and MPN of the source
+// it lacks, and reports whether it took any
+func adoptIdentifiers(merged models.Product, source dto.ProductResponse) (models.Product, bool) {
+	adopted := false
+	if merged.GTIN == "" && source.GTIN != "" {
+		merged.GTIN = source.GTIN
+		adopted = true
+	}
+	if merged.MPN == "" && source.MPN != "" && strings.EqualFold(merged.Brand, source.Brand) {
+		merged.MPN = source.MPN
+		adopted = true
+	}
+	return merged, adopted
+}
+
+// newID returns a random merge ID
+func newID() (string, error) {
+	b := make([]byte, idLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate merge ID: %w", err)
+	}
+	for i := range b {
+		// 256 is not a multiple of 62, the slight bias doesn't matter here
+		b[i] = idAlphabet[int(b[i])%len(idAlphabet)]
+	}
+	return string(b), nil
+}
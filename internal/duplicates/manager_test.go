@@ -0,0 +1,224 @@
+package duplicates
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"item-comparison-api/internal/models"
+	"item-comparison-api/internal/offers"
+	"item-comparison-api/internal/repository"
+	"item-comparison-api/internal/services"
+)
+
+const testMergedAt = "2026-01-02T03:04:05Z"
+
+func testProducts() (target, source models.Product) {
+	target = models.Product{ID: 1, Name: "Phone X", Brand: "Acme", SellerID: "s1", Price: 100, Version: 1,
+		CreatedAt: "2026-01-01T00:00:00Z", Specifications: map[string]string{"storage": "128GB"}}
+	source = models.Product{ID: 2, Name: "Phone X", Description: "A phone", SellerID: "s1", Price: 100, Version: 1,
+		CreatedAt: "2026-01-01T00:00:00Z", Specifications: map[string]string{"color": "black"}}
+	return target, source
+}
+
+// pendingMerge is the merge record Merge saves before changing any product
+func pendingMerge(target, source models.Product) *Merge {
+	merged := mergedRevision(target, source, testMergedAt)
+	return &Merge{
+		ID:            "m1",
+		CandidateID:   "c1",
+		TargetID:      target.ID,
+		SourceID:      source.ID,
+		Target:        services.ProductToResponse(target),
+		Source:        services.ProductToResponse(source),
+		Result:        services.ProductToResponse(merged),
+		Redirect:      true,
+		MergedAt:      testMergedAt,
+		Pending:       true,
+		MergedVersion: merged.Version,
+	}
+}
+
+// TestNewManagerFinishesPendingMerge replays a merge interrupted at each
+// step, and with the products changed by sellers in between
+func TestNewManagerFinishesPendingMerge(t *testing.T) {
+	tests := []struct {
+		name string
+		// before runs after the merge was recorded, as a crash left it
+		before      func(ctx context.Context, repo repository.ProductRepo, target, source models.Product) error
+		wantPending bool
+		wantVersion int
+		wantDesc    string
+		wantSpecs   map[string]string
+		wantSource  bool
+	}{
+		{
+			name:        "nothing written",
+			wantVersion: 2,
+			wantDesc:    "A phone",
+			wantSpecs:   map[string]string{"storage": "128GB", "color": "black"},
+		},
+		{
+			name: "merged product written",
+			before: func(ctx context.Context, repo repository.ProductRepo, target, source models.Product) error {
+				return repo.UpdateProducts(ctx, []models.Product{mergedRevision(target, source, testMergedAt)})
+			},
+			wantVersion: 2,
+			wantDesc:    "A phone",
+			wantSpecs:   map[string]string{"storage": "128GB", "color": "black"},
+		},
+		{
+			name: "source deleted",
+			before: func(ctx context.Context, repo repository.ProductRepo, target, source models.Product) error {
+				if err := repo.UpdateProducts(ctx, []models.Product{mergedRevision(target, source, testMergedAt)}); err != nil {
+					return err
+				}
+				return repo.DeleteByID(ctx, source.ID, source.Version)
+			},
+			wantVersion: 2,
+			wantDesc:    "A phone",
+			wantSpecs:   map[string]string{"storage": "128GB", "color": "black"},
+		},
+		{
+			name: "target updated by its seller",
+			before: func(ctx context.Context, repo repository.ProductRepo, target, source models.Product) error {
+				target.Version = 2
+				target.Description = "Updated by the seller"
+				return repo.UpdateProducts(ctx, []models.Product{target})
+			},
+			wantPending: true,
+			wantVersion: 2,
+			wantDesc:    "Updated by the seller",
+			wantSpecs:   map[string]string{"storage": "128GB"},
+			wantSource:  true,
+		},
+		{
+			name: "source updated before the merged write",
+			before: func(ctx context.Context, repo repository.ProductRepo, target, source models.Product) error {
+				source.Version = 2
+				source.Specifications = map[string]string{"color": "black", "weight": "200g"}
+				return repo.UpdateProducts(ctx, []models.Product{source})
+			},
+			wantVersion: 2,
+			wantDesc:    "A phone",
+			wantSpecs:   map[string]string{"storage": "128GB", "color": "black", "weight": "200g"},
+		},
+		{
+			name: "source updated after the merged write",
+			before: func(ctx context.Context, repo repository.ProductRepo, target, source models.Product) error {
+				if err := repo.UpdateProducts(ctx, []models.Product{mergedRevision(target, source, testMergedAt)}); err != nil {
+					return err
+				}
+				source.Version = 2
+				source.Specifications = map[string]string{"color": "black", "weight": "200g"}
+				return repo.UpdateProducts(ctx, []models.Product{source})
+			},
+			wantVersion: 3,
+			wantDesc:    "A phone",
+			wantSpecs:   map[string]string{"storage": "128GB", "color": "black", "weight": "200g"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := repository.NewProductRepoMemory()
+			target, source := testProducts()
+			if err := repo.SaveProducts(ctx, []models.Product{target, source}); err != nil {
+				t.Fatal(err)
+			}
+
+			dir := t.TempDir()
+			if err := os.MkdirAll(filepath.Join(dir, "merges"), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := saveFile(filepath.Join(dir, "merges"), "m1", pendingMerge(target, source)); err != nil {
+				t.Fatal(err)
+			}
+			if tt.before != nil {
+				if err := tt.before(ctx, repo, target, source); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			offerManager, err := offers.NewManager(t.TempDir(), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			m, err := NewManager(dir, repo, offerManager, Options{Threshold: 0.8})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := m.merges["m1"].Pending; got != tt.wantPending {
+				t.Errorf("pending = %v, want %v", got, tt.wantPending)
+			}
+			// The source ID only redirects once the merge is done
+			wantRedirect := 1
+			if tt.wantPending {
+				wantRedirect = 2
+			}
+			if got := m.Resolve(2); got != wantRedirect {
+				t.Errorf("Resolve(2) = %d, want %d", got, wantRedirect)
+			}
+
+			stored, err := repo.GetProductByID(ctx, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stored.Version != tt.wantVersion {
+				t.Errorf("target version = %d, want %d", stored.Version, tt.wantVersion)
+			}
+			if stored.Description != tt.wantDesc {
+				t.Errorf("target description = %q, want %q", stored.Description, tt.wantDesc)
+			}
+			if len(stored.Specifications) != len(tt.wantSpecs) {
+				t.Errorf("target specifications = %v, want %v", stored.Specifications, tt.wantSpecs)
+			}
+			for key, value := range tt.wantSpecs {
+				if stored.Specifications[key] != value {
+					t.Errorf("target specifications = %v, want %v", stored.Specifications, tt.wantSpecs)
+					break
+				}
+			}
+
+			_, err = repo.GetProductByID(ctx, 2)
+			if exists := !errors.Is(err, repository.ErrNotFound); exists != tt.wantSource {
+				t.Errorf("source exists = %v, want %v (err %v)", exists, tt.wantSource, err)
+			}
+		})
+	}
+}
+
+// TestFinishRejectsChangedTarget checks that a merge whose target a seller
+// changed fails with a version conflict instead of deleting the source
+func TestFinishRejectsChangedTarget(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewProductRepoMemory()
+	target, source := testProducts()
+	if err := repo.SaveProducts(ctx, []models.Product{target, source}); err != nil {
+		t.Fatal(err)
+	}
+	changed := target
+	changed.Version = 2
+	changed.Description = "Updated by the seller"
+	if err := repo.UpdateProducts(ctx, []models.Product{changed}); err != nil {
+		t.Fatal(err)
+	}
+
+	offerManager, err := offers.NewManager(t.TempDir(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := NewManager(t.TempDir(), repo, offerManager, Options{Threshold: 0.8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, err := m.finish(ctx, pendingMerge(target, source)); !errors.Is(err, repository.ErrVersionConflict) {
+		t.Fatalf("finish error = %v, want %v", err, repository.ErrVersionConflict)
+	}
+}
@@ -0,0 +1,56 @@
+package duplicates
+
+import "item-comparison-api/internal/dto"
+
+// CandidateToResponse converts a Candidate to a DuplicateCandidate DTO
+func CandidateToResponse(c *Candidate) dto.DuplicateCandidate {
+	return dto.DuplicateCandidate{
+		ID:         c.ID,
+		ProductIDs: c.ProductIDs,
+		Score:      c.Score,
+		Breakdown: dto.DuplicateBreakdown{
+			Name:           c.Breakdown.Name,
+			Brand:          c.Breakdown.Brand,
+			Specifications: c.Breakdown.Specifications,
+			GTIN:           c.Breakdown.GTIN,
+		},
+		Status:    c.Status,
+		MergeID:   c.MergeID,
+		CreatedAt: c.CreatedAt,
+		UpdatedAt: c.UpdatedAt,
+	}
+}
+
+// CandidatesToResponse converts candidates to DuplicateCandidate DTOs
+func CandidatesToResponse(candidates []*Candidate) []dto.DuplicateCandidate {
+	responses := make([]dto.DuplicateCandidate, 0, len(candidates))
+	for _, c := range candidates {
+		responses = append(responses, CandidateToResponse(c))
+	}
+	return responses
+}
+
+// MergeToResponse converts a Merge to a MergeResponse DTO
+func MergeToResponse(m *Merge) dto.MergeResponse {
+	return dto.MergeResponse{
+		ID:          m.ID,
+		CandidateID: m.CandidateID,
+		TargetID:    m.TargetID,
+		SourceID:    m.SourceID,
+		Target:      m.Target,
+		Source:      m.Source,
+		Result:      m.Result,
+		Redirect:    m.Redirect,
+		MergedBy:    m.MergedBy,
+		MergedAt:    m.MergedAt,
+	}
+}
+
+// MergesToResponse converts merges to MergeResponse DTOs
+func MergesToResponse(merges []*Merge) []dto.MergeResponse {
+	responses := make([]dto.MergeResponse, 0, len(merges))
+	for _, m := range merges {
+		responses = append(responses, MergeToResponse(m))
+	}
+	return responses
+}
@@ -0,0 +1,37 @@
+package duplicates
+
+import (
+	"context"
+	"item-comparison-api/internal/dto"
+	"item-comparison-api/internal/services"
+	"slices"
+)
+
+// redirectingService decorates a ProductServiceInterface, reading merged
+// products' IDs as the products they were merged into. Other calls pass
+// through.
+type redirectingService struct {
+	services.ProductServiceInterface
+	manager *Manager
+}
+
+// RedirectService wraps the given service so reads of merged products'
+// IDs keep working, whichever API they come from
+func RedirectService(next services.ProductServiceInterface, manager *Manager) services.ProductServiceInterface {
+	return &redirectingService{ProductServiceInterface: next, manager: manager}
+}
+
+func (s *redirectingService) GetProductByID(ctx context.Context, id int) (*dto.ProductResponse, error) {
+	return s.ProductServiceInterface.GetProductByID(ctx, s.manager.Resolve(id))
+}
+
+// CompareProducts compares the products the IDs resolve to, each once
+func (s *redirectingService) CompareProducts(ctx context.Context, ids []int) ([]dto.ProductResponse, error) {
+	resolved := make([]int, 0, len(ids))
+	for _, id := range ids {
+		if id = s.manager.Resolve(id); !slices.Contains(resolved, id) {
+			resolved = append(resolved, id)
+		}
+	}
+	return s.ProductServiceInterface.CompareProducts(ctx, resolved)
+}
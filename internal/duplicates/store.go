@@ -0,0 +1,72 @@
+package duplicates
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// saveFile writes v as JSON to dir/<id>.json atomically, so a crash never
+// leaves a half-written file behind
+func saveFile(dir, id string, v any) error {
+	bytes, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", id, err)
+	}
+
+	tmp, err := os.CreateTemp(dir, ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", id, err)
+	}
+	if _, err := tmp.Write(bytes); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write %s: %w", id, err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write %s: %w", id, err)
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(dir, id+".json")); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write %s: %w", id, err)
+	}
+	return nil
+}
+
+// removeFile deletes dir/<id>.json, a missing file is not an error
+func removeFile(dir, id string) error {
+	if err := os.Remove(filepath.Join(dir, id+".json")); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove %s: %w", id, err)
+	}
+	return nil
+}
+
+// loadFiles decodes every JSON file in dir
+func loadFiles[T any](dir string) ([]*T, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory %s: %w", dir, err)
+	}
+
+	var items []*T
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") || filepath.Ext(name) != ".json" {
+			continue
+		}
+
+		bytes, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		item := new(T)
+		if err := json.Unmarshal(bytes, item); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal %s: %w", name, err)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
@@ -0,0 +1,163 @@
+// Package events fans product change events out to live subscribers and
+// keeps a bounded log of recent events so clients can resume after a
+// disconnect.
+package events
+
+import (
+	"fmt"
+	"item-comparison-api/internal/dto"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// subscriberBuffer is how many events a subscriber may fall behind before
+// it is dropped. Dropped clients reconnect and resume from the log.
+const subscriberBuffer = 64
+
+// Broker keeps the most recent events and delivers new ones to subscribers.
+// Events keep the ID they are published with, so an event delivered again
+// has the same ID and consumers can drop the copy. Events without an ID get
+// one of the form <epoch>-<sequence>; the epoch changes on every start, so
+// IDs from an earlier run are never mistaken for current ones.
+type Broker struct {
+	mu     sync.Mutex
+	epoch  string
+	seq    uint64
+	log    []dto.ProductEvent
+	size   int
+	subs   map[*Subscription]struct{}
+	closed bool
+
+	listeners []func(dto.ProductEvent)
+}
+
+// Subscription receives the events matching its filter on C. C is closed
+// when the subscriber falls too far behind or the broker shuts down.
+type Subscription struct {
+	C      <-chan dto.ProductEvent
+	ch     chan dto.ProductEvent
+	filter Filter
+}
+
+// NewBroker creates a broker keeping the last logSize events
+func NewBroker(logSize int) *Broker {
+	return &Broker{
+		epoch: strconv.FormatInt(time.Now().UnixNano(), 36),
+		size:  logSize,
+		log:   make([]dto.ProductEvent, 0, logSize),
+		subs:  make(map[*Subscription]struct{}),
+	}
+}
+
+// OnPublish registers a listener that is called with every published event,
+// after it has an ID. Unlike subscribers, listeners never miss events, so
+// they must return quickly.
+func (b *Broker) OnPublish(listener func(dto.ProductEvent)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.listeners = append(b.listeners, listener)
+}
+
+// Publish assigns the event an ID if it has none, appends it to the log, delivers it to
+// every matching subscriber without blocking and hands it to the listeners
+func (b *Broker) Publish(event dto.ProductEvent) {
+	event, listeners := b.append(event)
+	for _, listener := range listeners {
+		listener(event)
+	}
+}
+
+// append logs the event and delivers it to the subscribers
+func (b *Broker) append(event dto.ProductEvent) (dto.ProductEvent, []func(dto.ProductEvent)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if event.ID == "" {
+		b.seq++
+		event.ID = fmt.Sprintf("%s-%d", b.epoch, b.seq)
+	}
+	if event.OccurredAt == "" {
+		event.OccurredAt = time.Now().Format(time.RFC3339)
+	}
+	if len(b.log) == b.size {
+		copy(b.log, b.log[1:])
+		b.log = b.log[:b.size-1]
+	}
+	b.log = append(b.log, event)
+
+	for sub := range b.subs {
+		if !sub.filter.Match(event) {
+			continue
+		}
+		select {
+		case sub.ch <- event:
+		default:
+			fmt.Printf("[Broker][Publish][ERROR] Dropping subscriber %d events behind\n", len(sub.ch))
+			b.remove(sub)
+		}
+	}
+	return event, b.listeners
+}
+
+// Subscribe registers a subscriber for events after lastEventID. It returns
+// the logged events the subscriber missed, or reset=true when lastEventID
+// is unknown or already evicted from the log, in which case the client must
+// reload its state. An empty lastEventID only subscribes to new events.
+func (b *Broker) Subscribe(lastEventID string, filter Filter) (sub *Subscription, missed []dto.ProductEvent, reset bool) {
+	ch := make(chan dto.ProductEvent, subscriberBuffer)
+	sub = &Subscription{C: ch, ch: ch, filter: filter}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		close(ch)
+		return sub, nil, false
+	}
+	b.subs[sub] = struct{}{}
+
+	if lastEventID == "" {
+		return sub, nil, false
+	}
+
+	// Resume after the first logged copy of the event, so events logged
+	// between it and a redelivered copy are not skipped
+	i := slices.IndexFunc(b.log, func(event dto.ProductEvent) bool { return event.ID == lastEventID })
+	if i < 0 {
+		return sub, nil, true
+	}
+	for _, event := range b.log[i+1:] {
+		if filter.Match(event) {
+			missed = append(missed, event)
+		}
+	}
+	return sub, missed, false
+}
+
+// Unsubscribe stops delivery to the subscriber
+func (b *Broker) Unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subs[sub]; ok {
+		b.remove(sub)
+	}
+}
+
+// Close ends every subscription, so open streams finish and the server can
+// shut down. Later subscriptions end right away; events are still logged and
+// passed to the listeners.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for sub := range b.subs {
+		b.remove(sub)
+	}
+}
+
+// remove closes and forgets a subscription. Callers hold mu.
+func (b *Broker) remove(sub *Subscription) {
+	delete(b.subs, sub)
+	close(sub.ch)
+}
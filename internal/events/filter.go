@@ -0,0 +1,31 @@
+package events
+
+import (
+	"item-comparison-api/internal/dto"
+	"strings"
+)
+
+// Filter selects the events a subscriber receives. Empty fields match
+// every event, all set fields must match.
+type Filter struct {
+	ProductIDs map[int]bool
+	SellerID   string
+	Category   string
+	Types      map[string]bool
+}
+
+// Match reports whether the event passes the filter. Categories compare
+// case-insensitively, like the GraphQL category filter.
+func (f Filter) Match(e dto.ProductEvent) bool {
+	switch {
+	case len(f.ProductIDs) > 0 && !f.ProductIDs[e.ProductID]:
+		return false
+	case f.SellerID != "" && e.SellerID != f.SellerID:
+		return false
+	case f.Category != "" && !strings.EqualFold(e.Category, f.Category):
+		return false
+	case len(f.Types) > 0 && !f.Types[e.Type]:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,40 @@
+package gql
+
+import (
+	"errors"
+	"item-comparison-api/internal/services"
+)
+
+// Error codes for failures that do not come from the service layer
+const (
+	codeMissingSeller = "missing_credentials"
+	codeLimitExceeded = "query_too_complex"
+	codeInvalidInput  = "invalid_input"
+)
+
+// errMissingSeller is returned by mutations without seller credentials
+var errMissingSeller = &resolverError{err: errors.New("missing seller credentials"), code: codeMissingSeller}
+
+// resolverError carries a machine-readable code into the "extensions" of
+// a GraphQL error, using the same codes as bulk write results
+type resolverError struct {
+	err  error
+	code string
+}
+
+// newResolverError wraps a service error with its error code
+func newResolverError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &resolverError{err: err, code: services.ErrorCode(err)}
+}
+
+func (e *resolverError) Error() string { return e.err.Error() }
+
+func (e *resolverError) Unwrap() error { return e.err }
+
+// Extensions is read by graphql-go when formatting the error
+func (e *resolverError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": e.code}
+}
@@ -0,0 +1,200 @@
+// Package gql serves the catalog as a GraphQL API on top of the product
+// service. Product reads within one request are batched and cached, and
+// every operation is checked against depth and complexity limits before
+// it runs.
+package gql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"item-comparison-api/internal/services"
+	"mime"
+	"net/http"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+)
+
+// request is a GraphQL request as sent over HTTP
+type request struct {
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables"`
+	OperationName string                 `json:"operationName"`
+}
+
+// Handler serves GraphQL queries and mutations over HTTP
+type Handler struct {
+	schema        graphql.Schema
+	service       services.ProductServiceInterface
+	maxDepth      int
+	maxComplexity int
+}
+
+// NewHandler creates a new instance of Handler
+func NewHandler(s services.ProductServiceInterface, maxCompareIDs, maxDepth, maxComplexity int) (*Handler, error) {
+	schema, err := newSchema(&resolvers{service: s, maxCompareIDs: maxCompareIDs})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build GraphQL schema: %w", err)
+	}
+	return &Handler{schema: schema, service: s, maxDepth: maxDepth, maxComplexity: maxComplexity}, nil
+}
+
+// ServeHTTP executes a GraphQL request. GET takes the request from the
+// query string and only runs queries; POST takes a JSON body or a bare
+// application/graphql document.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	req, status, err := readRequest(r)
+	if err != nil {
+		fmt.Printf("[GraphQLHandler][ServeHTTP][ERROR] %v\n", err)
+		http.Error(w, err.Error(), status)
+		return
+	}
+	if req.Query == "" {
+		http.Error(w, "Missing GraphQL query", http.StatusBadRequest)
+		return
+	}
+
+	// Parse and validate the document, then check it against the limits
+	doc, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{Body: []byte(req.Query), Name: "GraphQL request"})})
+	if err != nil {
+		writeResult(w, http.StatusBadRequest, &graphql.Result{Errors: gqlerrors.FormatErrors(err)})
+		return
+	}
+	if validation := graphql.ValidateDocument(&h.schema, doc, nil); !validation.IsValid {
+		writeResult(w, http.StatusBadRequest, &graphql.Result{Errors: validation.Errors})
+		return
+	}
+	operation, err := findOperation(doc, req.OperationName)
+	if err != nil {
+		writeResult(w, http.StatusBadRequest, &graphql.Result{Errors: requestErrors(err)})
+		return
+	}
+	if r.Method == http.MethodGet && operation.Operation == ast.OperationTypeMutation {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Mutations must be sent with POST", http.StatusMethodNotAllowed)
+		return
+	}
+	c := analyze(h.schema, doc, operation, req.Variables)
+	if err := c.check(h.maxDepth, h.maxComplexity); err != nil {
+		fmt.Printf("[GraphQLHandler][ServeHTTP][ERROR] %v\n", err)
+		writeResult(w, http.StatusBadRequest, &graphql.Result{Errors: requestErrors(err)})
+		return
+	}
+
+	// Product reads of this request share one loader
+	ctx := context.WithValue(r.Context(), loaderContextKey{}, newProductLoader(r.Context(), h.service))
+	result := graphql.Execute(graphql.ExecuteParams{
+		Schema:        h.schema,
+		AST:           doc,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       ctx,
+	})
+
+	fmt.Printf("[GraphQLHandler][ServeHTTP] Executed %s with depth %d, complexity %d, %d errors\n", operation.Operation, c.depth, c.complexity, len(result.Errors))
+	writeResult(w, http.StatusOK, result)
+}
+
+// readRequest extracts the GraphQL request from the query string or body
+func readRequest(r *http.Request) (request, int, error) {
+	var req request
+	switch r.Method {
+	case http.MethodGet:
+		query := r.URL.Query()
+		req.Query = query.Get("query")
+		req.OperationName = query.Get("operationName")
+		if variables := query.Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				return req, http.StatusBadRequest, fmt.Errorf("invalid variables: %w", err)
+			}
+		}
+		return req, 0, nil
+
+	case http.MethodPost:
+		mediaType := "application/json"
+		if contentType := r.Header.Get("Content-Type"); contentType != "" {
+			parsed, _, err := mime.ParseMediaType(contentType)
+			if err != nil {
+				return req, http.StatusUnsupportedMediaType, fmt.Errorf("invalid Content-Type %q", contentType)
+			}
+			mediaType = parsed
+		}
+
+		switch mediaType {
+		case "application/json":
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				var maxBytesErr *http.MaxBytesError
+				if errors.As(err, &maxBytesErr) {
+					return req, http.StatusRequestEntityTooLarge, errors.New("request body too large")
+				}
+				return req, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err)
+			}
+		case "application/graphql":
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				var maxBytesErr *http.MaxBytesError
+				if errors.As(err, &maxBytesErr) {
+					return req, http.StatusRequestEntityTooLarge, errors.New("request body too large")
+				}
+				return req, http.StatusBadRequest, fmt.Errorf("failed to read request body: %w", err)
+			}
+			req.Query = string(body)
+		default:
+			return req, http.StatusUnsupportedMediaType, fmt.Errorf("unsupported Content-Type %q, use application/json or application/graphql", mediaType)
+		}
+		return req, 0, nil
+
+	default:
+		return req, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method)
+	}
+}
+
+// findOperation returns the operation to run. Documents with several
+// operations must name one.
+func findOperation(doc *ast.Document, operationName string) (*ast.OperationDefinition, error) {
+	var found *ast.OperationDefinition
+	for _, def := range doc.Definitions {
+		op, ok := def.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		if operationName == "" {
+			if found != nil {
+				return nil, &resolverError{err: errors.New("operationName is required when the document has several operations"), code: codeInvalidInput}
+			}
+			found = op
+		} else if op.Name != nil && op.Name.Value == operationName {
+			return op, nil
+		}
+	}
+	if found == nil {
+		return nil, &resolverError{err: fmt.Errorf("unknown operation %q", operationName), code: codeInvalidInput}
+	}
+	return found, nil
+}
+
+// requestErrors formats an error that stopped the operation from running,
+// keeping the code of resolver errors
+func requestErrors(err error) []gqlerrors.FormattedError {
+	formatted := gqlerrors.FormatError(err)
+	var resErr *resolverError
+	if errors.As(err, &resErr) {
+		formatted.Extensions = resErr.Extensions()
+	}
+	return []gqlerrors.FormattedError{formatted}
+}
+
+// writeResult writes a GraphQL result as JSON
+func writeResult(w http.ResponseWriter, status int, result *graphql.Result) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		fmt.Printf("[GraphQLHandler][writeResult][ERROR] %v\n", err)
+	}
+}
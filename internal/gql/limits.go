@@ -0,0 +1,151 @@
+package gql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// defaultPageSize is the number of products a list returns without a first
+// argument, and the number of items any list field without one is assumed
+// to return when estimating complexity
+const defaultPageSize = 10
+
+// Bounds that keep the estimate from overflowing, far above any sane limit
+const (
+	maxListSize  = 1 << 20
+	maxFieldCost = 1 << 40
+)
+
+// cost is the estimated size of a selection
+type cost struct {
+	depth      int
+	complexity int
+}
+
+// analyzer estimates the depth and complexity of an operation before it
+// runs. Every field costs one; the fields selected below a list count once
+// per expected item, taken from the first argument when given. Introspection
+// fields are free so tooling keeps working.
+type analyzer struct {
+	schema    graphql.Schema
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+}
+
+// analyze estimates the cost of the operation. The document must already be
+// validated, so fragments exist and do not form cycles.
+func analyze(schema graphql.Schema, doc *ast.Document, operation *ast.OperationDefinition, variables map[string]interface{}) cost {
+	a := analyzer{schema: schema, fragments: make(map[string]*ast.FragmentDefinition), variables: variables}
+	for _, def := range doc.Definitions {
+		if fragment, ok := def.(*ast.FragmentDefinition); ok {
+			a.fragments[fragment.Name.Value] = fragment
+		}
+	}
+
+	root := schema.QueryType()
+	if operation.Operation == ast.OperationTypeMutation {
+		root = schema.MutationType()
+	}
+	return a.selectionSet(operation.SelectionSet, root)
+}
+
+// selectionSet estimates the cost of the selections made on parent
+func (a analyzer) selectionSet(set *ast.SelectionSet, parent graphql.Type) cost {
+	var total cost
+	if set == nil {
+		return total
+	}
+
+	for _, selection := range set.Selections {
+		var c cost
+		switch sel := selection.(type) {
+		case *ast.Field:
+			c = a.field(sel, parent)
+		case *ast.InlineFragment:
+			target := parent
+			if sel.TypeCondition != nil {
+				target = a.schema.Type(sel.TypeCondition.Name.Value)
+			}
+			c = a.selectionSet(sel.SelectionSet, target)
+		case *ast.FragmentSpread:
+			if fragment, ok := a.fragments[sel.Name.Value]; ok {
+				c = a.selectionSet(fragment.SelectionSet, a.schema.Type(fragment.TypeCondition.Name.Value))
+			}
+		}
+		total.depth = max(total.depth, c.depth)
+		total.complexity = min(total.complexity+c.complexity, maxFieldCost)
+	}
+	return total
+}
+
+// field estimates the cost of one field and its selections
+func (a analyzer) field(f *ast.Field, parent graphql.Type) cost {
+	if strings.HasPrefix(f.Name.Value, "__") {
+		return cost{}
+	}
+	object, ok := parent.(*graphql.Object)
+	if !ok {
+		return cost{depth: 1, complexity: 1}
+	}
+	def, ok := object.Fields()[f.Name.Value]
+	if !ok {
+		return cost{depth: 1, complexity: 1}
+	}
+
+	// Unwrap the field type, counting list items on the way
+	items := 1
+	fieldType := def.Type
+unwrap:
+	for {
+		switch t := fieldType.(type) {
+		case *graphql.NonNull:
+			fieldType = t.OfType
+		case *graphql.List:
+			items = min(items*a.listSize(f), maxListSize)
+			fieldType = t.OfType
+		default:
+			break unwrap
+		}
+	}
+
+	children := a.selectionSet(f.SelectionSet, fieldType)
+	return cost{depth: children.depth + 1, complexity: min(1+items*children.complexity, maxFieldCost)}
+}
+
+// listSize returns the first argument of a list field, or the default page size
+func (a analyzer) listSize(f *ast.Field) int {
+	for _, arg := range f.Arguments {
+		if arg.Name.Value != "first" {
+			continue
+		}
+		switch v := arg.Value.(type) {
+		case *ast.IntValue:
+			if n, err := strconv.Atoi(v.Value); err == nil {
+				return min(max(n, 0), maxListSize)
+			}
+		case *ast.Variable:
+			switch n := a.variables[v.Name.Value].(type) {
+			case int:
+				return min(max(n, 0), maxListSize)
+			case float64:
+				return int(min(max(n, 0), maxListSize))
+			}
+		}
+	}
+	return defaultPageSize
+}
+
+// check reports the limit the estimated cost exceeds, if any
+func (c cost) check(maxDepth, maxComplexity int) error {
+	if c.depth > maxDepth {
+		return &resolverError{err: fmt.Errorf("query depth %d exceeds the limit of %d", c.depth, maxDepth), code: codeLimitExceeded}
+	}
+	if c.complexity > maxComplexity {
+		return &resolverError{err: fmt.Errorf("query complexity %d exceeds the limit of %d", c.complexity, maxComplexity), code: codeLimitExceeded}
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package gql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"item-comparison-api/internal/dto"
+	"item-comparison-api/internal/repository"
+	"item-comparison-api/internal/services"
+	"sync"
+)
+
+// loaded is the cached outcome of loading one product. A nil product with
+// a nil error means the product does not exist.
+type loaded struct {
+	product *dto.ProductResponse
+	err     error
+}
+
+// productLoader batches and caches product reads for one request. Load
+// queues an ID and returns a thunk; the executor resolves thunks level by
+// level, so every ID requested on a level is read with a single
+// repository call instead of one call per field.
+type productLoader struct {
+	ctx     context.Context
+	service services.ProductServiceInterface
+
+	mu      sync.Mutex
+	pending []int
+	cache   map[int]loaded
+
+	all    []dto.ProductResponse
+	allErr error
+	listed bool
+}
+
+// newProductLoader creates a loader bound to the request context
+func newProductLoader(ctx context.Context, s services.ProductServiceInterface) *productLoader {
+	return &productLoader{ctx: ctx, service: s, cache: make(map[int]loaded)}
+}
+
+// Load queues the product for the next batch and returns a thunk yielding
+// it. Missing products resolve to nil.
+func (l *productLoader) Load(id int) func() (interface{}, error) {
+	l.mu.Lock()
+	if _, ok := l.cache[id]; !ok && !l.isPending(id) {
+		l.pending = append(l.pending, id)
+	}
+	l.mu.Unlock()
+
+	return func() (interface{}, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if _, ok := l.cache[id]; !ok {
+			l.dispatch()
+		}
+		result := l.cache[id]
+		if result.err != nil || result.product == nil {
+			return nil, result.err
+		}
+		return *result.product, nil
+	}
+}
+
+// List returns every product, scanning the repository at most once per
+// request. The scanned products also answer later Load calls.
+func (l *productLoader) List() ([]dto.ProductResponse, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.listed {
+		l.listed = true
+		l.all, l.allErr = l.service.LoadProducts(l.ctx)
+		if l.allErr == nil {
+			for i := range l.all {
+				l.cache[l.all[i].ID] = loaded{product: &l.all[i]}
+			}
+		}
+	}
+	return l.all, l.allErr
+}
+
+// Prime stores products read elsewhere, e.g. by a comparison
+func (l *productLoader) Prime(products []dto.ProductResponse) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i := range products {
+		l.cache[products[i].ID] = loaded{product: &products[i]}
+	}
+}
+
+// isPending reports whether the ID is already queued. Callers hold mu.
+func (l *productLoader) isPending(id int) bool {
+	for _, p := range l.pending {
+		if p == id {
+			return true
+		}
+	}
+	return false
+}
+
+// dispatch reads every queued product. Callers hold mu.
+func (l *productLoader) dispatch() {
+	ids := l.pending
+	l.pending = nil
+	if len(ids) == 0 {
+		return
+	}
+	fmt.Printf("[ProductLoader][dispatch] Loading %d products in one batch\n", len(ids))
+
+	// A full scan already answers every ID, unknown ones do not exist
+	if l.listed && l.allErr == nil {
+		for _, id := range ids {
+			if _, ok := l.cache[id]; !ok {
+				l.cache[id] = loaded{}
+			}
+		}
+		return
+	}
+
+	// Compare reads the whole batch in one call but fails on the first
+	// missing product; fall back to single reads to tell which are missing
+	products, err := l.service.CompareProducts(l.ctx, ids)
+	if err == nil {
+		for i := range products {
+			l.cache[products[i].ID] = loaded{product: &products[i]}
+		}
+		return
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		fmt.Printf("[ProductLoader][dispatch][ERROR] %v\n", err)
+		for _, id := range ids {
+			l.cache[id] = loaded{err: err}
+		}
+		return
+	}
+	for _, id := range ids {
+		product, err := l.service.GetProductByID(l.ctx, id)
+		if errors.Is(err, repository.ErrNotFound) {
+			l.cache[id] = loaded{}
+			continue
+		}
+		l.cache[id] = loaded{product: product, err: err}
+	}
+}
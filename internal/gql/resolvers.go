@@ -0,0 +1,434 @@
+package gql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"item-comparison-api/internal/api"
+	"item-comparison-api/internal/dto"
+	"item-comparison-api/internal/services"
+	"sort"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+)
+
+type loaderContextKey struct{}
+
+// resolvers answers GraphQL fields through the product service
+type resolvers struct {
+	service       services.ProductServiceInterface
+	maxCompareIDs int
+}
+
+// loaderFrom returns the product loader of the request
+func loaderFrom(ctx context.Context) *productLoader {
+	return ctx.Value(loaderContextKey{}).(*productLoader)
+}
+
+// productFilter holds the arguments of a product list
+type productFilter struct {
+	ids       []int
+	sellerID  string
+	brand     string
+	category  string
+	search    string
+	minPrice  *float64
+	maxPrice  *float64
+	minRating *float64
+	first     int
+	offset    int
+}
+
+// parseFilter reads the filter, first and offset arguments. Without first a
+// page holds defaultPageSize products, the size the complexity estimate
+// assumed.
+func parseFilter(args map[string]interface{}) (productFilter, error) {
+	f := productFilter{first: defaultPageSize}
+	if first, ok := args["first"].(int); ok {
+		if first < 0 {
+			return f, &resolverError{err: errors.New("first must not be negative"), code: codeInvalidInput}
+		}
+		f.first = first
+	}
+	if offset, ok := args["offset"].(int); ok {
+		if offset < 0 {
+			return f, &resolverError{err: errors.New("offset must not be negative"), code: codeInvalidInput}
+		}
+		f.offset = offset
+	}
+
+	filter, _ := args["filter"].(map[string]interface{})
+	if ids, ok := filter["ids"].([]interface{}); ok {
+		f.ids = make([]int, 0, len(ids))
+		for _, id := range ids {
+			f.ids = append(f.ids, id.(int))
+		}
+	}
+	f.sellerID, _ = filter["sellerId"].(string)
+	f.brand, _ = filter["brand"].(string)
+	f.category, _ = filter["category"].(string)
+	f.search, _ = filter["search"].(string)
+	for name, target := range map[string]**float64{"minPrice": &f.minPrice, "maxPrice": &f.maxPrice, "minRating": &f.minRating} {
+		if v, ok := filter[name].(float64); ok {
+			*target = &v
+		}
+	}
+	return f, nil
+}
+
+// matches reports whether the product passes every condition but ids
+func (f productFilter) matches(p dto.ProductResponse) bool {
+	switch {
+	case f.sellerID != "" && p.SellerID != f.sellerID:
+		return false
+	case f.brand != "" && !strings.EqualFold(p.Brand, f.brand):
+		return false
+	case f.category != "" && !strings.EqualFold(p.Specifications.Category(), f.category):
+		return false
+	case f.search != "" && !strings.Contains(strings.ToLower(p.Name), strings.ToLower(f.search)):
+		return false
+	case f.minPrice != nil && float64(p.Price) < *f.minPrice:
+		return false
+	case f.maxPrice != nil && float64(p.Price) > *f.maxPrice:
+		return false
+	case f.minRating != nil && float64(p.Rating) < *f.minRating:
+		return false
+	}
+	return true
+}
+
+// apply filters the products and cuts out the requested page
+func (f productFilter) apply(products []dto.ProductResponse) []dto.ProductResponse {
+	filtered := make([]dto.ProductResponse, 0, len(products))
+	for _, p := range products {
+		if f.matches(p) {
+			filtered = append(filtered, p)
+		}
+	}
+
+	if f.offset >= len(filtered) {
+		return []dto.ProductResponse{}
+	}
+	filtered = filtered[f.offset:]
+	if f.first < len(filtered) {
+		filtered = filtered[:f.first]
+	}
+	return filtered
+}
+
+// listProducts resolves a product list restricted to base conditions. Lists
+// by ID go through the loader, all others filter one catalog scan.
+func (res *resolvers) listProducts(p graphql.ResolveParams, base func(*productFilter)) (interface{}, error) {
+	f, err := parseFilter(p.Args)
+	if err != nil {
+		return nil, err
+	}
+	if base != nil {
+		base(&f)
+	}
+	loader := loaderFrom(p.Context)
+
+	if f.ids == nil {
+		products, err := loader.List()
+		if err != nil {
+			return nil, newResolverError(err)
+		}
+		return f.apply(products), nil
+	}
+
+	// Queue every ID now so they share a batch, missing IDs are skipped
+	thunks := make([]func() (interface{}, error), 0, len(f.ids))
+	for _, id := range f.ids {
+		thunks = append(thunks, loader.Load(id))
+	}
+	return func() (interface{}, error) {
+		products := make([]dto.ProductResponse, 0, len(thunks))
+		for _, thunk := range thunks {
+			v, err := thunk()
+			if err != nil {
+				return nil, newResolverError(err)
+			}
+			if v != nil {
+				products = append(products, v.(dto.ProductResponse))
+			}
+		}
+		return f.apply(products), nil
+	}, nil
+}
+
+// products resolves the root product list
+func (res *resolvers) products(p graphql.ResolveParams) (interface{}, error) {
+	return res.listProducts(p, nil)
+}
+
+// product resolves one product by ID, null when it does not exist
+func (res *resolvers) product(p graphql.ResolveParams) (interface{}, error) {
+	thunk := loaderFrom(p.Context).Load(p.Args["id"].(int))
+	return func() (interface{}, error) {
+		v, err := thunk()
+		return v, newResolverError(err)
+	}, nil
+}
+
+// sellers resolves every seller with at least one product
+func (res *resolvers) sellers(p graphql.ResolveParams) (interface{}, error) {
+	products, err := loaderFrom(p.Context).List()
+	if err != nil {
+		return nil, newResolverError(err)
+	}
+
+	seen := make(map[string]bool)
+	sellers := []seller{}
+	for _, product := range products {
+		if !seen[product.SellerID] {
+			seen[product.SellerID] = true
+			sellers = append(sellers, seller{ID: product.SellerID})
+		}
+	}
+	sort.Slice(sellers, func(i, j int) bool { return sellers[i].ID < sellers[j].ID })
+	return sellers, nil
+}
+
+// seller resolves one seller, null when it has no products
+func (res *resolvers) seller(p graphql.ResolveParams) (interface{}, error) {
+	products, err := loaderFrom(p.Context).List()
+	if err != nil {
+		return nil, newResolverError(err)
+	}
+
+	id := p.Args["id"].(string)
+	for _, product := range products {
+		if product.SellerID == id {
+			return seller{ID: id}, nil
+		}
+	}
+	return nil, nil
+}
+
+// sellerProducts resolves the products of a seller
+func (res *resolvers) sellerProducts(p graphql.ResolveParams) (interface{}, error) {
+	id := p.Source.(seller).ID
+	return res.listProducts(p, func(f *productFilter) { f.sellerID = id })
+}
+
+// sellerProductCount counts the products of a seller
+func (res *resolvers) sellerProductCount(p graphql.ResolveParams) (interface{}, error) {
+	id := p.Source.(seller).ID
+	return res.count(p, func(product dto.ProductResponse) bool { return product.SellerID == id })
+}
+
+// categories resolves every category used by at least one product
+func (res *resolvers) categories(p graphql.ResolveParams) (interface{}, error) {
+	products, err := loaderFrom(p.Context).List()
+	if err != nil {
+		return nil, newResolverError(err)
+	}
+
+	seen := make(map[string]bool)
+	categories := []category{}
+	for _, product := range products {
+		name := product.Specifications.Category()
+		if name != "" && !seen[name] {
+			seen[name] = true
+			categories = append(categories, category{Name: name})
+		}
+	}
+	sort.Slice(categories, func(i, j int) bool { return categories[i].Name < categories[j].Name })
+	return categories, nil
+}
+
+// categoryProducts resolves the products of a category
+func (res *resolvers) categoryProducts(p graphql.ResolveParams) (interface{}, error) {
+	name := p.Source.(category).Name
+	return res.listProducts(p, func(f *productFilter) { f.category = name })
+}
+
+// categoryProductCount counts the products of a category
+func (res *resolvers) categoryProductCount(p graphql.ResolveParams) (interface{}, error) {
+	name := p.Source.(category).Name
+	return res.count(p, func(product dto.ProductResponse) bool { return product.Specifications.Category() == name })
+}
+
+// count counts the products matching the predicate in the catalog scan
+func (res *resolvers) count(p graphql.ResolveParams, match func(dto.ProductResponse) bool) (interface{}, error) {
+	products, err := loaderFrom(p.Context).List()
+	if err != nil {
+		return nil, newResolverError(err)
+	}
+
+	n := 0
+	for _, product := range products {
+		if match(product) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// specifications resolves the specifications of a product, sorted by key
+func (res *resolvers) specifications(p graphql.ResolveParams) (interface{}, error) {
+	specs := p.Source.(dto.ProductResponse).Specifications
+
+	keys := specs.Keys()
+	if wanted, ok := p.Args["keys"].([]interface{}); ok {
+		keys = keys[:0]
+		for _, key := range wanted {
+			if _, ok := specs[key.(string)]; ok {
+				keys = append(keys, key.(string))
+			}
+		}
+	}
+
+	result := make([]specification, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, specification{Key: key, Value: specs[key]})
+	}
+	return result, nil
+}
+
+// compare resolves a comparison. Unlike product lists it fails when a
+// product does not exist, as the REST endpoint does.
+func (res *resolvers) compare(p graphql.ResolveParams) (interface{}, error) {
+	raw := p.Args["ids"].([]interface{})
+	if len(raw) == 0 {
+		return nil, &resolverError{err: errors.New("no product IDs provided"), code: codeInvalidInput}
+	}
+	if len(raw) > res.maxCompareIDs {
+		return nil, &resolverError{err: fmt.Errorf("too many product IDs, at most %d allowed", res.maxCompareIDs), code: codeInvalidInput}
+	}
+	ids := make([]int, 0, len(raw))
+	for _, id := range raw {
+		ids = append(ids, id.(int))
+	}
+
+	products, err := res.service.CompareProducts(p.Context, ids)
+	if err != nil {
+		fmt.Printf("[GraphQL][compare][ERROR] %v\n", err)
+		return nil, newResolverError(err)
+	}
+	loaderFrom(p.Context).Prime(products)
+	return comparison{Products: products}, nil
+}
+
+// comparisonProducts resolves the compared products
+func (res *resolvers) comparisonProducts(p graphql.ResolveParams) (interface{}, error) {
+	return p.Source.(comparison).Products, nil
+}
+
+// comparisonKeys resolves the union of the compared products' specification keys
+func (res *resolvers) comparisonKeys(p graphql.ResolveParams) (interface{}, error) {
+	union := make(dto.Specifications)
+	for _, product := range p.Source.(comparison).Products {
+		for key := range product.Specifications {
+			union[key] = ""
+		}
+	}
+	return union.Keys(), nil
+}
+
+// comparisonCheapest resolves the compared product with the lowest price
+func (res *resolvers) comparisonCheapest(p graphql.ResolveParams) (interface{}, error) {
+	return best(p.Source.(comparison).Products, func(a, b dto.ProductResponse) bool { return a.Price < b.Price }), nil
+}
+
+// comparisonBestRated resolves the compared product with the highest rating
+func (res *resolvers) comparisonBestRated(p graphql.ResolveParams) (interface{}, error) {
+	return best(p.Source.(comparison).Products, func(a, b dto.ProductResponse) bool { return a.Rating > b.Rating }), nil
+}
+
+// best returns the first product no other product beats, nil for none
+func best(products []dto.ProductResponse, better func(a, b dto.ProductResponse) bool) interface{} {
+	if len(products) == 0 {
+		return nil
+	}
+	winner := products[0]
+	for _, p := range products[1:] {
+		if better(p, winner) {
+			winner = p
+		}
+	}
+	return winner
+}
+
+// createProduct creates one product for the calling seller
+func (res *resolvers) createProduct(p graphql.ResolveParams) (interface{}, error) {
+	sellerID := api.SellerFromContext(p.Context)
+	if sellerID == "" {
+		return nil, errMissingSeller
+	}
+
+	req := productRequestFromInput(p.Args["input"].(map[string]interface{}))
+	result := res.service.SaveProductsBulk(p.Context, []dto.ProductRequest{req}, sellerID)[0]
+	if result.Err != nil {
+		fmt.Printf("[GraphQL][createProduct][ERROR] %v\n", result.Err)
+		return nil, newResolverError(result.Err)
+	}
+
+	fmt.Printf("[GraphQL][createProduct] Created product %d for seller %s\n", req.ID, sellerID)
+	return *result.Product, nil
+}
+
+// updateProduct updates one product owned by the calling seller
+func (res *resolvers) updateProduct(p graphql.ResolveParams) (interface{}, error) {
+	sellerID := api.SellerFromContext(p.Context)
+	if sellerID == "" {
+		return nil, errMissingSeller
+	}
+
+	req := productRequestFromInput(p.Args["input"].(map[string]interface{}))
+	result := res.service.UpdateProductsBulk(p.Context, []dto.ProductRequest{req}, sellerID)[0]
+	if result.Err != nil {
+		fmt.Printf("[GraphQL][updateProduct][ERROR] %v\n", result.Err)
+		return nil, newResolverError(result.Err)
+	}
+
+	fmt.Printf("[GraphQL][updateProduct] Updated product %d for seller %s\n", req.ID, sellerID)
+	return *result.Product, nil
+}
+
+// deleteProduct deletes one product owned by the calling seller
+func (res *resolvers) deleteProduct(p graphql.ResolveParams) (interface{}, error) {
+	sellerID := api.SellerFromContext(p.Context)
+	if sellerID == "" {
+		return nil, errMissingSeller
+	}
+
+	id := p.Args["id"].(int)
+	version, _ := p.Args["version"].(int)
+	if err := res.service.DeleteProductByID(p.Context, id, sellerID, version); err != nil {
+		fmt.Printf("[GraphQL][deleteProduct][ERROR] %v\n", err)
+		return nil, newResolverError(err)
+	}
+
+	fmt.Printf("[GraphQL][deleteProduct] Deleted product %d for seller %s\n", id, sellerID)
+	return true, nil
+}
+
+// productRequestFromInput maps a ProductInput argument to a request dto
+func productRequestFromInput(in map[string]interface{}) dto.ProductRequest {
+	req := dto.ProductRequest{
+		ID:   in["id"].(int),
+		Name: in["name"].(string),
+	}
+	if v, ok := in["price"].(float64); ok {
+		req.Price = float32(v)
+	}
+	req.Description, _ = in["description"].(string)
+	req.Brand, _ = in["brand"].(string)
+	req.GTIN, _ = in["gtin"].(string)
+	req.MPN, _ = in["mpn"].(string)
+	req.ImageUrl, _ = in["imageUrl"].(string)
+	if v, ok := in["rating"].(float64); ok {
+		req.Rating = float32(v)
+	}
+	req.Version, _ = in["version"].(int)
+	if specs, ok := in["specifications"].([]interface{}); ok {
+		req.Specifications = make(dto.Specifications, len(specs))
+		for _, spec := range specs {
+			entry := spec.(map[string]interface{})
+			req.Specifications[entry["key"].(string)] = entry["value"].(string)
+		}
+	}
+	return req
+}
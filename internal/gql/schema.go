@@ -0,0 +1,233 @@
+package gql
+
+import (
+	"item-comparison-api/internal/dto"
+
+	"github.com/graphql-go/graphql"
+)
+
+// seller and category are derived from the products that reference them
+type seller struct{ ID string }
+
+type category struct{ Name string }
+
+// comparison is the result of comparing a set of products
+type comparison struct{ Products []dto.ProductResponse }
+
+// specification is one key/value pair of a product's specifications
+type specification struct{ Key, Value string }
+
+// newSchema builds the GraphQL schema on top of the resolvers
+func newSchema(res *resolvers) (graphql.Schema, error) {
+	specificationType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Specification",
+		Description: "A free-form product attribute",
+		Fields: graphql.Fields{
+			"key":   &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: specField(func(s specification) interface{} { return s.Key })},
+			"value": &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: specField(func(s specification) interface{} { return s.Value })},
+		},
+	})
+
+	filterType := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name:        "ProductFilter",
+		Description: "Restricts a product list, all given conditions must hold",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"ids":       &graphql.InputObjectFieldConfig{Type: graphql.NewList(graphql.NewNonNull(graphql.Int))},
+			"sellerId":  &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"brand":     &graphql.InputObjectFieldConfig{Type: graphql.String, Description: "Case-insensitive exact match"},
+			"category":  &graphql.InputObjectFieldConfig{Type: graphql.String, Description: "Case-insensitive exact match"},
+			"search":    &graphql.InputObjectFieldConfig{Type: graphql.String, Description: "Case-insensitive substring of the name"},
+			"minPrice":  &graphql.InputObjectFieldConfig{Type: graphql.Float},
+			"maxPrice":  &graphql.InputObjectFieldConfig{Type: graphql.Float},
+			"minRating": &graphql.InputObjectFieldConfig{Type: graphql.Float},
+		},
+	})
+
+	// Arguments of every product list
+	listArgs := graphql.FieldConfigArgument{
+		"filter": &graphql.ArgumentConfig{Type: filterType},
+		"first":  &graphql.ArgumentConfig{Type: graphql.Int, Description: "Maximum number of products returned, 10 when omitted"},
+		"offset": &graphql.ArgumentConfig{Type: graphql.Int, Description: "Number of products skipped", DefaultValue: 0},
+	}
+
+	// Products, sellers and categories reference each other, so their
+	// fields are built lazily
+	var productType, sellerType, categoryType *graphql.Object
+
+	productType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Product",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id":          &graphql.Field{Type: graphql.NewNonNull(graphql.Int), Resolve: productField(func(p dto.ProductResponse) interface{} { return p.ID })},
+				"name":        &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: productField(func(p dto.ProductResponse) interface{} { return p.Name })},
+				"description": &graphql.Field{Type: graphql.String, Resolve: productField(func(p dto.ProductResponse) interface{} { return nullable(p.Description) })},
+				"price":       &graphql.Field{Type: graphql.NewNonNull(graphql.Float), Resolve: productField(func(p dto.ProductResponse) interface{} { return p.Price })},
+				"brand":       &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: productField(func(p dto.ProductResponse) interface{} { return p.Brand })},
+				"gtin":        &graphql.Field{Type: graphql.String, Resolve: productField(func(p dto.ProductResponse) interface{} { return nullable(p.GTIN) })},
+				"mpn":         &graphql.Field{Type: graphql.String, Resolve: productField(func(p dto.ProductResponse) interface{} { return nullable(p.MPN) })},
+				"imageUrl":    &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: productField(func(p dto.ProductResponse) interface{} { return p.ImageUrl })},
+				"rating":      &graphql.Field{Type: graphql.NewNonNull(graphql.Float), Resolve: productField(func(p dto.ProductResponse) interface{} { return p.Rating })},
+				"sellerId":    &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: productField(func(p dto.ProductResponse) interface{} { return p.SellerID })},
+				"seller":      &graphql.Field{Type: graphql.NewNonNull(sellerType), Resolve: productField(func(p dto.ProductResponse) interface{} { return seller{ID: p.SellerID} })},
+				"category":    &graphql.Field{Type: graphql.String, Resolve: productField(func(p dto.ProductResponse) interface{} { return nullable(p.Specifications.Category()) })},
+				"version":     &graphql.Field{Type: graphql.NewNonNull(graphql.Int), Resolve: productField(func(p dto.ProductResponse) interface{} { return p.Version })},
+				"updatedAt":   &graphql.Field{Type: graphql.String, Resolve: productField(func(p dto.ProductResponse) interface{} { return nullable(p.UpdatedAt) })},
+				"specifications": &graphql.Field{
+					Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(specificationType))),
+					Description: "Specifications sorted by key, only the given keys when keys is set",
+					Args: graphql.FieldConfigArgument{
+						"keys": &graphql.ArgumentConfig{Type: graphql.NewList(graphql.NewNonNull(graphql.String))},
+					},
+					Resolve: res.specifications,
+				},
+			}
+		}),
+	})
+
+	productList := graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(productType)))
+
+	sellerType = graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Seller",
+		Description: "A seller with at least one product",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id":           &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: sellerField(func(s seller) interface{} { return s.ID })},
+				"productCount": &graphql.Field{Type: graphql.NewNonNull(graphql.Int), Resolve: res.sellerProductCount},
+				"products":     &graphql.Field{Type: productList, Args: listArgs, Resolve: res.sellerProducts},
+			}
+		}),
+	})
+
+	categoryType = graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Category",
+		Description: "A product category, taken from the \"category\" specification",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"name":         &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: categoryField(func(c category) interface{} { return c.Name })},
+				"productCount": &graphql.Field{Type: graphql.NewNonNull(graphql.Int), Resolve: res.categoryProductCount},
+				"products":     &graphql.Field{Type: productList, Args: listArgs, Resolve: res.categoryProducts},
+			}
+		}),
+	})
+
+	comparisonType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Comparison",
+		Fields: graphql.Fields{
+			"products":          &graphql.Field{Type: productList, Description: "Compared products in request order", Resolve: res.comparisonProducts},
+			"specificationKeys": &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String))), Description: "Every specification key of the compared products, sorted", Resolve: res.comparisonKeys},
+			"cheapest":          &graphql.Field{Type: productType, Resolve: res.comparisonCheapest},
+			"bestRated":         &graphql.Field{Type: productType, Resolve: res.comparisonBestRated},
+		},
+	})
+
+	queryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"products": &graphql.Field{Type: productList, Args: listArgs, Resolve: res.products},
+			"product": &graphql.Field{
+				Type:    productType,
+				Args:    graphql.FieldConfigArgument{"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)}},
+				Resolve: res.product,
+			},
+			"sellers": &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(sellerType))), Resolve: res.sellers},
+			"seller": &graphql.Field{
+				Type:    sellerType,
+				Args:    graphql.FieldConfigArgument{"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)}},
+				Resolve: res.seller,
+			},
+			"categories": &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(categoryType))), Resolve: res.categories},
+			"compare": &graphql.Field{
+				Type:    graphql.NewNonNull(comparisonType),
+				Args:    graphql.FieldConfigArgument{"ids": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.Int)))}},
+				Resolve: res.compare,
+			},
+		},
+	})
+
+	specificationInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "SpecificationInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"key":   &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"value": &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+		},
+	})
+
+	productInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "ProductInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"id":             &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.Int)},
+			"name":           &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"description":    &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"price":          &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.Float)},
+			"brand":          &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"gtin":           &graphql.InputObjectFieldConfig{Type: graphql.String, Description: "EAN-8, UPC-A, EAN-13 or GTIN-14 with a valid check digit"},
+			"mpn":            &graphql.InputObjectFieldConfig{Type: graphql.String, Description: "Manufacturer part number, unique per brand"},
+			"imageUrl":       &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"rating":         &graphql.InputObjectFieldConfig{Type: graphql.Float},
+			"specifications": &graphql.InputObjectFieldConfig{Type: graphql.NewList(graphql.NewNonNull(specificationInput))},
+			"version":        &graphql.InputObjectFieldConfig{Type: graphql.Int, Description: "Version an update is based on, omit to skip the check"},
+		},
+	})
+
+	mutationType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createProduct": &graphql.Field{
+				Type:    graphql.NewNonNull(productType),
+				Args:    graphql.FieldConfigArgument{"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(productInput)}},
+				Resolve: res.createProduct,
+			},
+			"updateProduct": &graphql.Field{
+				Type:    graphql.NewNonNull(productType),
+				Args:    graphql.FieldConfigArgument{"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(productInput)}},
+				Resolve: res.updateProduct,
+			},
+			"deleteProduct": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Boolean),
+				Args: graphql.FieldConfigArgument{
+					"id":      &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+					"version": &graphql.ArgumentConfig{Type: graphql.Int, Description: "Current version, omit to skip the check", DefaultValue: 0},
+				},
+				Resolve: res.deleteProduct,
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: queryType, Mutation: mutationType})
+}
+
+// productField resolves a field of a product
+func productField(get func(dto.ProductResponse) interface{}) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		return get(p.Source.(dto.ProductResponse)), nil
+	}
+}
+
+// sellerField resolves a field of a seller
+func sellerField(get func(seller) interface{}) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		return get(p.Source.(seller)), nil
+	}
+}
+
+// categoryField resolves a field of a category
+func categoryField(get func(category) interface{}) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		return get(p.Source.(category)), nil
+	}
+}
+
+// specField resolves a field of a specification
+func specField(get func(specification) interface{}) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		return get(p.Source.(specification)), nil
+	}
+}
+
+// nullable maps empty optional strings to null
+func nullable(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
@@ -0,0 +1,49 @@
+// Package gtin validates Global Trade Item Numbers, the numbers behind EAN
+// and UPC barcodes. A GTIN-8 (EAN-8), GTIN-12 (UPC-A), GTIN-13 (EAN-13) and
+// GTIN-14 are the same number when padded to 14 digits with leading zeros.
+package gtin
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Length is the length every GTIN is normalized to
+const Length = 14
+
+// ErrInvalid is returned for codes that are not a valid GTIN
+var ErrInvalid = errors.New("invalid GTIN")
+
+// Normalize checks the length, digits and check digit of a GTIN and returns
+// it padded to 14 digits, so codes read from different barcodes compare equal
+func Normalize(code string) (string, error) {
+	switch len(code) {
+	case 8, 12, 13, 14:
+	default:
+		return "", fmt.Errorf("%w: %q must have 8, 12, 13 or 14 digits", ErrInvalid, code)
+	}
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return "", fmt.Errorf("%w: %q must only contain digits", ErrInvalid, code)
+		}
+	}
+	if want := checkDigit(code[:len(code)-1]); code[len(code)-1] != want {
+		return "", fmt.Errorf("%w: %q has check digit %c, expected %c", ErrInvalid, code, code[len(code)-1], want)
+	}
+	return strings.Repeat("0", Length-len(code)) + code, nil
+}
+
+// checkDigit computes the GS1 check digit of the digits before it: counting
+// from the right, digits are weighted 3 and 1 in turn
+func checkDigit(digits string) byte {
+	sum := 0
+	for i := 0; i < len(digits); i++ {
+		weight := 1
+		if (len(digits)-i)%2 == 1 {
+			weight = 3
+		}
+		sum += int(digits[i]-'0') * weight
+	}
+	return byte('0' + (10-sum%10)%10)
+}
@@ -0,0 +1,68 @@
+package gtin
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name    string
+		code    string
+		want    string
+		wantErr bool
+	}{
+		{"GTIN-8", "96385074", "00000096385074", false},
+		{"GTIN-12", "036000291452", "00036000291452", false},
+		{"GTIN-13", "4006381333931", "04006381333931", false},
+		{"GTIN-14", "10012345678902", "10012345678902", false},
+		{"UPC-A as EAN-13", "0036000291452", "00036000291452", false},
+		{"all zeros", "00000000000000", "00000000000000", false},
+		{"wrong check digit", "4006381333932", "", true},
+		{"wrong check digit GTIN-8", "96385075", "", true},
+		{"too short", "1234567", "", true},
+		{"GTIN-11", "12345678901", "", true},
+		{"too long", "100123456789021", "", true},
+		{"letters", "40063813339a1", "", true},
+		{"spaces", "400638 333931", "", true},
+		{"empty", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Normalize(tt.code)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalid) {
+					t.Fatalf("Normalize(%q) error = %v, want %v", tt.code, err, ErrInvalid)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Normalize(%q) error = %v", tt.code, err)
+			}
+			if got != tt.want {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.code, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckDigit(t *testing.T) {
+	tests := []struct {
+		digits string
+		want   byte
+	}{
+		{"400638133393", '1'},
+		{"03600029145", '2'},
+		{"9638507", '4'},
+		{"1001234567890", '2'},
+		// A sum divisible by ten gives 0, not 10
+		{"0000000", '0'},
+	}
+
+	for _, tt := range tests {
+		if got := checkDigit(tt.digits); got != tt.want {
+			t.Errorf("checkDigit(%q) = %c, want %c", tt.digits, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package idempotency
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Header is the request header carrying the idempotency key
+const Header = "Idempotency-Key"
+
+// ReplayedHeader marks responses replayed for a retry
+const ReplayedHeader = "Idempotent-Replayed"
+
+// MaxKeyLength is the longest idempotency key accepted
+const MaxKeyLength = 255
+
+// Middleware makes POST, PUT, PATCH and DELETE requests that carry an
+// Idempotency-Key safe to retry. The first response is stored and replayed
+// for identical retries; reusing a key for a different request gets a 422.
+// Keys are scoped by the string scope returns, e.g. the seller, so clients
+// can't collide with each other; requests without a scope are not deduplicated.
+// Server errors are not stored so the request can be retried.
+func Middleware(store Store, scope func(*http.Request) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(Header)
+			owner := scope(r)
+			if key == "" || owner == "" || !isWrite(r.Method) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > MaxKeyLength {
+				http.Error(w, fmt.Sprintf("%s must be at most %d characters", Header, MaxKeyLength), http.StatusBadRequest)
+				return
+			}
+
+			// The body is part of the fingerprint, keep a copy for the handler
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				http.Error(w, "Invalid request body", http.StatusBadRequest)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			scopedKey := owner + "\x00" + key
+			outcome, stored := store.Begin(scopedKey, fingerprint(r, body))
+			switch outcome {
+			case Mismatch:
+				fmt.Printf("[Idempotency][Middleware][ERROR] Key reused with a different request: %s %s\n", r.Method, r.URL.Path)
+				http.Error(w, Header+" was already used for a different request", http.StatusUnprocessableEntity)
+				return
+			case InFlight:
+				fmt.Printf("[Idempotency][Middleware][ERROR] Key still in flight: %s %s\n", r.Method, r.URL.Path)
+				w.Header().Set("Retry-After", "1")
+				http.Error(w, "A request with this "+Header+" is still being processed", http.StatusConflict)
+				return
+			case Replay:
+				fmt.Printf("[Idempotency][Middleware] Replaying stored response for %s %s\n", r.Method, r.URL.Path)
+				replay(w, stored)
+				return
+			}
+
+			recorder := &recorder{ResponseWriter: w, status: http.StatusOK}
+			completed := false
+			defer func() {
+				// Release the key if the handler panicked or failed on our side
+				if !completed {
+					store.Release(scopedKey)
+				}
+			}()
+			next.ServeHTTP(recorder, r)
+
+			if recorder.status >= http.StatusInternalServerError {
+				return
+			}
+			store.Complete(scopedKey, &Response{
+				Status: recorder.status,
+				Header: recorder.header,
+				Body:   recorder.body.Bytes(),
+			})
+			completed = true
+		})
+	}
+}
+
+// isWrite reports whether the method is one idempotency keys apply to
+func isWrite(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// fingerprint identifies a request by method, path, query, preconditions and body
+func fingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	for _, part := range []string{r.Method, r.URL.Path, r.URL.RawQuery, r.Header.Get("Content-Type"), r.Header.Get("If-Match")} {
+		io.WriteString(h, part)
+		h.Write([]byte{0})
+	}
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// replay writes a stored response
+func replay(w http.ResponseWriter, stored *Response) {
+	for name, values := range stored.Header {
+		w.Header()[name] = values
+	}
+	w.Header().Set(ReplayedHeader, "true")
+	w.WriteHeader(stored.Status)
+	w.Write(stored.Body)
+}
+
+// recorder passes the response through while keeping a copy of it
+type recorder struct {
+	http.ResponseWriter
+	status      int
+	header      http.Header
+	body        bytes.Buffer
+	wroteHeader bool
+}
+
+func (r *recorder) WriteHeader(status int) {
+	if r.wroteHeader {
+		return
+	}
+	r.wroteHeader = true
+	r.status = status
+	r.header = r.ResponseWriter.Header().Clone()
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	if !r.wroteHeader {
+		r.WriteHeader(http.StatusOK)
+	}
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
@@ -0,0 +1,66 @@
+package idempotency
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestMiddleware sends a request and then a second one with the same key
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name         string
+		seller       string
+		firstBody    string
+		secondBody   string
+		wantStatus   int
+		wantReplayed bool
+		wantCalls    int
+	}{
+		{"identical retry is replayed", "s1", `{"a":1}`, `{"a":1}`, http.StatusCreated, true, 1},
+		{"different body is rejected", "s1", `{"a":1}`, `{"a":2}`, http.StatusUnprocessableEntity, false, 1},
+		{"anonymous requests are not deduplicated", "", `{"a":1}`, `{"a":1}`, http.StatusCreated, false, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			handler := Middleware(NewMemoryStore(time.Hour), func(r *http.Request) string {
+				return r.Header.Get("X-Seller-ID")
+			})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				body, _ := io.ReadAll(r.Body)
+				w.WriteHeader(http.StatusCreated)
+				fmt.Fprintf(w, "call %d: %s", calls, body)
+			}))
+
+			send := func(body string) *httptest.ResponseRecorder {
+				req := httptest.NewRequest(http.MethodPost, "/api/v1/products", strings.NewReader(body))
+				req.Header.Set(Header, "k1")
+				req.Header.Set("X-Seller-ID", tt.seller)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+				return rec
+			}
+			first := send(tt.firstBody)
+			second := send(tt.secondBody)
+
+			if second.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", second.Code, tt.wantStatus, second.Body)
+			}
+			if replayed := second.Header().Get(ReplayedHeader) == "true"; replayed != tt.wantReplayed {
+				t.Errorf("replayed = %v, want %v", replayed, tt.wantReplayed)
+			}
+			if tt.wantReplayed && second.Body.String() != first.Body.String() {
+				t.Errorf("replayed body = %q, want %q", second.Body, first.Body)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("handler ran %d times, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
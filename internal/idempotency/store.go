@@ -0,0 +1,113 @@
+package idempotency
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Response is a stored response, replayed for retries of the same request
+type Response struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// Outcome of starting a request with an idempotency key
+type Outcome int
+
+const (
+	// Started means the key is new and the caller must Complete or Release it
+	Started Outcome = iota
+	// Replay means a response is stored for the same request
+	Replay
+	// Mismatch means the key was used for a different request
+	Mismatch
+	// InFlight means a request with the key is still being processed
+	InFlight
+)
+
+// Store keeps responses by idempotency key for a limited window
+type Store interface {
+	// Begin claims key for a request with the given fingerprint, or returns
+	// the stored response when the same request already completed
+	Begin(key, fingerprint string) (Outcome, *Response)
+	// Complete stores the response of a started request
+	Complete(key string, response *Response)
+	// Release forgets a started request so it can be retried
+	Release(key string)
+}
+
+type entry struct {
+	fingerprint string
+	response    *Response
+	expires     time.Time
+}
+
+// MemoryStore is a Store kept in process memory
+type MemoryStore struct {
+	window    time.Duration
+	mu        sync.Mutex
+	entries   map[string]*entry
+	lastSweep time.Time
+}
+
+// NewMemoryStore creates a new instance of MemoryStore keeping responses for window
+func NewMemoryStore(window time.Duration) *MemoryStore {
+	return &MemoryStore{
+		window:    window,
+		entries:   make(map[string]*entry),
+		lastSweep: time.Now(),
+	}
+}
+
+func (s *MemoryStore) Begin(key, fingerprint string) (Outcome, *Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+
+	if e, ok := s.entries[key]; ok && now.Before(e.expires) {
+		switch {
+		case e.fingerprint != fingerprint:
+			return Mismatch, nil
+		case e.response == nil:
+			return InFlight, nil
+		default:
+			return Replay, e.response
+		}
+	}
+
+	s.entries[key] = &entry{fingerprint: fingerprint, expires: now.Add(s.window)}
+	return Started, nil
+}
+
+func (s *MemoryStore) Complete(key string, response *Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.entries[key]; ok {
+		e.response = response
+		e.expires = time.Now().Add(s.window)
+	}
+}
+
+func (s *MemoryStore) Release(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+}
+
+// sweep drops expired entries at most once a minute, callers hold the lock
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	for key, e := range s.entries {
+		if now.After(e.expires) {
+			delete(s.entries, key)
+		}
+	}
+	s.lastSweep = now
+}
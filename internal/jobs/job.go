@@ -0,0 +1,85 @@
+package jobs
+
+import "errors"
+
+// Import modes: create fails rows whose ID already exists, upsert replaces them
+const (
+	ModeCreate = "create"
+	ModeUpsert = "upsert"
+)
+
+// Job statuses. Queued and running jobs are resumed after a restart.
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+)
+
+// maxRowErrors bounds the row errors kept per job, the failed count keeps growing
+const maxRowErrors = 1000
+
+var (
+	// ErrJobNotFound is returned for unknown import job IDs
+	ErrJobNotFound = errors.New("import job not found")
+	// ErrForbidden is returned when a seller reads an import started by another seller
+	ErrForbidden = errors.New("unauthorized: you do not own this import")
+	// ErrInvalidImport is returned for uploads or options that can't be imported
+	ErrInvalidImport = errors.New("invalid import")
+	// ErrKeyReused is returned when an idempotency key of the seller was
+	// already used for a different import
+	ErrKeyReused = errors.New("idempotency key was already used for a different import")
+)
+
+// Job is the persisted state of an import. InFlight counts the rows after
+// Processed that are being written; after a crash they may already be stored.
+// Fingerprint hashes the options and upload of a job created with an
+// idempotency key, so retries can be told apart from other imports.
+type Job struct {
+	ID          string            `json:"id"`
+	SellerID    string            `json:"seller_id"`
+	Key         string            `json:"idempotency_key,omitempty"`
+	Fingerprint string            `json:"fingerprint,omitempty"`
+	Mode        string            `json:"mode"`
+	Format      string            `json:"format,omitempty"`
+	Columns     map[string]string `json:"columns,omitempty"`
+	Status      string            `json:"status"`
+	Total       int               `json:"total"`
+	Processed   int               `json:"processed"`
+	InFlight    int               `json:"in_flight,omitempty"`
+	Succeeded   int               `json:"succeeded"`
+	Failed      int               `json:"failed"`
+	Errors      []RowError        `json:"errors,omitempty"`
+	Error       string            `json:"error,omitempty"`
+	CreatedAt   string            `json:"created_at"`
+	UpdatedAt   string            `json:"updated_at"`
+	FinishedAt  string            `json:"finished_at,omitempty"`
+}
+
+// RowError describes a row of the upload that could not be imported
+type RowError struct {
+	Row   int    `json:"row"`
+	ID    int    `json:"id,omitempty"`
+	Code  string `json:"code"`
+	Error string `json:"error"`
+}
+
+// finished reports whether the job will not make further progress
+func (j *Job) finished() bool {
+	return j.Status == StatusCompleted || j.Status == StatusFailed
+}
+
+// addError records a failed row, keeping at most maxRowErrors of them
+func (j *Job) addError(e RowError) {
+	j.Failed++
+	if len(j.Errors) < maxRowErrors {
+		j.Errors = append(j.Errors, e)
+	}
+}
+
+// clone returns a copy of the job that is safe to hand out
+func (j *Job) clone() *Job {
+	c := *j
+	c.Errors = append([]RowError(nil), j.Errors...)
+	return &c
+}
@@ -0,0 +1,483 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"item-comparison-api/internal/codec"
+	"item-comparison-api/internal/dto"
+	"item-comparison-api/internal/repository"
+	"item-comparison-api/internal/services"
+	"maps"
+	"os"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("item-comparison-api/internal/jobs")
+
+// Manager accepts product imports and processes them in the background with
+// a pool of workers. Progress is saved after every chunk, so jobs interrupted
+// by a shutdown resume from their last chunk on the next start.
+type Manager struct {
+	dir       string
+	service   services.ProductServiceInterface
+	workers   int
+	chunkSize int
+
+	mu   sync.Mutex
+	jobs map[string]*Job
+	// keys maps a seller and idempotency key to the job created with them
+	keys    map[string]string
+	pending []string
+	wake    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// NewManager creates a Manager keeping jobs in dir and loads the jobs saved there
+func NewManager(dir string, service services.ProductServiceInterface, workers, chunkSize int) (*Manager, error) {
+	fmt.Printf("[ImportManager][NewManager] Initializing with directory: %s\n", dir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create import directory %s: %w", dir, err)
+	}
+
+	saved, err := loadJobs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Manager{
+		dir:       dir,
+		service:   service,
+		workers:   workers,
+		chunkSize: chunkSize,
+		jobs:      make(map[string]*Job, len(saved)),
+		keys:      make(map[string]string),
+		wake:      make(chan struct{}, 1),
+	}
+	for _, job := range saved {
+		// Jobs saved before other formats were supported are JSON
+		if job.Format == "" {
+			job.Format = codec.JSON
+		}
+		m.jobs[job.ID] = job
+		if job.Key != "" {
+			m.keys[idempotencyKey(job.SellerID, job.Key)] = job.ID
+		}
+		if !job.finished() {
+			m.pending = append(m.pending, job.ID)
+		}
+	}
+	fmt.Printf("[ImportManager][NewManager] Loaded %d jobs, %d to resume\n", len(saved), len(m.pending))
+	return m, nil
+}
+
+// Start launches the workers. They stop once ctx is canceled, after
+// finishing the chunk they are working on.
+func (m *Manager) Start(ctx context.Context) {
+	for i := 0; i < m.workers; i++ {
+		m.wg.Add(1)
+		go func() {
+			defer m.wg.Done()
+			m.work(ctx)
+		}()
+	}
+	m.signal()
+}
+
+// Wait blocks until every worker has stopped
+func (m *Manager) Wait() {
+	m.wg.Wait()
+}
+
+// Create stores the upload and queues an import of it for the seller. columns
+// maps CSV header names to product fields. With an idempotency key, a retry
+// of the same import returns the job it created and replayed is true, and a
+// different import with the key fails with ErrKeyReused.
+func (m *Manager) Create(sellerID, mode, format string, columns map[string]string, key string, upload io.Reader) (job *Job, replayed bool, err error) {
+	fmt.Printf("[ImportManager][Create] Called with sellerID: %s, mode: %s, format: %s\n", sellerID, mode, format)
+	if mode != ModeCreate && mode != ModeUpsert {
+		return nil, false, fmt.Errorf("%w: mode %q must be %s or %s", ErrInvalidImport, mode, ModeCreate, ModeUpsert)
+	}
+	if !codec.Valid(format) {
+		return nil, false, fmt.Errorf("%w: format %q must be %s, %s or %s", ErrInvalidImport, format, codec.JSON, codec.CSV, codec.NDJSON)
+	}
+	if len(columns) > 0 && format != codec.CSV {
+		return nil, false, fmt.Errorf("%w: column mappings only apply to %s uploads", ErrInvalidImport, codec.CSV)
+	}
+
+	id, err := newJobID()
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Keep the upload on disk, it is read again row by row by the worker
+	path := uploadPath(m.dir, id)
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to store upload: %w", err)
+	}
+	// Retries are recognized by a hash of the options and the upload, taken
+	// while it is written so it is never held in memory
+	digest := fingerprint(mode, format, columns)
+	_, err = io.Copy(io.MultiWriter(file, digest), upload)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return nil, false, fmt.Errorf("failed to store upload: %w", err)
+	}
+
+	now := time.Now().Format(time.RFC3339)
+	job = &Job{
+		ID:        id,
+		SellerID:  sellerID,
+		Key:       key,
+		Mode:      mode,
+		Format:    format,
+		Columns:   columns,
+		Status:    StatusQueued,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if key != "" {
+		job.Fingerprint = hex.EncodeToString(digest.Sum(nil))
+	}
+
+	// Reject malformed uploads right away instead of failing the job later
+	job.Total, err = countRows(path, job)
+	if err != nil {
+		os.Remove(path)
+		fmt.Printf("[ImportManager][Create][ERROR] %v\n", err)
+		return nil, false, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+	}
+
+	// Claim the key before saving, a concurrent retry then finds this job
+	m.mu.Lock()
+	if key != "" {
+		if existing, ok := m.jobs[m.keys[idempotencyKey(sellerID, key)]]; ok {
+			snapshot := existing.clone()
+			m.mu.Unlock()
+			os.Remove(path)
+			if snapshot.Fingerprint != job.Fingerprint {
+				fmt.Printf("[ImportManager][Create][ERROR] Key reused for a different import than job %s\n", snapshot.ID)
+				return nil, false, ErrKeyReused
+			}
+			fmt.Printf("[ImportManager][Create] Retry of job %s\n", snapshot.ID)
+			return snapshot, true, nil
+		}
+		m.keys[idempotencyKey(sellerID, key)] = id
+	}
+	m.jobs[id] = job
+	m.mu.Unlock()
+
+	if err := saveJob(m.dir, job); err != nil {
+		os.Remove(path)
+		m.mu.Lock()
+		delete(m.jobs, id)
+		if key != "" {
+			delete(m.keys, idempotencyKey(sellerID, key))
+		}
+		m.mu.Unlock()
+		return nil, false, err
+	}
+
+	m.mu.Lock()
+	m.pending = append(m.pending, id)
+	snapshot := job.clone()
+	m.mu.Unlock()
+	m.signal()
+
+	fmt.Printf("[ImportManager][Create] Queued job %s with %d rows\n", id, job.Total)
+	return snapshot, false, nil
+}
+
+// Get returns the current state of a job owned by the seller
+func (m *Manager) Get(id, sellerID string) (*Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, ok := m.jobs[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrJobNotFound, id)
+	}
+	if job.SellerID != sellerID {
+		return nil, ErrForbidden
+	}
+	return job.clone(), nil
+}
+
+// signal wakes up an idle worker
+func (m *Manager) signal() {
+	select {
+	case m.wake <- struct{}{}:
+	default:
+	}
+}
+
+// next takes the next queued job, if any
+func (m *Manager) next() (string, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.pending) == 0 {
+		return "", false
+	}
+	id := m.pending[0]
+	m.pending = m.pending[1:]
+
+	// Let another worker pick up the rest of the queue
+	if len(m.pending) > 0 {
+		m.signal()
+	}
+	return id, true
+}
+
+// work processes queued jobs until ctx is canceled
+func (m *Manager) work(ctx context.Context) {
+	for {
+		if id, ok := m.next(); ok {
+			m.run(ctx, id)
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-m.wake:
+		}
+	}
+}
+
+// run processes a job from its last saved position
+func (m *Manager) run(ctx context.Context, id string) {
+	if ctx.Err() != nil {
+		return
+	}
+
+	m.mu.Lock()
+	job := m.jobs[id]
+	job.Status = StatusRunning
+	processed := job.Processed
+	replay := job.InFlight
+	m.mu.Unlock()
+	m.persist(job)
+	fmt.Printf("[ImportManager][run] Running job %s from row %d\n", id, processed+1)
+
+	reader, err := openUpload(uploadPath(m.dir, id), job)
+	if err != nil {
+		m.fail(job, err)
+		return
+	}
+	defer reader.Close()
+
+	// Skip the rows processed before an interruption
+	for i := 0; i < processed; i++ {
+		if _, err := reader.Next(); err != nil {
+			m.fail(job, err)
+			return
+		}
+	}
+
+	for {
+		// Stop between chunks on shutdown, the job resumes on the next start
+		if ctx.Err() != nil {
+			fmt.Printf("[ImportManager][run] Interrupted job %s at row %d\n", id, processed)
+			return
+		}
+
+		rows, err := readChunk(reader, m.chunkSize)
+		if err != nil {
+			m.fail(job, err)
+			return
+		}
+		if len(rows) == 0 {
+			break
+		}
+
+		// Mark the chunk before writing it, so a crash midway is noticed
+		m.mu.Lock()
+		job.InFlight = len(rows)
+		m.mu.Unlock()
+		m.persist(job)
+
+		// Finish the chunk even if shutdown starts, so the checkpoint stays exact
+		m.processChunk(context.WithoutCancel(ctx), job, rows, min(replay, len(rows)))
+		processed += len(rows)
+		replay = max(replay-len(rows), 0)
+	}
+
+	m.mu.Lock()
+	job.Status = StatusCompleted
+	job.FinishedAt = time.Now().Format(time.RFC3339)
+	m.mu.Unlock()
+	m.persist(job)
+	os.Remove(uploadPath(m.dir, id))
+	fmt.Printf("[ImportManager][run] Completed job %s: %d succeeded, %d failed\n", id, job.Succeeded, job.Failed)
+}
+
+// processChunk writes one chunk of rows through the service and records the
+// results. The first replayed rows were in flight when the job crashed, so
+// their products may already be stored: a conflict on one of them counts as
+// a success when the stored product matches the row.
+func (m *Manager) processChunk(ctx context.Context, job *Job, rows []row, replayed int) {
+	ctx, span := tracer.Start(ctx, "ImportJob.Chunk", trace.WithAttributes(
+		attribute.String("import.id", job.ID),
+		attribute.Int("import.first_row", rows[0].Number),
+		attribute.Int("import.rows", len(rows)),
+	))
+	defer span.End()
+
+	// Rows that could not be decoded fail on their own, the rest are written
+	var failed []RowError
+	var requests []dto.ProductRequest
+	var numbers []int
+	for _, r := range rows {
+		if r.Err != nil {
+			failed = append(failed, RowError{Row: r.Number, Code: "invalid_row", Error: r.Err.Error()})
+			continue
+		}
+		requests = append(requests, r.Product)
+		numbers = append(numbers, r.Number)
+	}
+
+	var results []services.ItemResult
+	if len(requests) > 0 {
+		if job.Mode == ModeUpsert {
+			results = m.service.UpdateProductsBulk(ctx, requests, job.SellerID)
+		} else {
+			results = m.service.SaveProductsBulk(ctx, requests, job.SellerID)
+		}
+	}
+
+	lastReplayed := 0
+	if replayed > 0 {
+		lastReplayed = rows[replayed-1].Number
+	}
+
+	succeeded := 0
+	for _, result := range results {
+		if result.Err != nil && numbers[result.Index] <= lastReplayed && m.alreadyImported(ctx, job.SellerID, requests[result.Index], result.Err) {
+			fmt.Printf("[ImportManager][processChunk] Row %d of job %s was written before a crash\n", numbers[result.Index], job.ID)
+			succeeded++
+			continue
+		}
+		if result.Err != nil {
+			failed = append(failed, RowError{Row: numbers[result.Index], ID: result.ID, Code: services.ErrorCode(result.Err), Error: result.Err.Error()})
+			continue
+		}
+		succeeded++
+	}
+	sort.Slice(failed, func(i, j int) bool { return failed[i].Row < failed[j].Row })
+
+	m.mu.Lock()
+	for _, e := range failed {
+		job.addError(e)
+	}
+	job.Succeeded += succeeded
+	job.Processed += len(rows)
+	job.InFlight = 0
+	m.mu.Unlock()
+	m.persist(job)
+}
+
+// alreadyImported reports whether a row failed only because it was written
+// before: its product exists, belongs to the seller and holds the row's values
+func (m *Manager) alreadyImported(ctx context.Context, sellerID string, req dto.ProductRequest, err error) bool {
+	if !errors.Is(err, repository.ErrAlreadyExists) && !errors.Is(err, repository.ErrVersionConflict) {
+		return false
+	}
+	stored, err := m.service.GetProductByID(ctx, req.ID)
+	if err != nil || stored.ID != req.ID || stored.SellerID != sellerID {
+		return false
+	}
+	return stored.Name == req.Name &&
+		stored.Description == req.Description &&
+		stored.Price == req.Price &&
+		stored.Brand == req.Brand &&
+		stored.GTIN == req.GTIN &&
+		stored.MPN == req.MPN &&
+		stored.ImageUrl == req.ImageUrl &&
+		stored.Rating == req.Rating &&
+		maps.Equal(stored.Specifications, req.Specifications)
+}
+
+// fail marks a job as failed when its upload can no longer be read
+func (m *Manager) fail(job *Job, err error) {
+	fmt.Printf("[ImportManager][run][ERROR] Job %s failed: %v\n", job.ID, err)
+	m.mu.Lock()
+	job.Status = StatusFailed
+	job.Error = err.Error()
+	job.FinishedAt = time.Now().Format(time.RFC3339)
+	m.mu.Unlock()
+	m.persist(job)
+	os.Remove(uploadPath(m.dir, job.ID))
+}
+
+// persist saves the job state, a failed save is retried with the next update
+func (m *Manager) persist(job *Job) {
+	m.mu.Lock()
+	job.UpdatedAt = time.Now().Format(time.RFC3339)
+	snapshot := job.clone()
+	m.mu.Unlock()
+
+	if err := saveJob(m.dir, snapshot); err != nil {
+		fmt.Printf("[ImportManager][persist][ERROR] %v\n", err)
+	}
+}
+
+// readChunk reads up to size rows
+func readChunk(reader *uploadReader, size int) ([]row, error) {
+	rows := make([]row, 0, size)
+	for len(rows) < size {
+		r, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, r)
+	}
+	return rows, nil
+}
+
+// idempotencyKey scopes an idempotency key to the seller
+func idempotencyKey(sellerID, key string) string {
+	return sellerID + "\x00" + key
+}
+
+// fingerprint starts the hash identifying an import, the upload is written to
+// it after the options
+func fingerprint(mode, format string, columns map[string]string) hash.Hash {
+	h := sha256.New()
+	parts := []string{mode, format}
+	for _, column := range slices.Sorted(maps.Keys(columns)) {
+		parts = append(parts, column, columns[column])
+	}
+	for _, part := range parts {
+		io.WriteString(h, part)
+		h.Write([]byte{0})
+	}
+	return h
+}
+
+// newJobID returns a random job ID
+func newJobID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate import job ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,159 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"item-comparison-api/internal/codec"
+	"item-comparison-api/internal/models"
+	"item-comparison-api/internal/repository"
+	"item-comparison-api/internal/services"
+)
+
+// TestCreateRecognizesRetries checks that an import retried with its
+// idempotency key returns the job it created
+func TestCreateRecognizesRetries(t *testing.T) {
+	const upload = `[{"id":1,"name":"Phone","price":10}]`
+	tests := []struct {
+		name         string
+		seller       string
+		key          string
+		mode         string
+		upload       string
+		wantErr      error
+		wantReplayed bool
+	}{
+		{"same import", "s1", "k1", ModeCreate, upload, nil, true},
+		{"different upload", "s1", "k1", ModeCreate, `[{"id":2,"name":"Phone","price":10}]`, ErrKeyReused, false},
+		{"different mode", "s1", "k1", ModeUpsert, upload, ErrKeyReused, false},
+		{"other seller", "s2", "k1", ModeCreate, upload, nil, false},
+		{"no key", "s1", "", ModeCreate, upload, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			m, err := NewManager(dir, nil, 1, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			first, _, err := m.Create("s1", ModeCreate, codec.JSON, nil, "k1", strings.NewReader(upload))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// The key is also known after a restart
+			m, err = NewManager(dir, nil, 1, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			job, replayed, err := m.Create(tt.seller, tt.mode, codec.JSON, nil, tt.key, strings.NewReader(tt.upload))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if replayed != tt.wantReplayed {
+				t.Errorf("replayed = %v, want %v", replayed, tt.wantReplayed)
+			}
+			if sameJob := job.ID == first.ID; sameJob != tt.wantReplayed {
+				t.Errorf("job %s, first job %s, want same job %v", job.ID, first.ID, tt.wantReplayed)
+			}
+		})
+	}
+}
+
+// TestRunReplaysChunkInFlight resumes a job that crashed while writing its
+// first chunk. Rows written before the crash count as succeeded, other
+// conflicts still fail.
+func TestRunReplaysChunkInFlight(t *testing.T) {
+	const upload = `[
+		{"id":1,"name":"Phone","price":10,"brand":"Acme"},
+		{"id":2,"name":"Tablet","price":20,"brand":"Acme","version":1}
+	]`
+	phone := models.Product{ID: 1, Name: "Phone", Price: 10, Brand: "Acme", SellerID: "s1", Version: 1}
+	tablet := models.Product{ID: 2, Name: "Tablet", Price: 20, Brand: "Acme", SellerID: "s1", Version: 2}
+	tests := []struct {
+		name string
+		mode string
+		// stored are the products in the catalog when the job resumes
+		stored        []models.Product
+		inFlight      int
+		wantSucceeded int
+		wantFailed    []int
+	}{
+		{"nothing written yet", ModeCreate, nil, 2, 2, nil},
+		{"first row written", ModeCreate, []models.Product{phone}, 2, 2, nil},
+		{"first row written, no crash marker", ModeCreate, []models.Product{phone}, 0, 1, []int{1}},
+		{"first row of another seller", ModeCreate, []models.Product{{ID: 1, Name: "Phone", Price: 10, Brand: "Acme", SellerID: "s2", Version: 1}}, 2, 1, []int{1}},
+		{"first row with other values", ModeCreate, []models.Product{{ID: 1, Name: "Phone", Price: 12, Brand: "Acme", SellerID: "s1", Version: 1}}, 2, 1, []int{1}},
+		{"upsert written", ModeUpsert, []models.Product{phone, tablet}, 2, 2, nil},
+		{"upsert only the first row in flight", ModeUpsert, []models.Product{phone, tablet}, 1, 1, []int{2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := repository.NewProductRepoMemory()
+			if len(tt.stored) > 0 {
+				if err := repo.SaveProducts(ctx, tt.stored); err != nil {
+					t.Fatal(err)
+				}
+			}
+			service := services.NewProductService(repo)
+
+			// Leave the job as a crash during its first chunk would
+			dir := t.TempDir()
+			m, err := NewManager(dir, service, 1, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			job, _, err := m.Create("s1", tt.mode, codec.JSON, nil, "", strings.NewReader(upload))
+			if err != nil {
+				t.Fatal(err)
+			}
+			job.Status = StatusRunning
+			job.InFlight = tt.inFlight
+			if err := saveJob(dir, job); err != nil {
+				t.Fatal(err)
+			}
+
+			m, err = NewManager(dir, service, 1, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			runCtx, cancel := context.WithCancel(ctx)
+			m.Start(runCtx)
+			defer func() {
+				cancel()
+				m.Wait()
+			}()
+			deadline := time.Now().Add(5 * time.Second)
+			for !job.finished() && time.Now().Before(deadline) {
+				time.Sleep(10 * time.Millisecond)
+				if job, err = m.Get(job.ID, "s1"); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if job.Status != StatusCompleted {
+				t.Fatalf("status = %s, want %s", job.Status, StatusCompleted)
+			}
+			if job.Succeeded != tt.wantSucceeded {
+				t.Errorf("succeeded = %d, want %d", job.Succeeded, tt.wantSucceeded)
+			}
+			var failed []int
+			for _, e := range job.Errors {
+				failed = append(failed, e.Row)
+			}
+			if !slices.Equal(failed, tt.wantFailed) {
+				t.Errorf("failed rows = %v, want %v", failed, tt.wantFailed)
+			}
+		})
+	}
+}
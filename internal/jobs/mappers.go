@@ -0,0 +1,26 @@
+package jobs
+
+import "item-comparison-api/internal/dto"
+
+// JobToResponse converts a Job to an ImportJobResponse DTO
+func JobToResponse(job *Job) dto.ImportJobResponse {
+	errors := make([]dto.ImportRowError, 0, len(job.Errors))
+	for _, e := range job.Errors {
+		errors = append(errors, dto.ImportRowError{Row: e.Row, ID: e.ID, Code: e.Code, Error: e.Error})
+	}
+	return dto.ImportJobResponse{
+		ID:         job.ID,
+		Mode:       job.Mode,
+		Format:     job.Format,
+		Status:     job.Status,
+		Total:      job.Total,
+		Processed:  job.Processed,
+		Succeeded:  job.Succeeded,
+		Failed:     job.Failed,
+		Errors:     errors,
+		Error:      job.Error,
+		CreatedAt:  job.CreatedAt,
+		UpdatedAt:  job.UpdatedAt,
+		FinishedAt: job.FinishedAt,
+	}
+}
@@ -0,0 +1,74 @@
+package jobs
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// jobPath returns the file the state of a job is kept in
+func jobPath(dir, id string) string {
+	return filepath.Join(dir, id+".json")
+}
+
+// uploadPath returns the file the upload of a job is kept in until it completes
+func uploadPath(dir, id string) string {
+	return filepath.Join(dir, id+".upload")
+}
+
+// saveJob writes the job state atomically, so a crash never leaves a
+// half-written file behind
+func saveJob(dir string, job *Job) error {
+	bytes, err := json.MarshalIndent(job, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal import job %s: %w", job.ID, err)
+	}
+
+	tmp, err := os.CreateTemp(dir, ".job-*")
+	if err != nil {
+		return fmt.Errorf("failed to write import job %s: %w", job.ID, err)
+	}
+	if _, err := tmp.Write(bytes); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write import job %s: %w", job.ID, err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write import job %s: %w", job.ID, err)
+	}
+	if err := os.Rename(tmp.Name(), jobPath(dir, job.ID)); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write import job %s: %w", job.ID, err)
+	}
+	return nil
+}
+
+// loadJobs reads every job state file in dir
+func loadJobs(dir string) ([]*Job, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read import directory %s: %w", dir, err)
+	}
+
+	var jobs []*Job
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") || filepath.Ext(name) != ".json" {
+			continue
+		}
+
+		bytes, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read import job %s: %w", name, err)
+		}
+		var job Job
+		if err := json.Unmarshal(bytes, &job); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal import job %s: %w", name, err)
+		}
+		jobs = append(jobs, &job)
+	}
+	return jobs, nil
+}
@@ -0,0 +1,78 @@
+package jobs
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"item-comparison-api/internal/codec"
+	"item-comparison-api/internal/dto"
+	"os"
+)
+
+// row is one product of an upload, Err is set when it could not be decoded
+type row struct {
+	Number  int
+	Product dto.ProductRequest
+	Err     error
+}
+
+// uploadReader numbers the rows of an upload file as they are decoded
+type uploadReader struct {
+	file   *os.File
+	reader codec.Reader
+	next   int
+}
+
+// openUpload opens the upload file of a job and positions the reader on the first row
+func openUpload(path string, job *Job) (*uploadReader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	reader, err := codec.NewReader(job.Format, file, job.Columns)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &uploadReader{file: file, reader: reader, next: 1}, nil
+}
+
+// Next returns the next row or io.EOF after the last one. Rows that can't be
+// decoded are returned with Err set; other errors end the upload.
+func (u *uploadReader) Next() (row, error) {
+	product, err := u.reader.Next()
+	if err == io.EOF {
+		return row{}, io.EOF
+	}
+	if err != nil && !errors.Is(err, codec.ErrInvalidRow) {
+		return row{}, fmt.Errorf("row %d: %w", u.next, err)
+	}
+
+	r := row{Number: u.next, Product: product, Err: err}
+	u.next++
+	return r, nil
+}
+
+// Close closes the upload file
+func (u *uploadReader) Close() error {
+	return u.file.Close()
+}
+
+// countRows reads the whole upload to check its syntax and count its rows
+func countRows(path string, job *Job) (int, error) {
+	reader, err := openUpload(path, job)
+	if err != nil {
+		return 0, err
+	}
+	defer reader.Close()
+
+	count := 0
+	for {
+		if _, err := reader.Next(); err == io.EOF {
+			return count, nil
+		} else if err != nil {
+			return 0, err
+		}
+		count++
+	}
+}
@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "item_comparison"
+
+// Metrics holds the Prometheus collectors exposed by the API
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests *prometheus.CounterVec
+	httpDuration *prometheus.HistogramVec
+
+	repoDuration *prometheus.HistogramVec
+	repoErrors   *prometheus.CounterVec
+
+	catalogSize prometheus.Gauge
+	compareSize prometheus.Histogram
+}
+
+// New creates a new instance of Metrics with its own registry
+func New() *Metrics {
+	fmt.Printf("[Metrics][New] Initializing Prometheus registry\n")
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "Number of HTTP requests by method, route and status code.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by method, route and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		repoDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "repository_operation_duration_seconds",
+			Help:      "Duration of product repository operations by method.",
+			Buckets:   prometheus.ExponentialBuckets(0.0005, 2, 14),
+		}, []string{"operation"}),
+		repoErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "repository_operation_errors_total",
+			Help:      "Number of failed product repository operations by method.",
+		}, []string{"operation"}),
+		catalogSize: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "catalog_products",
+			Help:      "Number of products in the catalog, refreshed on every full load.",
+		}),
+		compareSize: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "compare_request_products",
+			Help:      "Number of product IDs per compare request.",
+			Buckets:   []float64{1, 2, 3, 4, 5, 8, 10, 15, 20, 50},
+		}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.repoDuration,
+		m.repoErrors,
+		m.catalogSize,
+		m.compareSize,
+	)
+	return m
+}
+
+// Handler returns the HTTP handler serving the metrics in Prometheus text format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
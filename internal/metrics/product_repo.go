@@ -0,0 +1,97 @@
+package metrics
+
+import (
+	"context"
+	"item-comparison-api/internal/models"
+	"item-comparison-api/internal/repository"
+	"time"
+)
+
+// instrumentedRepo decorates a ProductRepo with timing and error metrics
+type instrumentedRepo struct {
+	next    repository.ProductRepo
+	metrics *Metrics
+}
+
+// InstrumentRepo wraps the given repository so every call is measured
+func InstrumentRepo(next repository.ProductRepo, m *Metrics) repository.ProductRepo {
+	return &instrumentedRepo{next: next, metrics: m}
+}
+
+// observe records the duration and outcome of a repository operation
+func (r *instrumentedRepo) observe(operation string, start time.Time, err error) {
+	r.metrics.repoDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+	if err != nil {
+		r.metrics.repoErrors.WithLabelValues(operation).Inc()
+	}
+}
+
+func (r *instrumentedRepo) LoadProducts(ctx context.Context) ([]models.Product, error) {
+	start := time.Now()
+	products, err := r.next.LoadProducts(ctx)
+	r.observe("LoadProducts", start, err)
+	if err == nil {
+		r.metrics.catalogSize.Set(float64(len(products)))
+	}
+	return products, err
+}
+
+func (r *instrumentedRepo) SaveProducts(ctx context.Context, products []models.Product) error {
+	start := time.Now()
+	err := r.next.SaveProducts(ctx, products)
+	r.observe("SaveProducts", start, err)
+	if err == nil {
+		r.metrics.catalogSize.Add(float64(len(products)))
+	}
+	return err
+}
+
+func (r *instrumentedRepo) UpdateProducts(ctx context.Context, products []models.Product) error {
+	start := time.Now()
+	err := r.next.UpdateProducts(ctx, products)
+	r.observe("UpdateProducts", start, err)
+	if err == nil {
+		// Updates upsert: a product written at version 1 was not stored
+		// before, otherwise the version check would have failed
+		created := 0
+		for _, p := range products {
+			if p.Version == 1 {
+				created++
+			}
+		}
+		r.metrics.catalogSize.Add(float64(created))
+	}
+	return err
+}
+
+func (r *instrumentedRepo) CompareProducts(ctx context.Context, ids []int) ([]models.Product, error) {
+	r.metrics.compareSize.Observe(float64(len(ids)))
+	start := time.Now()
+	products, err := r.next.CompareProducts(ctx, ids)
+	r.observe("CompareProducts", start, err)
+	return products, err
+}
+
+func (r *instrumentedRepo) GetProductByID(ctx context.Context, id int) (*models.Product, error) {
+	start := time.Now()
+	product, err := r.next.GetProductByID(ctx, id)
+	r.observe("GetProductByID", start, err)
+	return product, err
+}
+
+func (r *instrumentedRepo) GetProductByGTIN(ctx context.Context, code string) (*models.Product, error) {
+	start := time.Now()
+	product, err := r.next.GetProductByGTIN(ctx, code)
+	r.observe("GetProductByGTIN", start, err)
+	return product, err
+}
+
+func (r *instrumentedRepo) DeleteByID(ctx context.Context, id int, version int) error {
+	start := time.Now()
+	err := r.next.DeleteByID(ctx, id, version)
+	r.observe("DeleteByID", start, err)
+	if err == nil {
+		r.metrics.catalogSize.Dec()
+	}
+	return err
+}
@@ -0,0 +1,23 @@
+package models
+
+// Types of product changes, the same values as the product event types
+const (
+	ChangeCreated = "product.created"
+	ChangeUpdated = "product.updated"
+	ChangeDeleted = "product.deleted"
+)
+
+// ProductChange is the domain event recorded with a product write. Product
+// is the state after the change, or the deleted state for deletions.
+type ProductChange struct {
+	Type       string  `json:"type"`
+	Product    Product `json:"product"`
+	OccurredAt string  `json:"occurred_at"`
+}
+
+// OutboxEntry holds the changes of one repository write, recorded in the
+// same atomic unit as the write itself
+type OutboxEntry struct {
+	ID      string          `json:"id"`
+	Changes []ProductChange `json:"changes"`
+}
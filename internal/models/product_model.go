@@ -7,9 +7,13 @@ type Product struct {
 	Description    string            `json:"description,omitempty"`
 	Price          float32           `json:"price"`
 	Brand          string            `json:"brand"`
+	GTIN           string            `json:"gtin,omitempty"`
+	MPN            string            `json:"mpn,omitempty"`
 	SellerID       string            `json:"seller_id"`
 	ImageUrl       string            `json:"image_url"`
 	Rating         float32           `json:"rating"`
 	CreatedAt      string            `json:"created_at"`
+	UpdatedAt      string            `json:"updated_at,omitempty"`
+	Version        int               `json:"version"`
 	Specifications map[string]string `json:"specifications"`
 }
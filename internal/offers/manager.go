@@ -0,0 +1,240 @@
+// Package offers keeps the offers sellers make for catalog products. A
+// product holds the canonical name, brand and specifications, while price,
+// stock, condition and shipping differ per seller and live in offers. Offers
+// of deleted products are removed with the changes relayed from the outbox.
+package offers
+
+import (
+	"context"
+	"fmt"
+	"item-comparison-api/internal/dto"
+	"item-comparison-api/internal/models"
+	"item-comparison-api/internal/services"
+	"math"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Manager stores the offers of every product, one file per product
+type Manager struct {
+	dir      string
+	products services.ProductServiceInterface
+
+	mu     sync.Mutex
+	offers map[int][]*Offer
+}
+
+// NewManager creates a Manager keeping its offers in dir and loads the
+// offers saved there
+func NewManager(dir string, products services.ProductServiceInterface) (*Manager, error) {
+	fmt.Printf("[OfferManager][NewManager] Initializing with directory: %s\n", dir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create offers directory: %w", err)
+	}
+	files, err := loadFiles[productOffers](dir)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Manager{
+		dir:      dir,
+		products: products,
+		offers:   make(map[int][]*Offer, len(files)),
+	}
+	count := 0
+	for _, f := range files {
+		m.offers[f.ProductID] = f.Offers
+		count += len(f.Offers)
+	}
+	fmt.Printf("[OfferManager][NewManager] Loaded %d offers for %d products\n", count, len(files))
+	return m, nil
+}
+
+// Put creates or replaces the seller's offer for a product and reports
+// whether it was created
+func (m *Manager) Put(ctx context.Context, productID int, sellerID string, o Offer) (*Offer, bool, error) {
+	fmt.Printf("[OfferManager][Put] Called with productID: %d, sellerID: %s\n", productID, sellerID)
+	if err := validate(&o); err != nil {
+		return nil, false, err
+	}
+
+	// Hold the lock across the product check, so the removal of a deleted
+	// product's offers can't run in between
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, err := m.products.GetProductByID(ctx, productID); err != nil {
+		return nil, false, err
+	}
+
+	now := time.Now().Format(time.RFC3339)
+	o.ProductID = productID
+	o.SellerID = sellerID
+	o.CreatedAt = now
+	o.UpdatedAt = now
+
+	offers := slices.Clone(m.offers[productID])
+	i := slices.IndexFunc(offers, func(existing *Offer) bool { return existing.SellerID == sellerID })
+	created := i < 0
+	if created {
+		offers = append(offers, &o)
+	} else {
+		o.CreatedAt = offers[i].CreatedAt
+		offers[i] = &o
+	}
+	if err := m.save(productID, offers); err != nil {
+		return nil, false, err
+	}
+
+	copied := o
+	return &copied, created, nil
+}
+
+// List returns the offers for a product, best first
+func (m *Manager) List(ctx context.Context, productID int) ([]*Offer, error) {
+	if _, err := m.products.GetProductByID(ctx, productID); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	offers := make([]*Offer, 0, len(m.offers[productID]))
+	for _, o := range m.offers[productID] {
+		copied := *o
+		offers = append(offers, &copied)
+	}
+	sort.Slice(offers, func(i, j int) bool { return offers[i].better(offers[j]) })
+	return offers, nil
+}
+
+// Best returns the best offer in stock for a product, or nil when there is none
+func (m *Manager) Best(productID int) *Offer {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var best *Offer
+	for _, o := range m.offers[productID] {
+		if o.Stock > 0 && (best == nil || o.better(best)) {
+			best = o
+		}
+	}
+	if best == nil {
+		return nil
+	}
+	copied := *best
+	return &copied
+}
+
+// Delete removes the seller's offer for a product
+func (m *Manager) Delete(productID int, sellerID string) error {
+	fmt.Printf("[OfferManager][Delete] Called with productID: %d, sellerID: %s\n", productID, sellerID)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	offers := m.offers[productID]
+	i := slices.IndexFunc(offers, func(o *Offer) bool { return o.SellerID == sellerID })
+	if i < 0 {
+		return fmt.Errorf("%w: product %d has no offer of seller %s", ErrOfferNotFound, productID, sellerID)
+	}
+	return m.save(productID, slices.Delete(slices.Clone(offers), i, i+1))
+}
+
+// Move moves the offers of a product to another product, which happens
+// when the product is merged into the other one. Sellers with an offer for
+// both keep the offer for the other product.
+func (m *Manager) Move(from, to int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	moved := slices.Clone(m.offers[to])
+	for _, o := range m.offers[from] {
+		if slices.ContainsFunc(moved, func(existing *Offer) bool { return existing.SellerID == o.SellerID }) {
+			continue
+		}
+		copied := *o
+		copied.ProductID = to
+		moved = append(moved, &copied)
+	}
+	if err := m.save(to, moved); err != nil {
+		return err
+	}
+	if err := m.save(from, nil); err != nil {
+		return err
+	}
+	fmt.Printf("[OfferManager][Move] Moved the offers of product %d to product %d\n", from, to)
+	return nil
+}
+
+// Name identifies the manager as an outbox sink
+func (m *Manager) Name() string {
+	return "offers"
+}
+
+// Send removes the offers of deleted products
+func (m *Manager) Send(ctx context.Context, events []dto.ProductEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, event := range events {
+		if event.Type != models.ChangeDeleted {
+			continue
+		}
+		if _, ok := m.offers[event.ProductID]; !ok {
+			continue
+		}
+		if err := m.save(event.ProductID, nil); err != nil {
+			return err
+		}
+		fmt.Printf("[OfferManager][Send] Removed the offers of deleted product %d\n", event.ProductID)
+	}
+	return nil
+}
+
+// save stores the offers of a product, removing its file once none are
+// left. Callers hold mu.
+func (m *Manager) save(productID int, offers []*Offer) error {
+	id := strconv.Itoa(productID)
+	if len(offers) == 0 {
+		if err := removeFile(m.dir, id); err != nil {
+			return err
+		}
+		delete(m.offers, productID)
+		return nil
+	}
+	if err := saveFile(m.dir, id, productOffers{ProductID: productID, Offers: offers}); err != nil {
+		return err
+	}
+	m.offers[productID] = offers
+	return nil
+}
+
+// validate checks the price, stock, condition and shipping of an offer. A
+// missing condition means new.
+func validate(o *Offer) error {
+	if o.Price <= 0 || !finite(o.Price) {
+		return fmt.Errorf("%w: price must be a positive number", ErrInvalidOffer)
+	}
+	if o.Stock < 0 {
+		return fmt.Errorf("%w: stock must not be negative", ErrInvalidOffer)
+	}
+	if o.Condition == "" {
+		o.Condition = ConditionNew
+	}
+	if conditionRank(o.Condition) < 0 {
+		return fmt.Errorf("%w: condition %q must be new, refurbished or used", ErrInvalidOffer, o.Condition)
+	}
+	if o.Shipping.Cost < 0 || !finite(o.Shipping.Cost) {
+		return fmt.Errorf("%w: shipping cost must be a non-negative number", ErrInvalidOffer)
+	}
+	if o.Shipping.Days < 0 {
+		return fmt.Errorf("%w: shipping days must not be negative", ErrInvalidOffer)
+	}
+	return nil
+}
+
+// finite reports whether f is neither NaN nor infinite
+func finite(f float32) bool {
+	return !math.IsNaN(float64(f)) && !math.IsInf(float64(f), 0)
+}
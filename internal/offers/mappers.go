@@ -0,0 +1,37 @@
+package offers
+
+import "item-comparison-api/internal/dto"
+
+// RequestToOffer converts an OfferRequest DTO to an Offer
+func RequestToOffer(req dto.OfferRequest) Offer {
+	return Offer{
+		Price:     req.Price,
+		Stock:     req.Stock,
+		Condition: req.Condition,
+		Shipping:  Shipping{Cost: req.Shipping.Cost, Days: req.Shipping.Days},
+	}
+}
+
+// OfferToResponse converts an Offer to an OfferResponse DTO
+func OfferToResponse(o *Offer) dto.OfferResponse {
+	return dto.OfferResponse{
+		ProductID:  o.ProductID,
+		SellerID:   o.SellerID,
+		Price:      o.Price,
+		Stock:      o.Stock,
+		Condition:  o.Condition,
+		Shipping:   dto.OfferShipping{Cost: o.Shipping.Cost, Days: o.Shipping.Days},
+		TotalPrice: o.Total(),
+		CreatedAt:  o.CreatedAt,
+		UpdatedAt:  o.UpdatedAt,
+	}
+}
+
+// OffersToResponse converts offers to OfferResponse DTOs
+func OffersToResponse(offers []*Offer) []dto.OfferResponse {
+	responses := make([]dto.OfferResponse, 0, len(offers))
+	for _, o := range offers {
+		responses = append(responses, OfferToResponse(o))
+	}
+	return responses
+}
@@ -0,0 +1,77 @@
+package offers
+
+import (
+	"errors"
+	"slices"
+)
+
+// Offer conditions, from the most to the least preferred
+const (
+	ConditionNew         = "new"
+	ConditionRefurbished = "refurbished"
+	ConditionUsed        = "used"
+)
+
+// conditions lists the valid conditions in order of preference
+var conditions = []string{ConditionNew, ConditionRefurbished, ConditionUsed}
+
+var (
+	// ErrOfferNotFound is returned when a seller has no offer for a product
+	ErrOfferNotFound = errors.New("offer not found")
+	// ErrInvalidOffer is returned for offers with an invalid price, stock,
+	// condition or shipping
+	ErrInvalidOffer = errors.New("invalid offer")
+)
+
+// Offer is a seller's offer for a catalog product. A seller has at most one
+// offer per product.
+type Offer struct {
+	ProductID int      `json:"product_id"`
+	SellerID  string   `json:"seller_id"`
+	Price     float32  `json:"price"`
+	Stock     int      `json:"stock"`
+	Condition string   `json:"condition"`
+	Shipping  Shipping `json:"shipping"`
+	CreatedAt string   `json:"created_at"`
+	UpdatedAt string   `json:"updated_at"`
+}
+
+// Shipping is the cost and delivery time of an offer
+type Shipping struct {
+	Cost float32 `json:"cost"`
+	Days int     `json:"days"`
+}
+
+// Total returns the price including shipping
+func (o *Offer) Total() float32 {
+	return o.Price + o.Shipping.Cost
+}
+
+// better reports whether o is preferred over other: in stock first, then
+// the lower total, the better condition and the faster shipping
+func (o *Offer) better(other *Offer) bool {
+	if (o.Stock > 0) != (other.Stock > 0) {
+		return o.Stock > 0
+	}
+	if o.Total() != other.Total() {
+		return o.Total() < other.Total()
+	}
+	if a, b := conditionRank(o.Condition), conditionRank(other.Condition); a != b {
+		return a < b
+	}
+	if o.Shipping.Days != other.Shipping.Days {
+		return o.Shipping.Days < other.Shipping.Days
+	}
+	return o.SellerID < other.SellerID
+}
+
+// conditionRank returns the position of a condition in order of preference
+func conditionRank(condition string) int {
+	return slices.Index(conditions, condition)
+}
+
+// productOffers is the content of a product's offer file
+type productOffers struct {
+	ProductID int      `json:"product_id"`
+	Offers    []*Offer `json:"offers"`
+}
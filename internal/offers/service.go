@@ -0,0 +1,34 @@
+package offers
+
+import (
+	"context"
+	"item-comparison-api/internal/dto"
+	"item-comparison-api/internal/services"
+)
+
+// bestOfferService decorates a ProductServiceInterface, adding the best
+// offer of every compared product. Other calls pass through.
+type bestOfferService struct {
+	services.ProductServiceInterface
+	manager *Manager
+}
+
+// BestOfferService wraps the given service so its comparisons show the best
+// offer per product, whichever API they come from
+func BestOfferService(next services.ProductServiceInterface, manager *Manager) services.ProductServiceInterface {
+	return &bestOfferService{ProductServiceInterface: next, manager: manager}
+}
+
+func (s *bestOfferService) CompareProducts(ctx context.Context, ids []int) ([]dto.ProductResponse, error) {
+	products, err := s.ProductServiceInterface.CompareProducts(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range products {
+		if best := s.manager.Best(products[i].ID); best != nil {
+			response := OfferToResponse(best)
+			products[i].BestOffer = &response
+		}
+	}
+	return products, nil
+}
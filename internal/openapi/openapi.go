@@ -0,0 +1,46 @@
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed openapi.yaml
+var specYAML []byte
+
+//go:embed docs.html
+var docsHTML []byte
+
+// spec is the parsed OpenAPI document, specJSON its JSON encoding
+var (
+	spec     map[string]any
+	specJSON []byte
+)
+
+func init() {
+	// The spec is embedded, so a parse failure is a build-time mistake
+	if err := yaml.Unmarshal(specYAML, &spec); err != nil {
+		panic(fmt.Sprintf("openapi: invalid embedded spec: %v", err))
+	}
+	var err error
+	if specJSON, err = json.Marshal(spec); err != nil {
+		panic(fmt.Sprintf("openapi: failed to encode spec as JSON: %v", err))
+	}
+}
+
+// SpecHandler serves the OpenAPI document as JSON
+func SpecHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(specJSON)
+}
+
+// DocsHandler serves the bundled interactive documentation page, which
+// renders the document served by SpecHandler
+func DocsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(docsHTML)
+}
@@ -0,0 +1,170 @@
+package openapi
+
+import (
+	"errors"
+	"fmt"
+	"item-comparison-api/internal/dto"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// schemaTypes binds each component schema to the DTO it documents
+var schemaTypes = map[string]reflect.Type{
+	"ProductRequest":          reflect.TypeOf(dto.ProductRequest{}),
+	"ProductResponse":         reflect.TypeOf(dto.ProductResponse{}),
+	"CompareRequest":          reflect.TypeOf(dto.CompareRequest{}),
+	"BulkResponse":            reflect.TypeOf(dto.BulkResponse{}),
+	"BulkItemResult":          reflect.TypeOf(dto.BulkItemResult{}),
+	"ImportJobResponse":       reflect.TypeOf(dto.ImportJobResponse{}),
+	"ImportRowError":          reflect.TypeOf(dto.ImportRowError{}),
+	"ProductEvent":            reflect.TypeOf(dto.ProductEvent{}),
+	"WebhookRequest":          reflect.TypeOf(dto.WebhookRequest{}),
+	"WebhookFilter":           reflect.TypeOf(dto.WebhookFilter{}),
+	"WebhookResponse":         reflect.TypeOf(dto.WebhookResponse{}),
+	"WebhookDeliveryResponse": reflect.TypeOf(dto.WebhookDeliveryResponse{}),
+	"WebhookAttempt":          reflect.TypeOf(dto.WebhookAttempt{}),
+	"AlertRequest":            reflect.TypeOf(dto.AlertRequest{}),
+	"AlertChannel":            reflect.TypeOf(dto.AlertChannel{}),
+	"AlertResponse":           reflect.TypeOf(dto.AlertResponse{}),
+	"AlertNotification":       reflect.TypeOf(dto.AlertNotification{}),
+	"ComparisonRequest":       reflect.TypeOf(dto.ComparisonRequest{}),
+	"ComparisonResponse":      reflect.TypeOf(dto.ComparisonResponse{}),
+	"ProductDiff":             reflect.TypeOf(dto.ProductDiff{}),
+	"FieldDiff":               reflect.TypeOf(dto.FieldDiff{}),
+	"SimilarProduct":          reflect.TypeOf(dto.SimilarProduct{}),
+	"SimilarityBreakdown":     reflect.TypeOf(dto.SimilarityBreakdown{}),
+	"ComparedProduct":         reflect.TypeOf(dto.ComparedProduct{}),
+	"PopularComparison":       reflect.TypeOf(dto.PopularComparison{}),
+	"OfferRequest":            reflect.TypeOf(dto.OfferRequest{}),
+	"OfferShipping":           reflect.TypeOf(dto.OfferShipping{}),
+	"OfferResponse":           reflect.TypeOf(dto.OfferResponse{}),
+	"DuplicateCandidate":      reflect.TypeOf(dto.DuplicateCandidate{}),
+	"DuplicateBreakdown":      reflect.TypeOf(dto.DuplicateBreakdown{}),
+	"DuplicateScanResponse":   reflect.TypeOf(dto.DuplicateScanResponse{}),
+	"MergeRequest":            reflect.TypeOf(dto.MergeRequest{}),
+	"MergeResponse":           reflect.TypeOf(dto.MergeResponse{}),
+}
+
+// undocumented lists the routes that serve tooling rather than the API
+var undocumented = map[string]bool{
+	"GET /metrics":      true,
+	"GET /openapi.json": true,
+	"GET /docs":         true,
+}
+
+// chi path parameters may carry a regexp, e.g. {id:[0-9]+}
+var paramPattern = regexp.MustCompile(`\{([^}:]+)(:[^}]*)?\}`)
+
+// Verify checks that the spec documents exactly the routes registered on
+// the router and that the DTO schemas list exactly the DTOs' JSON fields.
+// It returns every mismatch found, the openapi tests fail on any of them.
+func Verify(routes chi.Routes) error {
+	var errs []error
+
+	// Collect operations from the router
+	registered := make(map[string]bool)
+	err := chi.Walk(routes, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		route = paramPattern.ReplaceAllString(route, "{$1}")
+		if len(route) > 1 {
+			route = strings.TrimSuffix(route, "/")
+		}
+		key := method + " " + route
+		if !undocumented[key] {
+			registered[key] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to walk routes: %w", err)
+	}
+
+	// Collect operations from the spec
+	documented := make(map[string]bool)
+	paths, _ := spec["paths"].(map[string]any)
+	for path, item := range paths {
+		operations, _ := item.(map[string]any)
+		for method := range operations {
+			switch method {
+			case "get", "put", "post", "delete", "patch", "head", "options":
+				documented[strings.ToUpper(method)+" "+path] = true
+			}
+		}
+	}
+
+	for _, key := range sortedKeys(registered) {
+		if !documented[key] {
+			errs = append(errs, fmt.Errorf("route %s is not documented in openapi.yaml", key))
+		}
+	}
+	for _, key := range sortedKeys(documented) {
+		if !registered[key] {
+			errs = append(errs, fmt.Errorf("openapi.yaml documents %s but no such route is registered", key))
+		}
+	}
+
+	// Compare DTO fields with schema properties
+	components, _ := spec["components"].(map[string]any)
+	schemas, _ := components["schemas"].(map[string]any)
+	for _, name := range sortedKeys(schemaTypes) {
+		schema, ok := schemas[name].(map[string]any)
+		if !ok {
+			errs = append(errs, fmt.Errorf("schema %s is missing from openapi.yaml", name))
+			continue
+		}
+		properties, _ := schema["properties"].(map[string]any)
+		fields := jsonFields(schemaTypes[name])
+		for _, field := range sortedKeys(fields) {
+			if _, ok := properties[field]; !ok {
+				errs = append(errs, fmt.Errorf("schema %s is missing property %q of %s", name, field, schemaTypes[name]))
+			}
+		}
+		for _, property := range sortedKeys(properties) {
+			if !fields[property] {
+				errs = append(errs, fmt.Errorf("schema %s documents property %q that %s does not have", name, property, schemaTypes[name]))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// jsonFields returns the JSON names of the exported fields of a struct type
+func jsonFields(t reflect.Type) map[string]bool {
+	fields := make(map[string]bool)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			for name := range jsonFields(field.Type) {
+				fields[name] = true
+			}
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		switch name {
+		case "-":
+			continue
+		case "":
+			name = field.Name
+		}
+		fields[name] = true
+	}
+	return fields
+}
+
+// sortedKeys returns the keys of m in order, so errors are reported stably
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
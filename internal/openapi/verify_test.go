@@ -0,0 +1,19 @@
+package openapi_test
+
+import (
+	"testing"
+
+	"item-comparison-api/internal"
+	"item-comparison-api/internal/config"
+	"item-comparison-api/internal/metrics"
+	"item-comparison-api/internal/openapi"
+)
+
+// TestSpecMatchesRouter fails when a route or DTO field drifts from the
+// OpenAPI document. Only the routes are needed, so the handlers stay nil.
+func TestSpecMatchesRouter(t *testing.T) {
+	r := internal.SetupRouter(config.Default(), nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, metrics.New())
+	if err := openapi.Verify(r); err != nil {
+		t.Fatalf("OpenAPI document is out of date:\n%v", err)
+	}
+}
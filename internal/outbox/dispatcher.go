@@ -0,0 +1,229 @@
+// Package outbox relays the product changes recorded by the storage backend
+// to sinks such as the in-process event bus, a file or a message broker.
+// Every sink keeps its own position, and an entry is only acknowledged once
+// every sink has it, so each sink gets every change at least once.
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"item-comparison-api/internal/dto"
+	"item-comparison-api/internal/models"
+	"item-comparison-api/internal/repository"
+	"item-comparison-api/internal/services"
+	"sync"
+	"time"
+)
+
+// drainTimeout bounds the final relay of entries recorded during shutdown
+const drainTimeout = 10 * time.Second
+
+// Options tune the relay. Entries are read BatchSize at a time. The outbox
+// is checked every PollInterval even without notification, and a failing
+// sink is retried after RetryBase, doubling up to RetryMax.
+type Options struct {
+	BatchSize    int
+	PollInterval time.Duration
+	RetryBase    time.Duration
+	RetryMax     time.Duration
+}
+
+// Dispatcher relays outbox entries to the sinks, each in its own goroutine,
+// so a failing sink doesn't hold up the others
+type Dispatcher struct {
+	outbox repository.Outbox
+	sinks  []Sink
+	opts   Options
+
+	mu      sync.Mutex
+	cursors []string
+	acked   string
+	wakes   []chan struct{}
+	wg      sync.WaitGroup
+}
+
+// NewDispatcher creates a Dispatcher relaying the entries of o to the sinks
+func NewDispatcher(o repository.Outbox, opts Options, sinks ...Sink) *Dispatcher {
+	d := &Dispatcher{
+		outbox:  o,
+		sinks:   sinks,
+		opts:    opts,
+		cursors: make([]string, len(sinks)),
+		wakes:   make([]chan struct{}, len(sinks)),
+	}
+	for i := range d.wakes {
+		d.wakes[i] = make(chan struct{}, 1)
+	}
+	return d
+}
+
+// Start launches a relay per sink. Once ctx is canceled every relay sends the
+// entries recorded so far one last time and stops.
+func (d *Dispatcher) Start(ctx context.Context) {
+	for i, sink := range d.sinks {
+		fmt.Printf("[Dispatcher][Start] Relaying outbox to sink %s\n", sink.Name())
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			d.run(ctx, i)
+		}()
+	}
+
+	// Fan the outbox notifications out to the relays
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-d.outbox.ChangeNotify():
+				for _, wake := range d.wakes {
+					signal(wake)
+				}
+			}
+		}
+	}()
+}
+
+// Wait blocks until every relay has stopped
+func (d *Dispatcher) Wait() {
+	d.wg.Wait()
+}
+
+// run relays entries to one sink until ctx is canceled
+func (d *Dispatcher) run(ctx context.Context, i int) {
+	sink := d.sinks[i]
+	failures := 0
+	for {
+		n, err := d.relay(ctx, i)
+		wait := d.opts.PollInterval
+		switch {
+		case err != nil && ctx.Err() == nil:
+			failures++
+			wait = d.backoff(failures)
+			fmt.Printf("[Dispatcher][run][ERROR] Sink %s failed %d times, retrying in %v: %v\n", sink.Name(), failures, wait, err)
+		case n > 0:
+			// More entries may be waiting
+			failures = 0
+			continue
+		default:
+			failures = 0
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			d.drain(ctx, i)
+			return
+		case <-d.wakes[i]:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
+
+// drain relays what is left once the dispatcher is stopping, so the changes
+// of the last requests aren't held back until the next start
+func (d *Dispatcher) drain(ctx context.Context, i int) {
+	drainCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), drainTimeout)
+	defer cancel()
+	for {
+		n, err := d.relay(drainCtx, i)
+		if err != nil {
+			fmt.Printf("[Dispatcher][drain][ERROR] Sink %s: %v, the rest is relayed after the next start\n", d.sinks[i].Name(), err)
+			return
+		}
+		if n == 0 {
+			return
+		}
+	}
+}
+
+// relay sends the next batch of entries to a sink and returns how many
+// entries it sent
+func (d *Dispatcher) relay(ctx context.Context, i int) (int, error) {
+	d.mu.Lock()
+	cursor := d.cursors[i]
+	d.mu.Unlock()
+
+	entries, err := d.outbox.PendingChanges(ctx, cursor, d.opts.BatchSize)
+	if err != nil || len(entries) == 0 {
+		return 0, err
+	}
+
+	var events []dto.ProductEvent
+	for _, entry := range entries {
+		events = append(events, eventsFromEntry(entry)...)
+	}
+	if err := d.sinks[i].Send(ctx, events); err != nil {
+		return 0, err
+	}
+
+	d.mu.Lock()
+	d.cursors[i] = entries[len(entries)-1].ID
+	d.mu.Unlock()
+	d.ack(ctx)
+	return len(entries), nil
+}
+
+// ack acknowledges the entries every sink has received
+func (d *Dispatcher) ack(ctx context.Context) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	upTo := d.cursors[0]
+	for _, cursor := range d.cursors[1:] {
+		upTo = min(upTo, cursor)
+	}
+	if upTo <= d.acked {
+		return
+	}
+	if err := d.outbox.AckChanges(ctx, upTo); err != nil {
+		// The entries are sent again after a restart
+		fmt.Printf("[Dispatcher][ack][ERROR] %v\n", err)
+		return
+	}
+	d.acked = upTo
+}
+
+// backoff returns the wait after the given number of consecutive failures
+func (d *Dispatcher) backoff(failures int) time.Duration {
+	wait := d.opts.RetryBase
+	for i := 1; i < failures && wait < d.opts.RetryMax; i++ {
+		wait *= 2
+	}
+	return min(wait, d.opts.RetryMax)
+}
+
+// eventsFromEntry converts the changes of an entry to events. Their IDs are
+// derived from the entry, so a sink sees the same ID when an entry is sent again.
+func eventsFromEntry(entry models.OutboxEntry) []dto.ProductEvent {
+	events := make([]dto.ProductEvent, 0, len(entry.Changes))
+	for i, change := range entry.Changes {
+		event := dto.ProductEvent{
+			ID:         fmt.Sprintf("%s-%d", entry.ID, i),
+			Type:       change.Type,
+			ProductID:  change.Product.ID,
+			SellerID:   change.Product.SellerID,
+			Category:   dto.Specifications(change.Product.Specifications).Category(),
+			Version:    change.Product.Version,
+			OccurredAt: change.OccurredAt,
+		}
+		if change.Type != models.ChangeDeleted {
+			response := services.ProductToResponse(change.Product)
+			event.Product = &response
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+// signal wakes up a relay without blocking
+func signal(wake chan struct{}) {
+	select {
+	case wake <- struct{}{}:
+	default:
+	}
+}
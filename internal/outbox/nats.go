@@ -0,0 +1,65 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// natsTimeout bounds each publish when ctx has no deadline
+const natsTimeout = 10 * time.Second
+
+// NATSProducer publishes to NATS JetStream. A publish returns once the stream
+// acknowledged storing every message, so the messages survive a broker
+// restart. The subject must be bound to a stream. Message IDs are sent as
+// Nats-Msg-Id, so the stream drops messages sent again within its duplicate
+// window. tls:// URLs connect with TLS.
+type NATSProducer struct {
+	conn *nats.Conn
+	js   jetstream.JetStream
+}
+
+// NewNATSProducer creates a producer for a nats:// or tls://[user:password@]host:port
+// URL. The connection is made in the background and kept up, publishes fail
+// while it is down.
+func NewNATSProducer(url string) (*NATSProducer, error) {
+	conn, err := nats.Connect(url,
+		nats.Name("item-comparison-api"),
+		nats.RetryOnFailedConnect(true),
+		nats.MaxReconnects(-1),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS at %s: %w", url, err)
+	}
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to create JetStream context: %w", err)
+	}
+	return &NATSProducer{conn: conn, js: js}, nil
+}
+
+// Publish sends the messages in order and waits for the stream to
+// acknowledge each one
+func (p *NATSProducer) Publish(ctx context.Context, subject string, messages []Message) error {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, natsTimeout)
+		defer cancel()
+	}
+	for _, message := range messages {
+		msg := &nats.Msg{Subject: subject, Data: message.Data}
+		if _, err := p.js.PublishMsg(ctx, msg, jetstream.WithMsgID(message.ID)); err != nil {
+			return fmt.Errorf("failed to publish message %s to NATS: %w", message.ID, err)
+		}
+	}
+	return nil
+}
+
+// Close flushes and closes the connection
+func (p *NATSProducer) Close() error {
+	return p.conn.Drain()
+}
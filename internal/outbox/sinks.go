@@ -0,0 +1,134 @@
+package outbox
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"item-comparison-api/internal/dto"
+	"os"
+)
+
+// Sink receives the relayed events in recording order. A batch may be sent
+// again after a failure or restart, so sinks see every event at least once.
+type Sink interface {
+	Name() string
+	Send(ctx context.Context, events []dto.ProductEvent) error
+}
+
+// Publisher is an in-process event bus, like the events broker
+type Publisher interface {
+	Publish(dto.ProductEvent)
+}
+
+// BusSink hands events to the in-process bus feeding the event stream and webhooks
+type BusSink struct {
+	bus Publisher
+}
+
+// NewBusSink creates a sink publishing to bus
+func NewBusSink(bus Publisher) *BusSink {
+	return &BusSink{bus: bus}
+}
+
+// Name identifies the sink in logs
+func (s *BusSink) Name() string {
+	return "bus"
+}
+
+// Send publishes every event, which never fails
+func (s *BusSink) Send(ctx context.Context, events []dto.ProductEvent) error {
+	for _, event := range events {
+		s.bus.Publish(event)
+	}
+	return nil
+}
+
+// FileSink appends events to a file as NDJSON, one event per line
+type FileSink struct {
+	path string
+}
+
+// NewFileSink creates a sink appending to the file at path
+func NewFileSink(path string) *FileSink {
+	return &FileSink{path: path}
+}
+
+// Name identifies the sink in logs
+func (s *FileSink) Name() string {
+	return "file"
+}
+
+// Send appends the events and syncs the file before reporting success
+func (s *FileSink) Send(ctx context.Context, events []dto.ProductEvent) error {
+	file, err := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open event file %s: %w", s.path, err)
+	}
+	defer file.Close()
+
+	w := bufio.NewWriter(file)
+	enc := json.NewEncoder(w)
+	for _, event := range events {
+		if err := enc.Encode(event); err != nil {
+			return fmt.Errorf("failed to write event file %s: %w", s.path, err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to write event file %s: %w", s.path, err)
+	}
+	if err := file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync event file %s: %w", s.path, err)
+	}
+	return nil
+}
+
+// Producer publishes messages to a message broker. Adapters for brokers such
+// as NATS, Kafka or RabbitMQ implement it to be used with BrokerSink.
+type Producer interface {
+	// Publish sends the messages to the topic and returns once the broker
+	// has stored them
+	Publish(ctx context.Context, topic string, messages []Message) error
+	Close() error
+}
+
+// Message is a message for a broker. ID is the event ID, which stays the
+// same when an event is sent again, so brokers can drop the duplicate.
+type Message struct {
+	ID   string
+	Data []byte
+}
+
+// BrokerSink publishes events as JSON messages through a Producer
+type BrokerSink struct {
+	producer Producer
+	topic    string
+}
+
+// NewBrokerSink creates a sink publishing to topic through producer
+func NewBrokerSink(producer Producer, topic string) *BrokerSink {
+	return &BrokerSink{producer: producer, topic: topic}
+}
+
+// Name identifies the sink in logs
+func (s *BrokerSink) Name() string {
+	return "broker:" + s.topic
+}
+
+// Send publishes one message per event
+func (s *BrokerSink) Send(ctx context.Context, events []dto.ProductEvent) error {
+	messages := make([]Message, 0, len(events))
+	for _, event := range events {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		messages = append(messages, Message{ID: event.ID, Data: data})
+	}
+	return s.producer.Publish(ctx, s.topic, messages)
+}
+
+// Close closes the producer, once nothing is sent anymore
+func (s *BrokerSink) Close() error {
+	return s.producer.Close()
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"fmt"
+	"item-comparison-api/internal/gtin"
+	"item-comparison-api/internal/models"
+	"strings"
+)
+
+// identifierIndex maps the identifiers of stored products to their IDs, so
+// writes can keep identifiers unique and products can be found by GTIN. A
+// GTIN is unique across the catalog, an MPN per brand.
+type identifierIndex struct {
+	owners map[string]int
+	keys   map[int][]identifier
+}
+
+// identifier is the index key of a product identifier, kept with the value
+// the product was written with for error messages
+type identifier struct {
+	key   string
+	label string
+}
+
+func newIdentifierIndex() *identifierIndex {
+	return &identifierIndex{owners: make(map[string]int), keys: make(map[int][]identifier)}
+}
+
+// check returns ErrAlreadyExists when a product takes an identifier another
+// product holds, in the index or earlier in the batch
+func (x *identifierIndex) check(products []models.Product) error {
+	batch := make(map[string]int)
+	for _, p := range products {
+		for _, id := range identifiers(p) {
+			owner, ok := batch[id.key]
+			if !ok {
+				owner, ok = x.owners[id.key]
+			}
+			if ok && owner != p.ID {
+				return fmt.Errorf("%s of product with ID %d %w on product with ID %d", id.label, p.ID, ErrAlreadyExists, owner)
+			}
+			batch[id.key] = p.ID
+		}
+	}
+	return nil
+}
+
+// apply updates the index with the changes of a write
+func (x *identifierIndex) apply(changes []models.ProductChange) {
+	for _, change := range changes {
+		x.remove(change.Product.ID)
+		if change.Type != models.ChangeDeleted {
+			x.add(change.Product)
+		}
+	}
+}
+
+func (x *identifierIndex) add(p models.Product) {
+	ids := identifiers(p)
+	for _, id := range ids {
+		x.owners[id.key] = p.ID
+	}
+	if len(ids) > 0 {
+		x.keys[p.ID] = ids
+	}
+}
+
+func (x *identifierIndex) remove(productID int) {
+	for _, id := range x.keys[productID] {
+		if x.owners[id.key] == productID {
+			delete(x.owners, id.key)
+		}
+	}
+	delete(x.keys, productID)
+}
+
+// lookupGTIN returns the ID of the product with the GTIN, in any of its forms
+func (x *identifierIndex) lookupGTIN(code string) (int, bool) {
+	normalized, err := gtin.Normalize(code)
+	if err != nil {
+		return 0, false
+	}
+	id, ok := x.owners[gtinKey(normalized)]
+	return id, ok
+}
+
+// identifiers returns the index keys of a product. GTINs are compared in
+// their 14 digit form, MPNs case-insensitively within the brand.
+func identifiers(p models.Product) []identifier {
+	var ids []identifier
+	if normalized, err := gtin.Normalize(p.GTIN); err == nil {
+		ids = append(ids, identifier{key: gtinKey(normalized), label: "GTIN " + p.GTIN})
+	}
+	if p.MPN != "" {
+		key := "mpn:" + strings.ToLower(strings.TrimSpace(p.Brand)) + "\x00" + strings.ToLower(p.MPN)
+		ids = append(ids, identifier{key: key, label: fmt.Sprintf("MPN %s of brand %q", p.MPN, p.Brand)})
+	}
+	return ids
+}
+
+func gtinKey(normalized string) string {
+	return "gtin:" + normalized
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"item-comparison-api/internal/models"
+	"time"
+)
+
+// Outbox is implemented by storage backends that record an entry in the same
+// atomic unit as every product write. Entries stay recorded until they are
+// acknowledged, so a relay that stops before acknowledging sends them again.
+type Outbox interface {
+	// PendingChanges returns up to limit entries recorded after the entry
+	// with ID after, oldest first. An empty after starts at the oldest entry.
+	PendingChanges(ctx context.Context, after string, limit int) ([]models.OutboxEntry, error)
+	// AckChanges forgets the entries up to and including the entry with ID upTo
+	AckChanges(ctx context.Context, upTo string) error
+	// ChangeNotify is signaled after new entries are recorded
+	ChangeNotify() <-chan struct{}
+}
+
+// newChanges describes a write of products as changes of one type
+func newChanges(changeType string, products ...models.Product) []models.ProductChange {
+	occurredAt := time.Now().Format(time.RFC3339)
+	changes := make([]models.ProductChange, 0, len(products))
+	for _, p := range products {
+		changes = append(changes, models.ProductChange{Type: changeType, Product: p, OccurredAt: occurredAt})
+	}
+	return changes
+}
+
+// updateChanges describes an update, where the products that were not
+// stored before the write were created
+func updateChanges(products []models.Product, existed map[int]bool) []models.ProductChange {
+	changes := newChanges(models.ChangeUpdated, products...)
+	for i := range changes {
+		if !existed[changes[i].Product.ID] {
+			changes[i].Type = models.ChangeCreated
+		}
+	}
+	return changes
+}
+
+// nextEntryID returns the ID of the entry after the one with sequence number
+// last. IDs are zero-padded nanosecond timestamps, so they sort in recording
+// order and don't repeat after a restart with an empty outbox.
+func nextEntryID(last uint64) (string, uint64) {
+	seq := max(last+1, uint64(time.Now().UnixNano()))
+	return fmt.Sprintf("%020d", seq), seq
+}
+
+// signal notifies a waiting relay without blocking
+func signal(notify chan struct{}) {
+	select {
+	case notify <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"item-comparison-api/internal/models"
+)
+
+// TestUpdateRecordsCreatedByExistence checks that an update reports the
+// products it added as created and the ones it replaced as updated, whatever
+// their version. Products saved before versioning reach version 1 with their
+// first update.
+func TestUpdateRecordsCreatedByExistence(t *testing.T) {
+	type repo interface {
+		ProductRepo
+		Outbox
+	}
+	repos := []struct {
+		name string
+		new  func(t *testing.T) repo
+	}{
+		{"memory", func(t *testing.T) repo { return NewProductRepoMemory() }},
+		{"json", func(t *testing.T) repo { return NewProductRepo(t.TempDir()) }},
+	}
+	tests := []struct {
+		name     string
+		stored   *models.Product
+		wantType string
+	}{
+		{"missing product", nil, models.ChangeCreated},
+		{"product at version 1", &models.Product{ID: 1, Name: "Phone", Price: 10, SellerID: "s1", Version: 1}, models.ChangeUpdated},
+		{"product saved before versioning", &models.Product{ID: 1, Name: "Phone", Price: 10, SellerID: "s1"}, models.ChangeUpdated},
+	}
+
+	for _, repo := range repos {
+		for _, tt := range tests {
+			t.Run(repo.name+" "+tt.name, func(t *testing.T) {
+				ctx := context.Background()
+				r := repo.new(t)
+				update := models.Product{ID: 1, Name: "Phone", Price: 20, SellerID: "s1", Version: 1}
+				if tt.stored != nil {
+					if err := r.SaveProducts(ctx, []models.Product{*tt.stored}); err != nil {
+						t.Fatal(err)
+					}
+					stored, err := r.GetProductByID(ctx, 1)
+					if err != nil {
+						t.Fatal(err)
+					}
+					update.Version = stored.Version + 1
+				}
+				if err := r.UpdateProducts(ctx, []models.Product{update}); err != nil {
+					t.Fatal(err)
+				}
+
+				entries, err := r.PendingChanges(ctx, "", 10)
+				if err != nil {
+					t.Fatal(err)
+				}
+				last := entries[len(entries)-1]
+				if len(last.Changes) != 1 || last.Changes[0].Type != tt.wantType {
+					t.Errorf("update recorded %v, want one %s change", last.Changes, tt.wantType)
+				}
+			})
+		}
+	}
+}
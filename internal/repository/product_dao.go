@@ -1,15 +1,47 @@
 package repository
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"item-comparison-api/internal/models"
 	"os"
 	"path/filepath"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("item-comparison-api/internal/repository")
+
+// Outbox entries live in the outbox subdirectory of the storage path. A write
+// first records its entry as <id>.commit, which makes it durable, then writes
+// the product files and renames the entry to <id>.json, which makes it
+// visible to the relay. A committed entry left behind by a crash is applied
+// again before the next read or write, so a write and its entry never get
+// separated.
+const (
+	outboxDir    = "outbox"
+	committedExt = ".commit"
+	appliedExt   = ".json"
 )
 
 type ProductRepoJson struct {
 	storagePath string
+	// mu serializes writes so version checks and the writes they guard
+	// happen as one step
+	mu sync.Mutex
+	// lastSeq is the sequence number of the newest outbox entry. recovered
+	// is false until committed entries left behind have been applied.
+	lastSeq   uint64
+	recovered bool
+	notify    chan struct{}
+	// ids indexes the identifiers of the stored products, it is built by
+	// recover
+	ids *identifierIndex
 }
 
 // NewProductRepo creates a new instance of ProductRepoJson
@@ -21,12 +53,17 @@ func NewProductRepo(path string) *ProductRepoJson {
 
 	return &ProductRepoJson{
 		storagePath: path,
+		notify:      make(chan struct{}, 1),
 	}
 }
 
 // LoadProducts loads products from a JSON file into a slice of Product structs.
-func (r *ProductRepoJson) LoadProducts() ([]models.Product, error) {
+func (r *ProductRepoJson) LoadProducts(ctx context.Context) ([]models.Product, error) {
 	fmt.Printf("[ProductRepository][LoadProducts] Loading all products from directory: %s\n", r.storagePath)
+	if err := r.recoverForRead(); err != nil {
+		fmt.Printf("[ProductRepository][LoadProducts][ERROR] %v\n", err)
+		return nil, err
+	}
 	dir := r.storagePath
 	// Ensure the directory exists before reading
 	entries, err := os.ReadDir(dir)
@@ -42,6 +79,12 @@ func (r *ProductRepoJson) LoadProducts() ([]models.Product, error) {
 	// Read all JSON files in the directory
 	var products []models.Product
 	for _, entry := range entries {
+		// Stop scanning if the caller gave up or the deadline passed
+		if err := ctx.Err(); err != nil {
+			fmt.Printf("[ProductRepository][LoadProducts][ERROR] Aborted after %d products: %v\n", len(products), err)
+			return nil, err
+		}
+
 		if entry.IsDir() {
 			continue
 		}
@@ -66,6 +109,7 @@ func (r *ProductRepoJson) LoadProducts() ([]models.Product, error) {
 			fmt.Printf("[ProductRepository][LoadProducts][ERROR] Failed to unmarshal file: %s, error: %v\n", filePath, err)
 			return nil, fmt.Errorf("failed to unmarshal file %s: %w", filePath, err)
 		}
+		upgradeLegacy(&product)
 
 		// Append the product to the slice
 		products = append(products, product)
@@ -76,74 +120,97 @@ func (r *ProductRepoJson) LoadProducts() ([]models.Product, error) {
 }
 
 // SaveProducts saves a slice of Product structs to a JSON file.
-func (r *ProductRepoJson) SaveProducts(products []models.Product) error {
+func (r *ProductRepoJson) SaveProducts(ctx context.Context, products []models.Product) error {
 	fmt.Printf("[ProductRepository][SaveProducts] Saving %d products\n", len(products))
 	dir := r.storagePath
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
-	for _, p := range products {
-		// Create the file name based on product ID
-		fileName := fmt.Sprintf("%s/%d.json", dir, p.ID)
-		fmt.Printf("[ProductRepository][SaveProducts] Saving product ID: %d to file: %s\n", p.ID, fileName)
+	if err := r.recover(); err != nil {
+		fmt.Printf("[ProductRepository][SaveProducts][ERROR] %v\n", err)
+		return err
+	}
 
-		// Check if file already exists to prevent overwriting
-		if _, err := os.Stat(fileName); err == nil {
-			fmt.Printf("[ProductRepository][SaveProducts][ERROR] Product with ID %d already exists\n", p.ID)
-			return fmt.Errorf("product with ID %d already exists", p.ID)
+	// Check every ID first so a failed batch leaves nothing behind
+	seen := make(map[int]bool, len(products))
+	for _, p := range products {
+		if err := ctx.Err(); err != nil {
+			fmt.Printf("[ProductRepository][SaveProducts][ERROR] Aborted before product ID %d: %v\n", p.ID, err)
+			return err
 		}
 
-		// Marshal the product to JSON
-		bytes, err := json.MarshalIndent(p, "", "  ")
-		if err != nil {
-			fmt.Printf("[ProductRepository][SaveProducts][ERROR] Failed to marshal product ID: %d, error: %v\n", p.ID, err)
-			return fmt.Errorf("failed to marshal product %s: %w", fileName, err)
+		// Check if file already exists, or comes earlier in the batch, to prevent overwriting
+		fileName := fmt.Sprintf("%s/%d.json", dir, p.ID)
+		if _, err := os.Stat(fileName); err == nil || seen[p.ID] {
+			fmt.Printf("[ProductRepository][SaveProducts][ERROR] Product with ID %d already exists\n", p.ID)
+			return fmt.Errorf("product with ID %d %w", p.ID, ErrAlreadyExists)
 		}
+		seen[p.ID] = true
+	}
+	if err := r.ids.check(products); err != nil {
+		fmt.Printf("[ProductRepository][SaveProducts][ERROR] %v\n", err)
+		return err
+	}
 
-		// Write the JSON data to the file
-		if err := os.WriteFile(fileName, bytes, 0644); err != nil {
-			fmt.Printf("[ProductRepository][SaveProducts][ERROR] Failed to write product ID: %d to file: %s, error: %v\n", p.ID, fileName, err)
-			return fmt.Errorf("failed to write product %s: %w", fileName, err)
-		}
+	if err := r.commit(newChanges(models.ChangeCreated, products...)); err != nil {
+		fmt.Printf("[ProductRepository][SaveProducts][ERROR] %v\n", err)
+		return err
 	}
 
 	fmt.Printf("[ProductRepository][SaveProducts] Successfully saved all products\n")
 	return nil
 }
 
-func (r *ProductRepoJson) UpdateProducts(products []models.Product) error {
+func (r *ProductRepoJson) UpdateProducts(ctx context.Context, products []models.Product) error {
 	fmt.Printf("[ProductRepository][UpdateProducts] Updating %d products\n", len(products))
-	dir := r.storagePath
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
-	for _, p := range products {
-		// Create the file name based on product ID
-		fileName := fmt.Sprintf("%s/%d.json", dir, p.ID)
-		fmt.Printf("[ProductRepository][UpdateProducts] Updating product ID: %d in file: %s\n", p.ID, fileName)
+	if err := r.recover(); err != nil {
+		fmt.Printf("[ProductRepository][UpdateProducts][ERROR] %v\n", err)
+		return err
+	}
 
-		// Marshal the product to JSON
-		bytes, err := json.MarshalIndent(p, "", "  ")
-		if err != nil {
-			fmt.Printf("[ProductRepository][UpdateProducts][ERROR] Failed to marshal product ID: %d, error: %v\n", p.ID, err)
-			return fmt.Errorf("failed to marshal product %s: %w", fileName, err)
+	// Check every version first so a conflicting batch writes nothing
+	existed := make(map[int]bool, len(products))
+	for _, p := range products {
+		stored, err := r.readProduct(p.ID)
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			fmt.Printf("[ProductRepository][UpdateProducts][ERROR] %v\n", err)
+			return err
 		}
-
-		// Write the JSON data to the file (overwrite existing file)
-		if err := os.WriteFile(fileName, bytes, 0644); err != nil {
-			fmt.Printf("[ProductRepository][UpdateProducts][ERROR] Failed to write product ID: %d to file: %s, error: %v\n", p.ID, fileName, err)
-			return fmt.Errorf("failed to write product %s: %w", fileName, err)
+		if err := checkVersion(p.ID, stored, p.Version-1); err != nil {
+			fmt.Printf("[ProductRepository][UpdateProducts][ERROR] %v\n", err)
+			return err
 		}
+		existed[p.ID] = stored != nil
+	}
+	if err := r.ids.check(products); err != nil {
+		fmt.Printf("[ProductRepository][UpdateProducts][ERROR] %v\n", err)
+		return err
+	}
+
+	if err := ctx.Err(); err != nil {
+		fmt.Printf("[ProductRepository][UpdateProducts][ERROR] Aborted: %v\n", err)
+		return err
+	}
+	if err := r.commit(updateChanges(products, existed)); err != nil {
+		fmt.Printf("[ProductRepository][UpdateProducts][ERROR] %v\n", err)
+		return err
 	}
 
 	fmt.Printf("[ProductRepository][UpdateProducts] Successfully updated all products\n")
 	return nil
 }
 
-func (r *ProductRepoJson) CompareProducts(ids []int) ([]models.Product, error) {
+func (r *ProductRepoJson) CompareProducts(ctx context.Context, ids []int) ([]models.Product, error) {
 	fmt.Printf("[ProductRepository][CompareProducts] Comparing products with IDs: %v\n", ids)
 	var products []models.Product
 
 	// Retrieve each product by ID and add it to the result slice
 	for _, id := range ids {
 		fmt.Printf("[ProductRepository][CompareProducts] Getting product by ID: %d\n", id)
-		product, err := r.GetProductByID(id)
+		product, err := r.GetProductByID(ctx, id)
 		if err != nil {
 			fmt.Printf("[ProductRepository][CompareProducts][ERROR] Failed to get product ID: %d, error: %v\n", id, err)
 			return nil, err
@@ -157,52 +224,163 @@ func (r *ProductRepoJson) CompareProducts(ids []int) ([]models.Product, error) {
 	return products, nil
 }
 
-func (r *ProductRepoJson) GetProductByID(id int) (*models.Product, error) {
+func (r *ProductRepoJson) GetProductByID(ctx context.Context, id int) (*models.Product, error) {
 	fmt.Printf("[ProductRepository][GetProductByID] Getting product by ID: %d\n", id)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := r.recoverForRead(); err != nil {
+		fmt.Printf("[ProductRepository][GetProductByID][ERROR] %v\n", err)
+		return nil, err
+	}
 	dir := r.storagePath
 
 	// Create the file name based on product ID
 	fileName := fmt.Sprintf("%s/%d.json", dir, id)
 
+	// Trace the file read itself, compare calls this once per ID
+	_, span := tracer.Start(ctx, "ProductRepoJson.ReadFile", trace.WithAttributes(
+		attribute.Int("product.id", id),
+		attribute.String("file.path", fileName),
+	))
+	defer span.End()
+
+	product, err := r.readProduct(id)
+	if err != nil {
+		fmt.Printf("[ProductRepository][GetProductByID][ERROR] %v\n", err)
+		return nil, err
+	}
+
+	fmt.Printf("[ProductRepository][GetProductByID] Successfully loaded product ID: %d\n", id)
+	return product, nil
+}
+
+// GetProductByGTIN looks the GTIN up in the identifier index and reads the
+// product holding it
+func (r *ProductRepoJson) GetProductByGTIN(ctx context.Context, code string) (*models.Product, error) {
+	fmt.Printf("[ProductRepository][GetProductByGTIN] Getting product by GTIN: %s\n", code)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Hold the lock so the product can't change between lookup and read
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.recover(); err != nil {
+		fmt.Printf("[ProductRepository][GetProductByGTIN][ERROR] %v\n", err)
+		return nil, err
+	}
+
+	id, ok := r.ids.lookupGTIN(code)
+	if !ok {
+		fmt.Printf("[ProductRepository][GetProductByGTIN][ERROR] No product with GTIN: %s\n", code)
+		return nil, fmt.Errorf("product with GTIN %s %w", code, ErrNotFound)
+	}
+	product, err := r.readProduct(id)
+	if err != nil {
+		fmt.Printf("[ProductRepository][GetProductByGTIN][ERROR] %v\n", err)
+		return nil, err
+	}
+
+	fmt.Printf("[ProductRepository][GetProductByGTIN] Successfully loaded product ID: %d\n", id)
+	return product, nil
+}
+
+// upgradeLegacy reads products saved before versioning as version 1, the
+// version every product starts at, so clients can send their ETag back in
+// If-Match. The file keeps version 0 until the product is next written.
+func upgradeLegacy(product *models.Product) {
+	if product.Version == 0 {
+		product.Version = 1
+	}
+}
+
+// readProduct reads and decodes the file of a single product
+func (r *ProductRepoJson) readProduct(id int) (*models.Product, error) {
+	fileName := fmt.Sprintf("%s/%d.json", r.storagePath, id)
+
 	// Read the file
 	bytes, err := os.ReadFile(fileName)
 	if err != nil {
 		if os.IsNotExist(err) {
-			fmt.Printf("[ProductRepository][GetProductByID][ERROR] Product with ID %d does not exist\n", id)
-			return nil, fmt.Errorf("product with ID %d does not exist", id)
+			return nil, fmt.Errorf("product with ID %d %w", id, ErrNotFound)
 		}
-		fmt.Printf("[ProductRepository][GetProductByID][ERROR] Failed to read file: %s, error: %v\n", fileName, err)
 		return nil, fmt.Errorf("failed to read file %s: %w", fileName, err)
 	}
 
 	// Unmarshal the JSON data into a Product struct
 	var product models.Product
 	if err := json.Unmarshal(bytes, &product); err != nil {
-		fmt.Printf("[ProductRepository][GetProductByID][ERROR] Failed to unmarshal file: %s, error: %v\n", fileName, err)
 		return nil, fmt.Errorf("failed to unmarshal file %s: %w", fileName, err)
 	}
-
-	fmt.Printf("[ProductRepository][GetProductByID] Successfully loaded product ID: %d\n", id)
+	upgradeLegacy(&product)
 	return &product, nil
 }
 
-func (r *ProductRepoJson) DeleteByID(id int) error {
+func (r *ProductRepoJson) DeleteByID(ctx context.Context, id int, version int) error {
 	fmt.Printf("[ProductRepository][DeleteByID] Deleting product by ID: %d\n", id)
-	dir := r.storagePath
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
-	// Create the file name based on product ID
-	fileName := fmt.Sprintf("%s/%d.json", dir, id)
+	if err := r.recover(); err != nil {
+		fmt.Printf("[ProductRepository][DeleteByID][ERROR] %v\n", err)
+		return err
+	}
 
-	// Delete the file
-	if err := os.Remove(fileName); err != nil {
-		if os.IsNotExist(err) {
-			fmt.Printf("[ProductRepository][DeleteByID][ERROR] Product with ID %d does not exist\n", id)
-			return fmt.Errorf("product with ID %d does not exist", id)
+	// The deleted state is recorded in the outbox
+	stored, err := r.readProduct(id)
+	if err != nil {
+		fmt.Printf("[ProductRepository][DeleteByID][ERROR] %v\n", err)
+		return err
+	}
+
+	// Only delete the version the caller has seen
+	if version != 0 {
+		if err := checkVersion(id, stored, version); err != nil {
+			fmt.Printf("[ProductRepository][DeleteByID][ERROR] %v\n", err)
+			return err
 		}
-		fmt.Printf("[ProductRepository][DeleteByID][ERROR] Failed to delete file: %s, error: %v\n", fileName, err)
-		return fmt.Errorf("failed to delete file %d: %w", id, err)
+	}
+
+	if err := r.commit(newChanges(models.ChangeDeleted, *stored)); err != nil {
+		fmt.Printf("[ProductRepository][DeleteByID][ERROR] %v\n", err)
+		return err
 	}
 
 	fmt.Printf("[ProductRepository][DeleteByID] Successfully deleted product ID: %d\n", id)
 	return nil
 }
+
+// CheckHealth verifies the storage directory exists and is writable by
+// creating and removing a temporary file in it
+func (r *ProductRepoJson) CheckHealth(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	dir := r.storagePath
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		fmt.Printf("[ProductRepository][CheckHealth][ERROR] Storage directory not reachable: %s, error: %v\n", dir, err)
+		return fmt.Errorf("storage directory %s not reachable: %w", dir, err)
+	}
+	if !info.IsDir() {
+		fmt.Printf("[ProductRepository][CheckHealth][ERROR] Storage path is not a directory: %s\n", dir)
+		return fmt.Errorf("storage path %s is not a directory", dir)
+	}
+
+	// Probe write access with a hidden temp file, it's skipped by LoadProducts
+	probe, err := os.CreateTemp(dir, ".readyz-*")
+	if err != nil {
+		fmt.Printf("[ProductRepository][CheckHealth][ERROR] Storage directory not writable: %s, error: %v\n", dir, err)
+		return fmt.Errorf("storage directory %s not writable: %w", dir, err)
+	}
+	probe.Close()
+	if err := os.Remove(probe.Name()); err != nil {
+		return fmt.Errorf("failed to remove probe file %s: %w", probe.Name(), err)
+	}
+	return nil
+}
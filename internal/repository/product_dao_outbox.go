@@ -0,0 +1,269 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"item-comparison-api/internal/models"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// commit records the changes as an outbox entry and applies them. Once the
+// entry is recorded the write counts as done: if applying fails, the failure
+// is only logged and the entry is applied again before the next read or
+// write. Callers hold mu.
+func (r *ProductRepoJson) commit(changes []models.ProductChange) error {
+	id, seq := nextEntryID(r.lastSeq)
+	entry := models.OutboxEntry{ID: id, Changes: changes}
+	if err := r.writeEntry(entry); err != nil {
+		return err
+	}
+	r.lastSeq = seq
+	r.ids.apply(changes)
+
+	if err := r.apply(entry); err != nil {
+		fmt.Printf("[ProductRepository][commit][ERROR] Outbox entry %s is committed but not applied yet: %v\n", id, err)
+		r.recovered = false
+	}
+	signal(r.notify)
+	return nil
+}
+
+// writeEntry durably records a committed entry
+func (r *ProductRepoJson) writeEntry(entry models.OutboxEntry) error {
+	dir := filepath.Join(r.storagePath, outboxDir)
+	bytes, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal outbox entry %s: %w", entry.ID, err)
+	}
+
+	tmp, err := os.CreateTemp(dir, ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write outbox entry %s: %w", entry.ID, err)
+	}
+	_, err = tmp.Write(bytes)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), filepath.Join(dir, entry.ID+committedExt))
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write outbox entry %s: %w", entry.ID, err)
+	}
+	return nil
+}
+
+// apply writes the product files of a committed entry and marks it applied.
+// Applying an entry again gives the same result, as long as no later entry
+// was applied in between.
+func (r *ProductRepoJson) apply(entry models.OutboxEntry) error {
+	for _, change := range entry.Changes {
+		fileName := fmt.Sprintf("%s/%d.json", r.storagePath, change.Product.ID)
+
+		if change.Type == models.ChangeDeleted {
+			fmt.Printf("[ProductRepository][apply] Deleting product ID: %d file: %s\n", change.Product.ID, fileName)
+			if err := os.Remove(fileName); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to delete file %d: %w", change.Product.ID, err)
+			}
+			continue
+		}
+
+		// Marshal the product to JSON
+		fmt.Printf("[ProductRepository][apply] Writing product ID: %d to file: %s\n", change.Product.ID, fileName)
+		bytes, err := json.MarshalIndent(change.Product, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal product %s: %w", fileName, err)
+		}
+
+		// Write the JSON data to the file (overwrite existing file)
+		if err := os.WriteFile(fileName, bytes, 0644); err != nil {
+			return fmt.Errorf("failed to write product %s: %w", fileName, err)
+		}
+	}
+
+	dir := filepath.Join(r.storagePath, outboxDir)
+	if err := os.Rename(filepath.Join(dir, entry.ID+committedExt), filepath.Join(dir, entry.ID+appliedExt)); err != nil {
+		return fmt.Errorf("failed to mark outbox entry %s applied: %w", entry.ID, err)
+	}
+	return nil
+}
+
+// recover creates the outbox directory on first use, applies the committed
+// entries a crash or failed write left behind, oldest first, and builds the
+// identifier index from the product files. Callers hold mu.
+func (r *ProductRepoJson) recover() error {
+	if r.recovered {
+		return nil
+	}
+	dir := filepath.Join(r.storagePath, outboxDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create outbox directory %s: %w", dir, err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read outbox directory %s: %w", dir, err)
+	}
+	for _, e := range entries {
+		id, ext := entryFile(e.Name())
+		if id == "" {
+			continue
+		}
+		if seq, err := strconv.ParseUint(id, 10, 64); err == nil {
+			r.lastSeq = max(r.lastSeq, seq)
+		}
+		if ext != committedExt {
+			continue
+		}
+
+		entry, err := r.readEntry(e.Name())
+		if err != nil {
+			return err
+		}
+		fmt.Printf("[ProductRepository][recover] Applying outbox entry %s left by an interrupted write\n", id)
+		if err := r.apply(*entry); err != nil {
+			return err
+		}
+		signal(r.notify)
+	}
+
+	if err := r.indexIdentifiers(); err != nil {
+		return err
+	}
+	r.recovered = true
+	return nil
+}
+
+// recoverForRead applies the committed entries left behind before a read, so
+// reads see every write that was reported as done
+func (r *ProductRepoJson) recoverForRead() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.recover()
+}
+
+// indexIdentifiers builds the identifier index from the product files.
+// Callers hold mu.
+func (r *ProductRepoJson) indexIdentifiers() error {
+	entries, err := os.ReadDir(r.storagePath)
+	if err != nil {
+		return fmt.Errorf("failed to read directory %s: %w", r.storagePath, err)
+	}
+	ids := newIdentifierIndex()
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".json" {
+			continue
+		}
+		id, err := strconv.Atoi(strings.TrimSuffix(e.Name(), ".json"))
+		if err != nil {
+			continue
+		}
+		product, err := r.readProduct(id)
+		if err != nil {
+			return err
+		}
+		ids.add(*product)
+	}
+	r.ids = ids
+	return nil
+}
+
+// readEntry reads an outbox entry file
+func (r *ProductRepoJson) readEntry(name string) (*models.OutboxEntry, error) {
+	fileName := filepath.Join(r.storagePath, outboxDir, name)
+	bytes, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read outbox entry %s: %w", fileName, err)
+	}
+	var entry models.OutboxEntry
+	if err := json.Unmarshal(bytes, &entry); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal outbox entry %s: %w", fileName, err)
+	}
+	return &entry, nil
+}
+
+// PendingChanges returns the applied entries recorded after the given entry
+func (r *ProductRepoJson) PendingChanges(ctx context.Context, after string, limit int) ([]models.OutboxEntry, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Entries of a crashed write are applied before they are handed out
+	if err := r.recoverForRead(); err != nil {
+		fmt.Printf("[ProductRepository][PendingChanges][ERROR] %v\n", err)
+		return nil, err
+	}
+
+	dir := filepath.Join(r.storagePath, outboxDir)
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read outbox directory %s: %w", dir, err)
+	}
+
+	// ReadDir sorts by name, which is the recording order
+	var entries []models.OutboxEntry
+	for _, f := range files {
+		id, ext := entryFile(f.Name())
+		if ext != appliedExt || id <= after {
+			continue
+		}
+		entry, err := r.readEntry(f.Name())
+		if err != nil {
+			if os.IsNotExist(err) {
+				// Acknowledged since the directory was read
+				continue
+			}
+			return nil, err
+		}
+		entries = append(entries, *entry)
+		if len(entries) == limit {
+			break
+		}
+	}
+	return entries, nil
+}
+
+// AckChanges removes the entry files up to and including the given entry
+func (r *ProductRepoJson) AckChanges(ctx context.Context, upTo string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	dir := filepath.Join(r.storagePath, outboxDir)
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read outbox directory %s: %w", dir, err)
+	}
+	for _, f := range files {
+		id, ext := entryFile(f.Name())
+		if ext != appliedExt || id > upTo {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, f.Name())); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove outbox entry %s: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// ChangeNotify is signaled after every write
+func (r *ProductRepoJson) ChangeNotify() <-chan struct{} {
+	return r.notify
+}
+
+// entryFile splits an outbox file name into entry ID and extension. Other
+// files, like temporary ones, give an empty ID.
+func entryFile(name string) (string, string) {
+	ext := filepath.Ext(name)
+	if strings.HasPrefix(name, ".") || (ext != committedExt && ext != appliedExt) {
+		return "", ""
+	}
+	return strings.TrimSuffix(name, ext), ext
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"item-comparison-api/internal/models"
+)
+
+func testProduct(id int) models.Product {
+	return models.Product{ID: id, Name: "Phone", Price: 10, SellerID: "s1", Version: 1, CreatedAt: "2026-01-01T00:00:00Z"}
+}
+
+// writeCommitted leaves a committed but unapplied entry behind, as a crash
+// between recording an entry and writing the product files does
+func writeCommitted(t *testing.T, dir string, entry models.OutboxEntry) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Join(dir, outboxDir), 0755); err != nil {
+		t.Fatal(err)
+	}
+	bytes, err := json.Marshal(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, outboxDir, entry.ID+committedExt), bytes, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestReadsRecoverCommittedEntries checks that every read applies the
+// entries a crash left behind before it looks at the product files
+func TestReadsRecoverCommittedEntries(t *testing.T) {
+	reads := []struct {
+		name string
+		read func(ctx context.Context, r *ProductRepoJson) ([]models.Product, error)
+	}{
+		{"LoadProducts", func(ctx context.Context, r *ProductRepoJson) ([]models.Product, error) {
+			return r.LoadProducts(ctx)
+		}},
+		{"GetProductByID", func(ctx context.Context, r *ProductRepoJson) ([]models.Product, error) {
+			p, err := r.GetProductByID(ctx, 1)
+			if err != nil {
+				return nil, err
+			}
+			return []models.Product{*p}, nil
+		}},
+		{"CompareProducts", func(ctx context.Context, r *ProductRepoJson) ([]models.Product, error) {
+			return r.CompareProducts(ctx, []int{1})
+		}},
+	}
+
+	for _, tt := range reads {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			entry := models.OutboxEntry{ID: "00000000000000000001", Changes: newChanges(models.ChangeCreated, testProduct(1))}
+			writeCommitted(t, dir, entry)
+
+			products, err := tt.read(context.Background(), NewProductRepo(dir))
+			if err != nil {
+				t.Fatalf("read failed: %v", err)
+			}
+			if len(products) != 1 || products[0].ID != 1 {
+				t.Fatalf("read returned %v, want product 1", products)
+			}
+			if _, err := os.Stat(filepath.Join(dir, outboxDir, entry.ID+appliedExt)); err != nil {
+				t.Errorf("entry not marked applied: %v", err)
+			}
+		})
+	}
+}
+
+// TestCommitSucceedsOnceEntryIsDurable checks that a write whose entry was
+// recorded is reported as done even if applying it fails, and that the entry
+// is applied and handed out once that works again
+func TestCommitSucceedsOnceEntryIsDurable(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	r := NewProductRepo(dir)
+	if _, err := r.LoadProducts(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// A directory in place of the applied entry makes marking it applied fail
+	r.lastSeq = 1 << 62
+	id, _ := nextEntryID(r.lastSeq)
+	blocker := filepath.Join(dir, outboxDir, id+appliedExt)
+	if err := os.Mkdir(blocker, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.SaveProducts(ctx, []models.Product{testProduct(1)}); err != nil {
+		t.Fatalf("SaveProducts returned %v after the entry was committed", err)
+	}
+
+	if err := os.Remove(blocker); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := r.PendingChanges(ctx, "", 10)
+	if err != nil || len(entries) != 1 || entries[0].ID != id {
+		t.Fatalf("PendingChanges = %v, %v, want entry %s", entries, err, id)
+	}
+	if err := r.AckChanges(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := r.PendingChanges(ctx, "", 10); len(entries) != 0 {
+		t.Errorf("PendingChanges after ack = %d entries, want 0", len(entries))
+	}
+}
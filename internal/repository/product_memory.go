@@ -0,0 +1,208 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"item-comparison-api/internal/models"
+	"sort"
+	"sync"
+)
+
+// ProductRepoMemory keeps products in memory, useful for local runs and
+// demos where nothing should be written to disk. Outbox entries are recorded
+// under the same lock as the writes.
+type ProductRepoMemory struct {
+	mu       sync.RWMutex
+	products map[int]models.Product
+	ids      *identifierIndex
+	outbox   []models.OutboxEntry
+	lastSeq  uint64
+	notify   chan struct{}
+}
+
+// NewProductRepoMemory creates a new, empty instance of ProductRepoMemory
+func NewProductRepoMemory() *ProductRepoMemory {
+	fmt.Printf("[ProductRepoMemory][NewProductRepoMemory] Initializing in-memory storage\n")
+	return &ProductRepoMemory{products: make(map[int]models.Product), ids: newIdentifierIndex(), notify: make(chan struct{}, 1)}
+}
+
+// LoadProducts returns all products ordered by ID
+func (r *ProductRepoMemory) LoadProducts(ctx context.Context) ([]models.Product, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	products := make([]models.Product, 0, len(r.products))
+	for _, p := range r.products {
+		products = append(products, p)
+	}
+	sort.Slice(products, func(i, j int) bool { return products[i].ID < products[j].ID })
+	return products, nil
+}
+
+// SaveProducts stores new products, failing if any ID is already taken
+func (r *ProductRepoMemory) SaveProducts(ctx context.Context, products []models.Product) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Check every ID first so a failed batch leaves nothing behind
+	for _, p := range products {
+		if _, ok := r.products[p.ID]; ok {
+			return fmt.Errorf("product with ID %d %w", p.ID, ErrAlreadyExists)
+		}
+	}
+	if err := r.ids.check(products); err != nil {
+		return err
+	}
+	for _, p := range products {
+		r.products[p.ID] = p
+	}
+	r.record(newChanges(models.ChangeCreated, products...))
+	return nil
+}
+
+// UpdateProducts stores the given products, replacing existing ones
+func (r *ProductRepoMemory) UpdateProducts(ctx context.Context, products []models.Product) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Check every version first so a conflicting batch writes nothing
+	existed := make(map[int]bool, len(products))
+	for _, p := range products {
+		stored := r.lookup(p.ID)
+		if err := checkVersion(p.ID, stored, p.Version-1); err != nil {
+			return err
+		}
+		existed[p.ID] = stored != nil
+	}
+	if err := r.ids.check(products); err != nil {
+		return err
+	}
+	for _, p := range products {
+		r.products[p.ID] = p
+	}
+	r.record(updateChanges(products, existed))
+	return nil
+}
+
+func (r *ProductRepoMemory) CompareProducts(ctx context.Context, ids []int) ([]models.Product, error) {
+	var products []models.Product
+	for _, id := range ids {
+		product, err := r.GetProductByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, *product)
+	}
+	return products, nil
+}
+
+func (r *ProductRepoMemory) GetProductByID(ctx context.Context, id int) (*models.Product, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	product := r.lookup(id)
+	if product == nil {
+		return nil, fmt.Errorf("product with ID %d %w", id, ErrNotFound)
+	}
+	return product, nil
+}
+
+// GetProductByGTIN returns the product holding the GTIN
+func (r *ProductRepoMemory) GetProductByGTIN(ctx context.Context, code string) (*models.Product, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	id, ok := r.ids.lookupGTIN(code)
+	if !ok {
+		return nil, fmt.Errorf("product with GTIN %s %w", code, ErrNotFound)
+	}
+	return r.lookup(id), nil
+}
+
+// lookup returns a copy of the stored product or nil, callers hold the lock
+func (r *ProductRepoMemory) lookup(id int) *models.Product {
+	product, ok := r.products[id]
+	if !ok {
+		return nil
+	}
+	return &product
+}
+
+func (r *ProductRepoMemory) DeleteByID(ctx context.Context, id int, version int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := r.lookup(id)
+	if stored == nil {
+		return fmt.Errorf("product with ID %d %w", id, ErrNotFound)
+	}
+	if version != 0 {
+		if err := checkVersion(id, stored, version); err != nil {
+			return err
+		}
+	}
+	delete(r.products, id)
+	r.record(newChanges(models.ChangeDeleted, *stored))
+	return nil
+}
+
+// record appends an outbox entry and indexes the identifiers of the
+// changes, callers hold the write lock
+func (r *ProductRepoMemory) record(changes []models.ProductChange) {
+	r.ids.apply(changes)
+	var id string
+	id, r.lastSeq = nextEntryID(r.lastSeq)
+	r.outbox = append(r.outbox, models.OutboxEntry{ID: id, Changes: changes})
+	signal(r.notify)
+}
+
+// PendingChanges returns the entries recorded after the given entry
+func (r *ProductRepoMemory) PendingChanges(ctx context.Context, after string, limit int) ([]models.OutboxEntry, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	start := sort.Search(len(r.outbox), func(i int) bool { return r.outbox[i].ID > after })
+	end := min(start+limit, len(r.outbox))
+	return append([]models.OutboxEntry(nil), r.outbox[start:end]...), nil
+}
+
+// AckChanges forgets the entries up to and including the given entry
+func (r *ProductRepoMemory) AckChanges(ctx context.Context, upTo string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := sort.Search(len(r.outbox), func(i int) bool { return r.outbox[i].ID > upTo })
+	r.outbox = append([]models.OutboxEntry(nil), r.outbox[n:]...)
+	return nil
+}
+
+// ChangeNotify is signaled after every write
+func (r *ProductRepoMemory) ChangeNotify() <-chan struct{} {
+	return r.notify
+}
+
+// CheckHealth always succeeds, memory is always reachable
+func (r *ProductRepoMemory) CheckHealth(ctx context.Context) error {
+	return ctx.Err()
+}
@@ -1,13 +1,56 @@
 package repository
 
-import "item-comparison-api/internal/models"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"item-comparison-api/internal/models"
+)
+
+// Errors returned by repositories, wrapped with the product ID. Callers
+// check them with errors.Is.
+var (
+	ErrNotFound        = errors.New("does not exist")
+	ErrAlreadyExists   = errors.New("already exists")
+	ErrVersionConflict = errors.New("version conflict")
+)
 
 // ProductRepo defines the interface for product repository operations
 type ProductRepo interface {
-	LoadProducts() ([]models.Product, error)
-	SaveProducts([]models.Product) error
-	UpdateProducts([]models.Product) error
-	CompareProducts([]int) ([]models.Product, error)
-	GetProductByID(int) (*models.Product, error)
-	DeleteByID(int) error
+	LoadProducts(context.Context) ([]models.Product, error)
+	// SaveProducts and UpdateProducts return ErrAlreadyExists when a product
+	// takes the GTIN, or the MPN within its brand, of another product
+	SaveProducts(context.Context, []models.Product) error
+	// UpdateProducts stores products, creating missing ones. Each product's
+	// Version must be exactly one above the stored version (a missing product
+	// counts as version 0), otherwise nothing is written and
+	// ErrVersionConflict is returned.
+	UpdateProducts(context.Context, []models.Product) error
+	CompareProducts(context.Context, []int) ([]models.Product, error)
+	GetProductByID(context.Context, int) (*models.Product, error)
+	// GetProductByGTIN returns the product with the GTIN, given as any of
+	// GTIN-8, -12, -13 or -14
+	GetProductByGTIN(context.Context, string) (*models.Product, error)
+	// DeleteByID removes a product. A non-zero version must match the stored
+	// version or ErrVersionConflict is returned.
+	DeleteByID(ctx context.Context, id int, version int) error
+}
+
+// HealthChecker is implemented by storage backends that can report whether
+// they are reachable and writable
+type HealthChecker interface {
+	CheckHealth(context.Context) error
+}
+
+// checkVersion enforces optimistic concurrency: the stored product, nil when
+// missing, must be at the expected version
+func checkVersion(id int, stored *models.Product, expected int) error {
+	current := 0
+	if stored != nil {
+		current = stored.Version
+	}
+	if current != expected {
+		return fmt.Errorf("product with ID %d is at version %d, expected %d: %w", id, current, expected, ErrVersionConflict)
+	}
+	return nil
 }
@@ -1,11 +1,18 @@
 package internal
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"time"
 
 	"item-comparison-api/internal/api"
+	"item-comparison-api/internal/config"
+	"item-comparison-api/internal/gql"
+	"item-comparison-api/internal/idempotency"
+	"item-comparison-api/internal/metrics"
+	"item-comparison-api/internal/openapi"
+	"item-comparison-api/internal/tracing"
 
 	"github.com/go-chi/chi/v5"
 )
@@ -20,21 +27,178 @@ func loggingMiddleware(next http.Handler) http.Handler {
 	})
 }
 
-func SetupRouter(handler *api.ProductHandler) *chi.Mux {
+// bodyLimitMiddleware rejects request bodies larger than maxBytes
+func bodyLimitMiddleware(maxBytes int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// deadlineMargin is how long before the timeout the request context expires
+const deadlineMargin = 250 * time.Millisecond
+
+// timeoutMiddleware cancels the request context shortly before the timeout
+// elapses, so handlers that observe the expired deadline from the service
+// respond with 504. If the handler has not written a response once the
+// timeout elapses, it responds with 503.
+func timeoutMiddleware(timeout time.Duration) func(http.Handler) http.Handler {
+	margin := min(deadlineMargin, timeout/10)
+	return func(next http.Handler) http.Handler {
+		return http.TimeoutHandler(deadlineMiddleware(timeout-margin)(next), timeout, "Request timed out")
+	}
+}
+
+// deadlineMiddleware cancels the request context once the timeout elapses
+// but, unlike timeoutMiddleware, leaves the response unbuffered, so large
+// exports stream to the client as they are encoded. Handlers map the expired
+// deadline to 504 as long as they have not started the response.
+func deadlineMiddleware(timeout time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func SetupRouter(cfg *config.Config, handler *api.ProductHandler, offers *api.OfferHandler, similar *api.SimilarityHandler, compared *api.CooccurrenceHandler, imports *api.ImportHandler, events *api.EventsHandler, webhooks *api.WebhookHandler, alerts *api.AlertHandler, comparisons *api.ComparisonHandler, duplicates *api.DuplicateHandler, graphql *gql.Handler, health *api.HealthHandler, m *metrics.Metrics) *chi.Mux {
 	// Create a new router
 	r := chi.NewRouter()
 
-	// Apply logging middleware
-	r.Use(loggingMiddleware)
+	// Apply logging, tracing and metrics middleware
+	if cfg.Logging.Requests {
+		r.Use(loggingMiddleware)
+	}
+	r.Use(tracing.Middleware)
+	r.Use(m.Middleware)
+
+	// Expose Prometheus metrics and health probes
+	r.Method(http.MethodGet, "/metrics", m.Handler())
+	r.Get("/healthz", health.Liveness)
+	r.Get("/readyz", health.Readiness)
+
+	// Serve the OpenAPI document and interactive docs
+	r.Get("/openapi.json", openapi.SpecHandler)
+	r.Get("/docs", openapi.DocsHandler)
+
+	// Per-route request timeouts. Reads scan the storage directory, writes
+	// touch one file per product.
+	read := timeoutMiddleware(cfg.Limits.ReadTimeout)
+	write := timeoutMiddleware(cfg.Limits.WriteTimeout)
+	// The product list doubles as the CSV and NDJSON export, which must not
+	// be held in memory until the timeout handler releases it
+	export := deadlineMiddleware(cfg.Limits.ReadTimeout)
+
+	// Responses to writes with an Idempotency-Key are replayed per seller
+	idempotencyStore := idempotency.NewMemoryStore(cfg.Idempotency.Window)
+	sellerScope := func(r *http.Request) string { return api.SellerFromContext(r.Context()) }
 
 	// Define routes
 	r.Route("/api/v1/products", func(r chi.Router) {
-		r.Get("/", handler.LoadProducts)
-		r.Post("/", handler.SaveProducts)
-		r.Put("/", handler.UpdateProducts)
-		r.Delete("/{id}", handler.DeleteProduct)
-		r.Get("/{id}", handler.GetProduct)
-		r.Post("/compare", handler.CompareProducts)
+		r.Use(bodyLimitMiddleware(cfg.Limits.MaxBodyBytes))
+		r.Use(api.AuthMiddleware(cfg.Auth))
+		r.Use(idempotency.Middleware(idempotencyStore, sellerScope))
+
+		r.With(export).Get("/", handler.LoadProducts)
+		r.With(write).Post("/", handler.SaveProducts)
+		r.With(write).Put("/", handler.UpdateProducts)
+		r.With(write).Delete("/{id}", handler.DeleteProduct)
+		r.With(write).Patch("/{id}", handler.PatchProduct)
+		r.With(read, duplicates.RedirectMerged).Get("/{id}", handler.GetProduct)
+		r.With(read).Get("/by-gtin/{code}", handler.GetProductByGTIN)
+		r.With(read, duplicates.RedirectMerged).Get("/{id}/offers", offers.ListOffers)
+		r.With(write).Put("/{id}/offers", offers.PutOffer)
+		r.With(write).Delete("/{id}/offers", offers.DeleteOffer)
+		r.With(read, duplicates.RedirectMerged).Get("/{id}/similar", similar.GetSimilarProducts)
+		r.With(read, duplicates.RedirectMerged).Get("/{id}/frequently-compared", compared.GetFrequentlyCompared)
+		r.With(read).Get("/compare/popular", compared.GetPopularComparisons)
+		r.With(read).Post("/compare", handler.CompareProducts)
+	})
+
+	// Imports take large uploads and are processed in the background. The
+	// upload itself is bounded by the server read timeout, not a route timeout.
+	// Uploads are not buffered for idempotency, the import manager recognizes
+	// retries by their Idempotency-Key instead.
+	r.Route("/api/v1/imports", func(r chi.Router) {
+		r.Use(bodyLimitMiddleware(cfg.Imports.MaxUploadBytes))
+		r.Use(api.AuthMiddleware(cfg.Auth))
+
+		r.Post("/", imports.CreateImport)
+		r.With(read).Get("/{id}", imports.GetImport)
+	})
+
+	// Webhook subscriptions with their delivery history and dead letters
+	r.Route("/api/v1/webhooks", func(r chi.Router) {
+		r.Use(bodyLimitMiddleware(cfg.Limits.MaxBodyBytes))
+		r.Use(api.AuthMiddleware(cfg.Auth))
+		r.Use(idempotency.Middleware(idempotencyStore, sellerScope))
+
+		r.With(write).Post("/", webhooks.CreateWebhook)
+		r.With(read).Get("/", webhooks.ListWebhooks)
+		r.With(read).Get("/dead-letters", webhooks.ListDeadLetters)
+		r.With(read).Get("/{id}", webhooks.GetWebhook)
+		r.With(write).Put("/{id}", webhooks.UpdateWebhook)
+		r.With(write).Delete("/{id}", webhooks.DeleteWebhook)
+		r.With(write).Post("/{id}/ping", webhooks.PingWebhook)
+		r.With(read).Get("/{id}/deliveries", webhooks.ListDeliveries)
+		r.With(read).Get("/{id}/deliveries/{deliveryID}", webhooks.GetDelivery)
+		r.With(write).Post("/{id}/deliveries/{deliveryID}/redeliver", webhooks.Redeliver)
+	})
+
+	// Price drop alerts of the caller
+	r.Route("/api/v1/alerts", func(r chi.Router) {
+		r.Use(bodyLimitMiddleware(cfg.Limits.MaxBodyBytes))
+		r.Use(api.AuthMiddleware(cfg.Auth))
+		r.Use(idempotency.Middleware(idempotencyStore, sellerScope))
+
+		r.With(write).Post("/", alerts.CreateAlert)
+		r.With(read).Get("/", alerts.ListAlerts)
+		r.With(read).Get("/{id}", alerts.GetAlert)
+		r.With(write).Delete("/{id}", alerts.CancelAlert)
+	})
+
+	// Saved comparisons. Saving and viewing work without credentials, so
+	// comparisons can be shared by their ID.
+	r.Route("/api/v1/comparisons", func(r chi.Router) {
+		r.Use(bodyLimitMiddleware(cfg.Limits.MaxBodyBytes))
+		r.Use(api.AuthMiddleware(cfg.Auth))
+		r.Use(idempotency.Middleware(idempotencyStore, sellerScope))
+
+		r.With(write).Post("/", comparisons.CreateComparison)
+		r.With(read).Get("/", comparisons.ListComparisons)
+		r.With(read).Get("/{id}", comparisons.GetComparison)
+		r.With(write).Delete("/{id}", comparisons.DeleteComparison)
+	})
+
+	// Review queue of likely duplicate products and their merges
+	r.Route("/api/v1/duplicates", func(r chi.Router) {
+		r.Use(bodyLimitMiddleware(cfg.Limits.MaxBodyBytes))
+		r.Use(api.AuthMiddleware(cfg.Auth))
+		r.Use(idempotency.Middleware(idempotencyStore, sellerScope))
+
+		r.With(read).Get("/", duplicates.ListCandidates)
+		r.With(write).Post("/scan", duplicates.ScanDuplicates)
+		r.With(read).Get("/merges", duplicates.ListMerges)
+		r.With(write).Post("/{id}/dismiss", duplicates.DismissCandidate)
+		r.With(write).Post("/{id}/merge", duplicates.MergeCandidate)
+	})
+
+	// The event stream stays open indefinitely, so it gets no route timeout
+	r.Get("/api/v1/events", events.Stream)
+
+	// GraphQL serves reads and writes on one route, so it gets the longer
+	// write timeout
+	r.Group(func(r chi.Router) {
+		r.Use(bodyLimitMiddleware(cfg.Limits.MaxBodyBytes))
+		r.Use(api.AuthMiddleware(cfg.Auth))
+		r.Use(write)
+
+		r.Method(http.MethodGet, "/graphql", graphql)
+		r.Method(http.MethodPost, "/graphql", graphql)
 	})
 
 	return r
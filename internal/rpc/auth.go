@@ -0,0 +1,103 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"item-comparison-api/internal/config"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type sellerContextKey struct{}
+
+// apiKeyMetadata carries the API key in api_key auth mode, like the X-API-Key header
+const apiKeyMetadata = "x-api-key"
+
+// resolveSeller finds the seller of a call from its metadata, with the same
+// rules as the HTTP AuthMiddleware: the seller header is trusted in header
+// mode, the API key is looked up in api_key mode and unknown keys are
+// rejected. Calls without credentials resolve to an empty seller.
+func resolveSeller(ctx context.Context, cfg config.AuthConfig) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	first := func(key string) string {
+		if values := md.Get(strings.ToLower(key)); len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+
+	var sellerID string
+	switch cfg.Mode {
+	case config.AuthModeAPIKey:
+		if key := first(apiKeyMetadata); key != "" {
+			seller, ok := cfg.APIKeys[key]
+			if !ok {
+				return ctx, status.Error(codes.Unauthenticated, "invalid API key")
+			}
+			sellerID = seller
+		}
+	default:
+		sellerID = first(cfg.SellerHeader)
+	}
+
+	if sellerID == "" {
+		return ctx, nil
+	}
+	return context.WithValue(ctx, sellerContextKey{}, sellerID), nil
+}
+
+// sellerFromContext returns the seller resolved by the auth interceptors, or
+// an empty string when the call carried no credentials
+func sellerFromContext(ctx context.Context) string {
+	sellerID, _ := ctx.Value(sellerContextKey{}).(string)
+	return sellerID
+}
+
+// requireSeller returns the seller of a write call or an InvalidArgument
+// error, as the HTTP API answers 400 to writes without credentials
+func requireSeller(ctx context.Context, method string) (string, error) {
+	sellerID := sellerFromContext(ctx)
+	if sellerID == "" {
+		fmt.Printf("[CatalogServer][%s][ERROR] Missing seller credentials\n", method)
+		return "", status.Error(codes.InvalidArgument, "missing seller credentials")
+	}
+	return sellerID, nil
+}
+
+// authUnaryInterceptor resolves the seller of unary calls
+func authUnaryInterceptor(cfg config.AuthConfig) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := resolveSeller(ctx, cfg)
+		if err != nil {
+			fmt.Printf("[AuthInterceptor][ERROR] %v for %s\n", err, info.FullMethod)
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// authStreamInterceptor resolves the seller of streaming calls
+func authStreamInterceptor(cfg config.AuthConfig) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := resolveSeller(ss.Context(), cfg)
+		if err != nil {
+			fmt.Printf("[AuthInterceptor][ERROR] %v for %s\n", err, info.FullMethod)
+			return err
+		}
+		return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// contextStream replaces the context of a server stream
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
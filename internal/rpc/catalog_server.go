@@ -0,0 +1,147 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"item-comparison-api/internal/rpc/catalogpb"
+	"item-comparison-api/internal/services"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// CatalogServer implements the ProductCatalogService on top of the same
+// service as the HTTP handlers
+type CatalogServer struct {
+	catalogpb.UnimplementedProductCatalogServiceServer
+	service       services.ProductServiceInterface
+	maxCompareIDs int
+}
+
+// NewCatalogServer creates a new instance of CatalogServer
+func NewCatalogServer(s services.ProductServiceInterface, maxCompareIDs int) *CatalogServer {
+	return &CatalogServer{service: s, maxCompareIDs: maxCompareIDs}
+}
+
+// List returns every product in the catalog
+func (c *CatalogServer) List(ctx context.Context, req *catalogpb.ListRequest) (*catalogpb.ListResponse, error) {
+	products, err := c.service.LoadProducts(ctx)
+	if err != nil {
+		fmt.Printf("[CatalogServer][List][ERROR] %v\n", err)
+		return nil, toStatus(err, "failed to load products")
+	}
+
+	fmt.Printf("[CatalogServer][List] Loaded %d products\n", len(products))
+	return &catalogpb.ListResponse{Products: productsFromResponses(products)}, nil
+}
+
+// ListAll streams every product in the catalog, one message per product
+func (c *CatalogServer) ListAll(req *catalogpb.ListAllRequest, stream grpc.ServerStreamingServer[catalogpb.ListAllResponse]) error {
+	ctx := stream.Context()
+	products, err := c.service.LoadProducts(ctx)
+	if err != nil {
+		fmt.Printf("[CatalogServer][ListAll][ERROR] %v\n", err)
+		return toStatus(err, "failed to load products")
+	}
+
+	for _, p := range products {
+		// Stop sending once the client went away or the deadline passed
+		if err := ctx.Err(); err != nil {
+			return toStatus(err, "stream aborted")
+		}
+		if err := stream.Send(&catalogpb.ListAllResponse{Product: productFromResponse(p)}); err != nil {
+			fmt.Printf("[CatalogServer][ListAll][ERROR] %v\n", err)
+			return err
+		}
+	}
+
+	fmt.Printf("[CatalogServer][ListAll] Streamed %d products\n", len(products))
+	return nil
+}
+
+// Get returns one product
+func (c *CatalogServer) Get(ctx context.Context, req *catalogpb.GetRequest) (*catalogpb.GetResponse, error) {
+	product, err := c.service.GetProductByID(ctx, int(req.GetId()))
+	if err != nil {
+		fmt.Printf("[CatalogServer][Get][ERROR] %v\n", err)
+		return nil, toStatus(err, "failed to get product")
+	}
+	if product == nil {
+		return nil, status.Errorf(codes.NotFound, "product with ID %d does not exist", req.GetId())
+	}
+
+	return &catalogpb.GetResponse{Product: productFromResponse(*product)}, nil
+}
+
+// Create creates every product in the batch for the calling seller
+func (c *CatalogServer) Create(ctx context.Context, req *catalogpb.CreateRequest) (*catalogpb.CreateResponse, error) {
+	sellerID, err := requireSeller(ctx, "Create")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.service.SaveProducts(ctx, productRequestsFromInputs(req.GetProducts()), sellerID); err != nil {
+		fmt.Printf("[CatalogServer][Create][ERROR] %v\n", err)
+		return nil, toStatus(err, "failed to save products")
+	}
+
+	fmt.Printf("[CatalogServer][Create] Saved %d products for seller %s\n", len(req.GetProducts()), sellerID)
+	return &catalogpb.CreateResponse{Created: int32(len(req.GetProducts()))}, nil
+}
+
+// Update replaces the calling seller's products, creating the missing ones
+func (c *CatalogServer) Update(ctx context.Context, req *catalogpb.UpdateRequest) (*catalogpb.UpdateResponse, error) {
+	sellerID, err := requireSeller(ctx, "Update")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.service.UpdateProducts(ctx, productRequestsFromInputs(req.GetProducts()), sellerID); err != nil {
+		fmt.Printf("[CatalogServer][Update][ERROR] %v\n", err)
+		return nil, toStatus(err, "failed to update products")
+	}
+
+	fmt.Printf("[CatalogServer][Update] Updated %d products for seller %s\n", len(req.GetProducts()), sellerID)
+	return &catalogpb.UpdateResponse{Updated: int32(len(req.GetProducts()))}, nil
+}
+
+// Delete deletes a product owned by the calling seller
+func (c *CatalogServer) Delete(ctx context.Context, req *catalogpb.DeleteRequest) (*catalogpb.DeleteResponse, error) {
+	sellerID, err := requireSeller(ctx, "Delete")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.service.DeleteProductByID(ctx, int(req.GetId()), sellerID, int(req.GetVersion())); err != nil {
+		fmt.Printf("[CatalogServer][Delete][ERROR] %v\n", err)
+		return nil, toStatus(err, "failed to delete product")
+	}
+
+	fmt.Printf("[CatalogServer][Delete] Deleted product ID %d for seller %s\n", req.GetId(), sellerID)
+	return &catalogpb.DeleteResponse{}, nil
+}
+
+// Compare returns the requested products in the order given
+func (c *CatalogServer) Compare(ctx context.Context, req *catalogpb.CompareRequest) (*catalogpb.CompareResponse, error) {
+	// Validate the number of IDs like the HTTP handler does
+	if len(req.GetIds()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "no product IDs provided")
+	}
+	if len(req.GetIds()) > c.maxCompareIDs {
+		return nil, status.Errorf(codes.InvalidArgument, "too many product IDs, at most %d allowed", c.maxCompareIDs)
+	}
+
+	ids := make([]int, 0, len(req.GetIds()))
+	for _, id := range req.GetIds() {
+		ids = append(ids, int(id))
+	}
+	products, err := c.service.CompareProducts(ctx, ids)
+	if err != nil {
+		fmt.Printf("[CatalogServer][Compare][ERROR] %v\n", err)
+		return nil, toStatus(err, "failed to compare products")
+	}
+
+	fmt.Printf("[CatalogServer][Compare] Compared %d products\n", len(products))
+	return &catalogpb.CompareResponse{Products: productsFromResponses(products)}, nil
+}
@@ -0,0 +1,39 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"item-comparison-api/internal/repository"
+	"item-comparison-api/internal/services"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errorCode maps an error returned by the service layer to a gRPC status
+// code, mirroring the HTTP status mapping of the api package
+func errorCode(err error) codes.Code {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return codes.DeadlineExceeded
+	case errors.Is(err, context.Canceled):
+		return codes.Canceled
+	case errors.Is(err, repository.ErrNotFound):
+		return codes.NotFound
+	case errors.Is(err, repository.ErrAlreadyExists):
+		return codes.AlreadyExists
+	case errors.Is(err, repository.ErrVersionConflict):
+		return codes.FailedPrecondition
+	case errors.Is(err, services.ErrForbidden):
+		return codes.PermissionDenied
+	case errors.Is(err, services.ErrValidation):
+		return codes.InvalidArgument
+	default:
+		return codes.Internal
+	}
+}
+
+// toStatus converts a service error to a gRPC status error
+func toStatus(err error, message string) error {
+	return status.Error(errorCode(err), message+": "+err.Error())
+}
@@ -0,0 +1,56 @@
+package rpc
+
+import (
+	"item-comparison-api/internal/dto"
+	"item-comparison-api/internal/rpc/catalogpb"
+)
+
+// Maps from protobuf input to request dto
+func productRequestFromInput(in *catalogpb.ProductInput) dto.ProductRequest {
+	return dto.ProductRequest{
+		ID:             int(in.GetId()),
+		Name:           in.GetName(),
+		Description:    in.GetDescription(),
+		Price:          in.GetPrice(),
+		Brand:          in.GetBrand(),
+		ImageUrl:       in.GetImageUrl(),
+		Rating:         in.GetRating(),
+		Specifications: in.GetSpecifications(),
+		Version:        int(in.GetVersion()),
+	}
+}
+
+// Maps a list of protobuf inputs to request dtos
+func productRequestsFromInputs(inputs []*catalogpb.ProductInput) []dto.ProductRequest {
+	requests := make([]dto.ProductRequest, 0, len(inputs))
+	for _, in := range inputs {
+		requests = append(requests, productRequestFromInput(in))
+	}
+	return requests
+}
+
+// Maps from response dto to protobuf product
+func productFromResponse(p dto.ProductResponse) *catalogpb.Product {
+	return &catalogpb.Product{
+		Id:             int64(p.ID),
+		Name:           p.Name,
+		Description:    p.Description,
+		Price:          p.Price,
+		Brand:          p.Brand,
+		ImageUrl:       p.ImageUrl,
+		Rating:         p.Rating,
+		Specifications: p.Specifications,
+		SellerId:       p.SellerID,
+		Version:        int64(p.Version),
+		UpdatedAt:      p.UpdatedAt,
+	}
+}
+
+// Maps a list of response dtos to protobuf products
+func productsFromResponses(products []dto.ProductResponse) []*catalogpb.Product {
+	result := make([]*catalogpb.Product, 0, len(products))
+	for _, p := range products {
+		result = append(result, productFromResponse(p))
+	}
+	return result
+}
@@ -0,0 +1,117 @@
+// Package rpc serves the catalog over gRPC. The protobuf definition lives in
+// proto/catalog/v1 and the code in catalogpb is generated from it with
+// "buf generate" at the repository root.
+package rpc
+
+import (
+	"context"
+	"item-comparison-api/internal/config"
+	"item-comparison-api/internal/rpc/catalogpb"
+	"log"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/reflection"
+)
+
+// writeMethods are the calls bounded by the write timeout, every other call
+// gets the read timeout
+var writeMethods = map[string]bool{
+	catalogpb.ProductCatalogService_Create_FullMethodName: true,
+	catalogpb.ProductCatalogService_Update_FullMethodName: true,
+	catalogpb.ProductCatalogService_Delete_FullMethodName: true,
+}
+
+// NewServer creates a gRPC server for the catalog with tracing, optional
+// request logging, auth and per-call timeouts, using the TLS settings of the
+// HTTP listener
+func NewServer(cfg *config.Config, catalog *CatalogServer) (*grpc.Server, error) {
+	unary := []grpc.UnaryServerInterceptor{}
+	stream := []grpc.StreamServerInterceptor{}
+	if cfg.Logging.Requests {
+		unary = append(unary, loggingUnaryInterceptor)
+		stream = append(stream, loggingStreamInterceptor)
+	}
+	unary = append(unary, authUnaryInterceptor(cfg.Auth), timeoutUnaryInterceptor(cfg.Limits))
+	stream = append(stream, authStreamInterceptor(cfg.Auth), timeoutStreamInterceptor(cfg.Limits))
+
+	opts := []grpc.ServerOption{
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(stream...),
+		grpc.MaxRecvMsgSize(int(cfg.Limits.MaxBodyBytes)),
+	}
+	if cfg.TLS.Enabled {
+		creds, err := credentials.NewServerTLSFromFile(cfg.TLS.CertFile, cfg.TLS.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.Creds(creds))
+	}
+
+	server := grpc.NewServer(opts...)
+	catalogpb.RegisterProductCatalogServiceServer(server, catalog)
+	// Let tools like grpcurl discover the API
+	reflection.Register(server)
+	return server, nil
+}
+
+// Shutdown stops the server gracefully, cutting remaining calls once ctx is done
+func Shutdown(ctx context.Context, server *grpc.Server) {
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		server.Stop()
+	}
+}
+
+// timeoutFor returns the timeout of a call, like the HTTP route timeouts
+func timeoutFor(limits config.LimitsConfig, method string) time.Duration {
+	if writeMethods[method] {
+		return limits.WriteTimeout
+	}
+	return limits.ReadTimeout
+}
+
+// timeoutUnaryInterceptor bounds unary calls by the read or write timeout
+func timeoutUnaryInterceptor(limits config.LimitsConfig) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, cancel := context.WithTimeout(ctx, timeoutFor(limits, info.FullMethod))
+		defer cancel()
+		return handler(ctx, req)
+	}
+}
+
+// timeoutStreamInterceptor bounds streaming calls by the read timeout
+func timeoutStreamInterceptor(limits config.LimitsConfig) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, cancel := context.WithTimeout(ss.Context(), timeoutFor(limits, info.FullMethod))
+		defer cancel()
+		return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// loggingUnaryInterceptor logs each unary call
+func loggingUnaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	log.Printf("Started gRPC %s", info.FullMethod)
+	resp, err := handler(ctx, req)
+	log.Printf("Completed gRPC %s in %v", info.FullMethod, time.Since(start))
+	return resp, err
+}
+
+// loggingStreamInterceptor logs each streaming call
+func loggingStreamInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	log.Printf("Started gRPC %s", info.FullMethod)
+	err := handler(srv, ss)
+	log.Printf("Completed gRPC %s in %v", info.FullMethod, time.Since(start))
+	return err
+}
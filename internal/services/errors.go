@@ -0,0 +1,39 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"item-comparison-api/internal/repository"
+)
+
+var (
+	// ErrForbidden is returned when a seller writes a product owned by another seller
+	ErrForbidden = errors.New("unauthorized: you do not own this product")
+	// ErrValidation is returned when a product breaks a validation rule
+	ErrValidation = errors.New("validation failed")
+	// ErrInvalidPatch is returned for malformed or unsupported patch documents
+	ErrInvalidPatch = errors.New("invalid patch")
+	// ErrPatchConflict is returned when a JSON Patch test operation fails
+	ErrPatchConflict = errors.New("patch test failed")
+)
+
+// ErrorCode maps an error to a stable, machine-readable code for per-item
+// results of bulk writes and imports
+func ErrorCode(err error) string {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+		return "timeout"
+	case errors.Is(err, repository.ErrNotFound):
+		return "not_found"
+	case errors.Is(err, repository.ErrAlreadyExists):
+		return "already_exists"
+	case errors.Is(err, repository.ErrVersionConflict):
+		return "version_conflict"
+	case errors.Is(err, ErrForbidden):
+		return "forbidden"
+	case errors.Is(err, ErrValidation):
+		return "validation_failed"
+	default:
+		return "internal_error"
+	}
+}
@@ -1,15 +1,20 @@
 package services
 
 import (
+	"context"
 	"item-comparison-api/internal/dto"
 )
 
 // ProductServiceInterface defines the methods for product service operations
 type ProductServiceInterface interface {
-	LoadProducts() ([]dto.ProductResponse, error)
-	SaveProducts(req []dto.ProductRequest, sellerID string) error
-	UpdateProducts(req []dto.ProductRequest, sellerID string) error
-	CompareProducts(ids []int) ([]dto.ProductResponse, error)
-	GetProductByID(id int) (*dto.ProductResponse, error)
-	DeleteProductByID(id int, sellerID string) error
+	LoadProducts(ctx context.Context) ([]dto.ProductResponse, error)
+	SaveProducts(ctx context.Context, req []dto.ProductRequest, sellerID string) error
+	UpdateProducts(ctx context.Context, req []dto.ProductRequest, sellerID string) error
+	SaveProductsBulk(ctx context.Context, req []dto.ProductRequest, sellerID string) []ItemResult
+	UpdateProductsBulk(ctx context.Context, req []dto.ProductRequest, sellerID string) []ItemResult
+	CompareProducts(ctx context.Context, ids []int) ([]dto.ProductResponse, error)
+	GetProductByID(ctx context.Context, id int) (*dto.ProductResponse, error)
+	GetProductByGTIN(ctx context.Context, code string) (*dto.ProductResponse, error)
+	DeleteProductByID(ctx context.Context, id int, sellerID string, version int) error
+	PatchProduct(ctx context.Context, id int, sellerID string, patchType string, patch []byte, version int) (*dto.ProductResponse, error)
 }
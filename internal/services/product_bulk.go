@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"item-comparison-api/internal/dto"
+	"item-comparison-api/internal/models"
+	"time"
+)
+
+// ItemResult is the outcome of one product in a bulk operation. Product is
+// set on success, Err on failure.
+type ItemResult struct {
+	Index   int
+	ID      int
+	Product *dto.ProductResponse
+	Err     error
+}
+
+// SaveProductsBulk saves each product on its own, so invalid or conflicting
+// products don't stop the rest of the batch. Results follow request order.
+func (s *ProductService) SaveProductsBulk(ctx context.Context, req []dto.ProductRequest, sellerID string) []ItemResult {
+	fmt.Printf("[ProductService][SaveProductsBulk] Called with %d products, sellerID: %s\n", len(req), sellerID)
+
+	results := make([]ItemResult, 0, len(req))
+	createdAt := time.Now().Format(time.RFC3339)
+	for i, r := range req {
+		result := ItemResult{Index: i, ID: r.ID}
+		if err := ValidateProductRequest(r); err != nil {
+			result.Err = err
+			results = append(results, result)
+			continue
+		}
+
+		product := newProduct(r, sellerID, createdAt)
+		if err := s.repo.SaveProducts(ctx, []models.Product{product}); err != nil {
+			result.Err = err
+			results = append(results, result)
+			continue
+		}
+
+		response := ProductToResponse(product)
+		result.Product = &response
+		results = append(results, result)
+	}
+
+	fmt.Printf("[ProductService][SaveProductsBulk] Saved %d of %d products\n", countSucceeded(results), len(req))
+	return results
+}
+
+// UpdateProductsBulk updates each product on its own, so invalid, foreign or
+// stale products don't stop the rest of the batch. Results follow request order.
+func (s *ProductService) UpdateProductsBulk(ctx context.Context, req []dto.ProductRequest, sellerID string) []ItemResult {
+	fmt.Printf("[ProductService][UpdateProductsBulk] Called with %d products, sellerID: %s\n", len(req), sellerID)
+
+	results := make([]ItemResult, 0, len(req))
+	updatedAt := time.Now().Format(time.RFC3339)
+	for i, r := range req {
+		result := ItemResult{Index: i, ID: r.ID}
+		if err := ValidateProductRequest(r); err != nil {
+			result.Err = err
+			results = append(results, result)
+			continue
+		}
+
+		product, err := s.prepareUpdate(ctx, r, sellerID, updatedAt)
+		if err == nil {
+			err = s.repo.UpdateProducts(ctx, []models.Product{product})
+		}
+		if err != nil {
+			result.Err = err
+			results = append(results, result)
+			continue
+		}
+
+		response := ProductToResponse(product)
+		result.Product = &response
+		results = append(results, result)
+	}
+
+	fmt.Printf("[ProductService][UpdateProductsBulk] Updated %d of %d products\n", countSucceeded(results), len(req))
+	return results
+}
+
+// countSucceeded returns how many results carry no error
+func countSucceeded(results []ItemResult) int {
+	n := 0
+	for _, r := range results {
+		if r.Err == nil {
+			n++
+		}
+	}
+	return n
+}
@@ -13,9 +13,29 @@ func ProductFromRequest(req dto.ProductRequest) models.Product {
 		Description:    req.Description,
 		Price:          req.Price,
 		Brand:          req.Brand,
+		GTIN:           req.GTIN,
+		MPN:            req.MPN,
 		ImageUrl:       req.ImageUrl,
 		Rating:         req.Rating,
 		Specifications: req.Specifications,
+		Version:        req.Version,
+	}
+}
+
+// Maps from model to request dto, used as the document a patch applies to
+func ProductToRequest(p models.Product) dto.ProductRequest {
+	return dto.ProductRequest{
+		ID:             p.ID,
+		Name:           p.Name,
+		Description:    p.Description,
+		Price:          p.Price,
+		Brand:          p.Brand,
+		GTIN:           p.GTIN,
+		MPN:            p.MPN,
+		ImageUrl:       p.ImageUrl,
+		Rating:         p.Rating,
+		Specifications: p.Specifications,
+		Version:        p.Version,
 	}
 }
 
@@ -27,10 +47,14 @@ func ProductToResponse(p models.Product) dto.ProductResponse {
 		Description:    p.Description,
 		Price:          p.Price,
 		Brand:          p.Brand,
+		GTIN:           p.GTIN,
+		MPN:            p.MPN,
 		ImageUrl:       p.ImageUrl,
 		Rating:         p.Rating,
 		Specifications: p.Specifications,
 		SellerID:       p.SellerID,
+		Version:        p.Version,
+		UpdatedAt:      updatedAt(p),
 	}
 }
 
@@ -51,3 +75,12 @@ func ProductsToResponses(products []models.Product) []dto.ProductResponse {
 	}
 	return responses
 }
+
+// updatedAt returns when the product last changed, products written before
+// versioning only have CreatedAt
+func updatedAt(p models.Product) string {
+	if p.UpdatedAt != "" {
+		return p.UpdatedAt
+	}
+	return p.CreatedAt
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"item-comparison-api/internal/dto"
+	"item-comparison-api/internal/models"
+	"item-comparison-api/internal/repository"
+	"time"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+)
+
+// Supported patch document media types
+const (
+	MergePatchType = "application/merge-patch+json"
+	JSONPatchType  = "application/json-patch+json"
+)
+
+// PatchProduct applies a JSON Merge Patch (RFC 7386) or JSON Patch (RFC 6902)
+// document to a product owned by the seller. The patch works on the request
+// representation of the product, so specifications can be patched per key,
+// e.g. {"op": "replace", "path": "/specifications/color", "value": "red"}.
+// A non-zero version, or a version set by the patch, must match the current one.
+func (s *ProductService) PatchProduct(ctx context.Context, id int, sellerID string, patchType string, patch []byte, version int) (*dto.ProductResponse, error) {
+	fmt.Printf("[ProductService][PatchProduct] Called with ID: %d, sellerID: %s, type: %s\n", id, sellerID, patchType)
+
+	existingProduct, err := s.repo.GetProductByID(ctx, id)
+	if err != nil {
+		fmt.Printf("[ProductService][PatchProduct][ERROR] %v\n", err)
+		return nil, err
+	}
+
+	// if the sellerID does not match, return an error
+	if existingProduct.SellerID != sellerID {
+		fmt.Printf("[ProductService][PatchProduct][ERROR] Unauthorized patch attempt for product ID: %d\n", id)
+		return nil, ErrForbidden
+	}
+	if version != 0 && version != existingProduct.Version {
+		fmt.Printf("[ProductService][PatchProduct][ERROR] Stale version %d for product ID: %d\n", version, id)
+		return nil, fmt.Errorf("product with ID %d is at version %d, expected %d: %w", id, existingProduct.Version, version, repository.ErrVersionConflict)
+	}
+
+	// Apply the patch to the current product
+	document, err := patchDocument(ProductToRequest(*existingProduct))
+	if err != nil {
+		return nil, err
+	}
+	patched, err := applyPatch(patchType, document, patch)
+	if err != nil {
+		fmt.Printf("[ProductService][PatchProduct][ERROR] %v\n", err)
+		return nil, err
+	}
+	var patchedRequest dto.ProductRequest
+	if err := json.Unmarshal(patched, &patchedRequest); err != nil {
+		fmt.Printf("[ProductService][PatchProduct][ERROR] %v\n", err)
+		return nil, fmt.Errorf("patched product is not a valid product: %v: %w", err, ErrInvalidPatch)
+	}
+
+	// The ID identifies the resource and can't be patched; a patched version
+	// works like If-Match
+	if patchedRequest.ID != id {
+		return nil, fmt.Errorf("product ID can't be changed from %d to %d: %w", id, patchedRequest.ID, ErrInvalidPatch)
+	}
+	if patchedRequest.Version != existingProduct.Version {
+		return nil, fmt.Errorf("product with ID %d is at version %d, expected %d: %w", id, existingProduct.Version, patchedRequest.Version, repository.ErrVersionConflict)
+	}
+	if err := ValidateProductRequest(patchedRequest); err != nil {
+		fmt.Printf("[ProductService][PatchProduct][ERROR] %v\n", err)
+		return nil, err
+	}
+
+	// Store the result as the next version
+	product := ProductFromRequest(patchedRequest)
+	product.SellerID = existingProduct.SellerID
+	product.CreatedAt = existingProduct.CreatedAt
+	product.UpdatedAt = time.Now().Format(time.RFC3339)
+	product.Version = existingProduct.Version + 1
+
+	fmt.Printf("[ProductService][PatchProduct] Updating patched product in repository\n")
+	if err := s.repo.UpdateProducts(ctx, []models.Product{product}); err != nil {
+		fmt.Printf("[ProductService][PatchProduct][ERROR] %v\n", err)
+		return nil, err
+	}
+
+	response := ProductToResponse(product)
+	fmt.Printf("[ProductService][PatchProduct] Successfully patched product ID: %d to version %d\n", id, product.Version)
+	return &response, nil
+}
+
+// patchDocument encodes the product with every field present, so JSON Patch
+// operations like replace work on empty fields and add works on specifications
+func patchDocument(req dto.ProductRequest) ([]byte, error) {
+	if req.Specifications == nil {
+		req.Specifications = map[string]string{}
+	}
+	encoded, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode product %d: %w", req.ID, err)
+	}
+
+	var document map[string]any
+	if err := json.Unmarshal(encoded, &document); err != nil {
+		return nil, fmt.Errorf("failed to encode product %d: %w", req.ID, err)
+	}
+	document["description"] = req.Description
+	document["version"] = req.Version
+	return json.Marshal(document)
+}
+
+// applyPatch applies a patch document of the given media type
+func applyPatch(patchType string, document, patch []byte) ([]byte, error) {
+	switch patchType {
+	case MergePatchType:
+		patched, err := jsonpatch.MergePatch(document, patch)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %w", err, ErrInvalidPatch)
+		}
+		return patched, nil
+	case JSONPatchType:
+		operations, err := jsonpatch.DecodePatch(patch)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %w", err, ErrInvalidPatch)
+		}
+		patched, err := operations.Apply(document)
+		if errors.Is(err, jsonpatch.ErrTestFailed) {
+			return nil, fmt.Errorf("%v: %w", err, ErrPatchConflict)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%v: %w", err, ErrInvalidPatch)
+		}
+		return patched, nil
+	default:
+		return nil, fmt.Errorf("unsupported patch type %q: %w", patchType, ErrInvalidPatch)
+	}
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"item-comparison-api/internal/models"
+	"item-comparison-api/internal/repository"
+)
+
+func TestPatchProduct(t *testing.T) {
+	tests := []struct {
+		name      string
+		seller    string
+		patchType string
+		patch     string
+		version   int
+		wantErr   error
+		wantPrice float32
+		wantSpecs map[string]string
+	}{
+		{"merge patch", "s1", MergePatchType, `{"price":20,"specifications":{"color":null}}`, 0, nil, 20, map[string]string{"storage": "128GB"}},
+		{"json patch", "s1", JSONPatchType, `[{"op":"replace","path":"/price","value":20},{"op":"add","path":"/specifications/weight","value":"200g"}]`, 0,
+			nil, 20, map[string]string{"color": "black", "storage": "128GB", "weight": "200g"}},
+		{"passing test operation", "s1", JSONPatchType, `[{"op":"test","path":"/version","value":2},{"op":"replace","path":"/price","value":20}]`, 0,
+			nil, 20, map[string]string{"color": "black", "storage": "128GB"}},
+		{"current If-Match version", "s1", MergePatchType, `{"price":20}`, 2, nil, 20, map[string]string{"color": "black", "storage": "128GB"}},
+		{"failing test operation", "s1", JSONPatchType, `[{"op":"test","path":"/price","value":99},{"op":"replace","path":"/price","value":20}]`, 0,
+			ErrPatchConflict, 10, nil},
+		{"stale If-Match version", "s1", MergePatchType, `{"price":20}`, 1, repository.ErrVersionConflict, 10, nil},
+		{"stale version in the patch", "s1", MergePatchType, `{"price":20,"version":1}`, 0, repository.ErrVersionConflict, 10, nil},
+		{"changed ID", "s1", MergePatchType, `{"id":2}`, 0, ErrInvalidPatch, 10, nil},
+		{"invalid result", "s1", MergePatchType, `{"price":-1}`, 0, ErrValidation, 10, nil},
+		{"malformed patch", "s1", JSONPatchType, `{"op":"replace"}`, 0, ErrInvalidPatch, 10, nil},
+		{"missing path", "s1", JSONPatchType, `[{"op":"replace","path":"/missing/field","value":1}]`, 0, ErrInvalidPatch, 10, nil},
+		{"unsupported type", "s1", "application/json", `{"price":20}`, 0, ErrInvalidPatch, 10, nil},
+		{"other seller", "s2", MergePatchType, `{"price":20}`, 0, ErrForbidden, 10, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := repository.NewProductRepoMemory()
+			stored := models.Product{ID: 1, Name: "Phone", Price: 10, SellerID: "s1", Version: 2,
+				Specifications: map[string]string{"color": "black", "storage": "128GB"}}
+			if err := repo.SaveProducts(ctx, []models.Product{stored}); err != nil {
+				t.Fatal(err)
+			}
+
+			patched, err := NewProductService(repo).PatchProduct(ctx, 1, tt.seller, tt.patchType, []byte(tt.patch), tt.version)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("PatchProduct error = %v, want %v", err, tt.wantErr)
+			}
+			current, _ := repo.GetProductByID(ctx, 1)
+			if current.Price != tt.wantPrice {
+				t.Errorf("stored price = %v, want %v", current.Price, tt.wantPrice)
+			}
+			if err != nil {
+				if current.Version != 2 {
+					t.Errorf("failed patch changed the version to %d", current.Version)
+				}
+				return
+			}
+			if patched.Version != 3 || current.Version != 3 {
+				t.Errorf("version = %d, stored %d, want 3", patched.Version, current.Version)
+			}
+			if len(current.Specifications) != len(tt.wantSpecs) {
+				t.Errorf("specifications = %v, want %v", current.Specifications, tt.wantSpecs)
+			}
+			for key, value := range tt.wantSpecs {
+				if current.Specifications[key] != value {
+					t.Errorf("specifications = %v, want %v", current.Specifications, tt.wantSpecs)
+					break
+				}
+			}
+		})
+	}
+}
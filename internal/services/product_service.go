@@ -1,11 +1,13 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"item-comparison-api/internal/dto"
+	"item-comparison-api/internal/gtin"
 	"item-comparison-api/internal/models"
 	"item-comparison-api/internal/repository"
-	"strings"
 	"time"
 )
 
@@ -14,18 +16,19 @@ type ProductService struct {
 	repo repository.ProductRepo
 }
 
-// NewProductService creates a new instance of ProductService with the given repository.
+// NewProductService creates a new instance of ProductService with the given
+// repository. Change events are recorded by the repository's outbox.
 func NewProductService(r repository.ProductRepo) *ProductService {
 	fmt.Printf("[ProductService][NewProductService] Initializing ProductService\n")
 	return &ProductService{repo: r}
 }
 
 // LoadProducts loads all products from the repository.
-func (s *ProductService) LoadProducts() ([]dto.ProductResponse, error) {
+func (s *ProductService) LoadProducts(ctx context.Context) ([]dto.ProductResponse, error) {
 	fmt.Printf("[ProductService][LoadProducts] Called\n")
 
 	fmt.Printf("[ProductService][LoadProducts] Loading all products from repository\n")
-	products, err := s.repo.LoadProducts()
+	products, err := s.repo.LoadProducts(ctx)
 	if err != nil {
 		fmt.Printf("[ProductService][LoadProducts][ERROR] %v\n", err)
 		return nil, err
@@ -37,21 +40,22 @@ func (s *ProductService) LoadProducts() ([]dto.ProductResponse, error) {
 }
 
 // SaveProducts saves all products to the repository.
-func (s *ProductService) SaveProducts(req []dto.ProductRequest, sellerID string) error {
+func (s *ProductService) SaveProducts(ctx context.Context, req []dto.ProductRequest, sellerID string) error {
 	fmt.Printf("[ProductService][SaveProducts] Called with %d products, sellerID: %s\n", len(req), sellerID)
+	if err := validateProductRequests(req); err != nil {
+		fmt.Printf("[ProductService][SaveProducts][ERROR] %v\n", err)
+		return err
+	}
 
 	// Define a slice to hold products to save and set CreatedAt and SellerID for each product
 	productsToSave := make([]models.Product, 0, len(req))
 	createdAt := time.Now().Format(time.RFC3339)
 	for _, r := range req {
-		product := ProductFromRequest(r)
-		product.CreatedAt = createdAt
-		product.SellerID = sellerID
-		productsToSave = append(productsToSave, product)
+		productsToSave = append(productsToSave, newProduct(r, sellerID, createdAt))
 	}
 
 	fmt.Printf("[ProductService][SaveProducts] Saving products to repository\n")
-	if err := s.repo.SaveProducts(productsToSave); err != nil {
+	if err := s.repo.SaveProducts(ctx, productsToSave); err != nil {
 		fmt.Printf("[ProductService][SaveProducts][ERROR] %v\n", err)
 		return err
 	}
@@ -60,41 +64,30 @@ func (s *ProductService) SaveProducts(req []dto.ProductRequest, sellerID string)
 	return nil
 }
 
-func (s *ProductService) UpdateProducts(req []dto.ProductRequest, sellerID string) error {
+// UpdateProducts replaces products owned by the seller and creates missing
+// ones. A non-zero Version in a request must match the current version.
+func (s *ProductService) UpdateProducts(ctx context.Context, req []dto.ProductRequest, sellerID string) error {
 	fmt.Printf("[ProductService][UpdateProducts] Called with %d products, sellerID: %s\n", len(req), sellerID)
+	if err := validateProductRequests(req); err != nil {
+		fmt.Printf("[ProductService][UpdateProducts][ERROR] %v\n", err)
+		return err
+	}
 
 	// Define a slice to hold the products to update
 	productsToUpdate := make([]models.Product, 0, len(req))
-	// Convert requests to models
-	productsRequest := ProductsFromRequests(req)
-
-	for _, product := range productsRequest {
-		fmt.Printf("[ProductService][UpdateProducts] Getting product by ID: %d from repository\n", product.ID)
 
-		// Retrieve the existing product from the repository
-		existingProduct, err := s.repo.GetProductByID(product.ID)
+	updatedAt := time.Now().Format(time.RFC3339)
+	for _, r := range req {
+		product, err := s.prepareUpdate(ctx, r, sellerID, updatedAt)
 		if err != nil {
-			// If the product does not exist, we provide CreatedAt and SellerID for the new product
-			if strings.Contains(err.Error(), "does not exist") {
-				createdAt := time.Now().Format(time.RFC3339)
-				product.CreatedAt = createdAt
-				product.SellerID = sellerID
-			}
-
-			// if the sellerID does not match, return an error
-		} else if existingProduct.SellerID != sellerID {
-			fmt.Printf("[ProductService][UpdateProducts][ERROR] Unauthorized update attempt for product ID: %d\n", product.ID)
-			return fmt.Errorf("unauthorized: you do not own this product")
+			fmt.Printf("[ProductService][UpdateProducts][ERROR] %v\n", err)
+			return err
 		}
-
-		// If the product exists, retain its CreatedAt and SellerID
-		product.CreatedAt = existingProduct.CreatedAt
-		product.SellerID = existingProduct.SellerID
 		productsToUpdate = append(productsToUpdate, product)
 	}
 
 	fmt.Printf("[ProductService][UpdateProducts] Updating products in repository\n")
-	if err := s.repo.UpdateProducts(productsToUpdate); err != nil {
+	if err := s.repo.UpdateProducts(ctx, productsToUpdate); err != nil {
 		fmt.Printf("[ProductService][UpdateProducts][ERROR] %v\n", err)
 		return err
 	}
@@ -103,10 +96,63 @@ func (s *ProductService) UpdateProducts(req []dto.ProductRequest, sellerID strin
 	return nil
 }
 
-func (s *ProductService) CompareProducts(ids []int) ([]dto.ProductResponse, error) {
+// newProduct builds a new product owned by the seller from a request
+func newProduct(req dto.ProductRequest, sellerID string, createdAt string) models.Product {
+	product := ProductFromRequest(req)
+	product.CreatedAt = createdAt
+	product.UpdatedAt = createdAt
+	product.SellerID = sellerID
+	product.Version = 1
+	return product
+}
+
+// prepareUpdate builds the next version of a product from an update request,
+// checking ownership and the expected version against the stored product
+func (s *ProductService) prepareUpdate(ctx context.Context, req dto.ProductRequest, sellerID string, updatedAt string) (models.Product, error) {
+	product := ProductFromRequest(req)
+	fmt.Printf("[ProductService][UpdateProducts] Getting product by ID: %d from repository\n", product.ID)
+
+	// Retrieve the existing product from the repository
+	existingProduct, err := s.repo.GetProductByID(ctx, product.ID)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		// A version can only be expected from a product that exists
+		if product.Version != 0 {
+			return product, fmt.Errorf("product with ID %d %w", product.ID, repository.ErrVersionConflict)
+		}
+
+		// If the product does not exist, we provide CreatedAt and SellerID for the new product
+		product.CreatedAt = updatedAt
+		product.SellerID = sellerID
+		product.Version = 1
+	case err != nil:
+		return product, err
+	default:
+		// if the sellerID does not match, return an error
+		if existingProduct.SellerID != sellerID {
+			fmt.Printf("[ProductService][UpdateProducts][ERROR] Unauthorized update attempt for product ID: %d\n", product.ID)
+			return product, ErrForbidden
+		}
+
+		// Reject updates based on a version that is no longer current
+		if product.Version != 0 && product.Version != existingProduct.Version {
+			return product, fmt.Errorf("product with ID %d is at version %d, expected %d: %w", product.ID, existingProduct.Version, product.Version, repository.ErrVersionConflict)
+		}
+
+		// If the product exists, retain its CreatedAt and SellerID
+		product.CreatedAt = existingProduct.CreatedAt
+		product.SellerID = existingProduct.SellerID
+		product.Version = existingProduct.Version + 1
+	}
+
+	product.UpdatedAt = updatedAt
+	return product, nil
+}
+
+func (s *ProductService) CompareProducts(ctx context.Context, ids []int) ([]dto.ProductResponse, error) {
 	fmt.Printf("[ProductService][CompareProducts] Called with IDs: %v\n", ids)
 	fmt.Printf("[ProductService][CompareProducts] Comparing products by IDs in repository\n")
-	products, err := s.repo.CompareProducts(ids)
+	products, err := s.repo.CompareProducts(ctx, ids)
 	if err != nil {
 		fmt.Printf("[ProductService][CompareProducts][ERROR] %v\n", err)
 		return nil, err
@@ -117,10 +163,10 @@ func (s *ProductService) CompareProducts(ids []int) ([]dto.ProductResponse, erro
 	return responseProducts, nil
 }
 
-func (s *ProductService) GetProductByID(id int) (*dto.ProductResponse, error) {
+func (s *ProductService) GetProductByID(ctx context.Context, id int) (*dto.ProductResponse, error) {
 	fmt.Printf("[ProductService][GetProductByID] Called with ID: %d\n", id)
 	fmt.Printf("[ProductService][GetProductByID] Getting product by ID from repository\n")
-	product, err := s.repo.GetProductByID(id)
+	product, err := s.repo.GetProductByID(ctx, id)
 	if err != nil {
 		fmt.Printf("[ProductService][GetProductByID][ERROR] %v\n", err)
 		return nil, err
@@ -131,10 +177,33 @@ func (s *ProductService) GetProductByID(id int) (*dto.ProductResponse, error) {
 	return &response, nil
 }
 
-func (s *ProductService) DeleteProductByID(id int, sellerID string) error {
-	fmt.Printf("[ProductService][DeleteProductByID] Called with ID: %d, sellerID: %s\n", id, sellerID)
+// GetProductByGTIN returns the product with the GTIN, given as any of
+// GTIN-8, -12, -13 or -14
+func (s *ProductService) GetProductByGTIN(ctx context.Context, code string) (*dto.ProductResponse, error) {
+	fmt.Printf("[ProductService][GetProductByGTIN] Called with GTIN: %s\n", code)
+	if _, err := gtin.Normalize(code); err != nil {
+		fmt.Printf("[ProductService][GetProductByGTIN][ERROR] %v\n", err)
+		return nil, fmt.Errorf("%s: %w", err, ErrValidation)
+	}
+
+	fmt.Printf("[ProductService][GetProductByGTIN] Getting product by GTIN from repository\n")
+	product, err := s.repo.GetProductByGTIN(ctx, code)
+	if err != nil {
+		fmt.Printf("[ProductService][GetProductByGTIN][ERROR] %v\n", err)
+		return nil, err
+	}
+
+	response := ProductToResponse(*product)
+	fmt.Printf("[ProductService][GetProductByGTIN] Successfully loaded product ID: %d\n", product.ID)
+	return &response, nil
+}
+
+// DeleteProductByID deletes a product owned by the seller. A non-zero version
+// must match the current version of the product.
+func (s *ProductService) DeleteProductByID(ctx context.Context, id int, sellerID string, version int) error {
+	fmt.Printf("[ProductService][DeleteProductByID] Called with ID: %d, sellerID: %s, version: %d\n", id, sellerID, version)
 	fmt.Printf("[ProductService][DeleteProductByID] Getting product by ID from repository\n")
-	product, err := s.repo.GetProductByID(id)
+	product, err := s.repo.GetProductByID(ctx, id)
 	if err != nil {
 		fmt.Printf("[ProductService][DeleteProductByID][ERROR] %v\n", err)
 		return err
@@ -143,11 +212,11 @@ func (s *ProductService) DeleteProductByID(id int, sellerID string) error {
 	// if the sellerID does not match, return an error
 	if product.SellerID != sellerID {
 		fmt.Printf("[ProductService][DeleteProductByID][ERROR] Unauthorized delete attempt for product ID: %d\n", id)
-		return fmt.Errorf("unauthorized: you do not own this product")
+		return ErrForbidden
 	}
 
 	fmt.Printf("[ProductService][DeleteProductByID] Deleting product by ID in repository\n")
-	if err := s.repo.DeleteByID(id); err != nil {
+	if err := s.repo.DeleteByID(ctx, id, version); err != nil {
 		fmt.Printf("[ProductService][DeleteProductByID][ERROR] %v\n", err)
 		return err
 	}
@@ -0,0 +1,50 @@
+package services
+
+import (
+	"fmt"
+	"item-comparison-api/internal/dto"
+	"item-comparison-api/internal/gtin"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxMPNLength is the longest manufacturer part number accepted
+const maxMPNLength = 70
+
+// ValidateProductRequest checks the rules declared in the validate tags of
+// dto.ProductRequest and reports every broken rule at once
+func ValidateProductRequest(req dto.ProductRequest) error {
+	var problems []string
+	if strings.TrimSpace(req.Name) == "" {
+		problems = append(problems, "name is required")
+	}
+	if req.Price <= 0 {
+		problems = append(problems, "price must be greater than 0")
+	}
+	if req.GTIN != "" {
+		if _, err := gtin.Normalize(req.GTIN); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	if req.MPN != "" && strings.TrimSpace(req.MPN) != req.MPN {
+		problems = append(problems, "mpn must not start or end with spaces")
+	}
+	if utf8.RuneCountInString(req.MPN) > maxMPNLength {
+		problems = append(problems, fmt.Sprintf("mpn must be at most %d characters", maxMPNLength))
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("product %d: %s: %w", req.ID, strings.Join(problems, ", "), ErrValidation)
+	}
+	return nil
+}
+
+// validateProductRequests validates a batch, stopping at the first invalid product
+func validateProductRequests(req []dto.ProductRequest) error {
+	for _, r := range req {
+		if err := ValidateProductRequest(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
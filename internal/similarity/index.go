@@ -0,0 +1,292 @@
+// Package similarity finds products similar to a given one. The index holds
+// every product in memory; it is loaded from the repository at startup and
+// kept up to date with the changes relayed from the outbox.
+package similarity
+
+import (
+	"context"
+	"fmt"
+	"item-comparison-api/internal/dto"
+	"item-comparison-api/internal/models"
+	"item-comparison-api/internal/repository"
+	"item-comparison-api/internal/services"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Weights of the signals in the overall score, which is between 0 and 1
+const (
+	weightSpecifications = 0.4
+	weightCategory       = 0.25
+	weightPrice          = 0.2
+	weightBrand          = 0.15
+)
+
+// document is an indexed product with its normalized signals
+type document struct {
+	product  dto.ProductResponse
+	brand    string
+	category string
+	tokens   []string
+}
+
+// Index scores products against each other by brand, category, price
+// proximity and TF-IDF weighted cosine similarity of their specifications
+type Index struct {
+	mu   sync.RWMutex
+	docs map[int]*document
+	// df counts the products each specification token occurs in
+	df map[string]int
+}
+
+// NewIndex creates an empty Index
+func NewIndex() *Index {
+	return &Index{
+		docs: make(map[int]*document),
+		df:   make(map[string]int),
+	}
+}
+
+// Load indexes every product of the repository
+func (x *Index) Load(ctx context.Context, repo repository.ProductRepo) error {
+	fmt.Printf("[SimilarityIndex][Load] Loading products from repository\n")
+	products, err := repo.LoadProducts(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load products for the similarity index: %w", err)
+	}
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	for _, p := range products {
+		x.put(services.ProductToResponse(p))
+	}
+	fmt.Printf("[SimilarityIndex][Load] Indexed %d products\n", len(x.docs))
+	return nil
+}
+
+// Name identifies the index as an outbox sink
+func (x *Index) Name() string {
+	return "similarity"
+}
+
+// Send applies product changes to the index. Applying a change again
+// leaves the index as it is.
+func (x *Index) Send(ctx context.Context, events []dto.ProductEvent) error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	for _, event := range events {
+		switch {
+		case event.Type == models.ChangeDeleted:
+			x.remove(event.ProductID)
+		case event.Product != nil:
+			x.put(*event.Product)
+		}
+	}
+	return nil
+}
+
+// Similar returns up to limit indexed products most similar to p, best
+// first. The product itself and products scoring 0 are left out.
+func (x *Index) Similar(p dto.ProductResponse, limit int) []dto.SimilarProduct {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	target := newDocument(p)
+	targetVector, targetNorm := x.vector(target.tokens)
+
+	var results []dto.SimilarProduct
+	for id, doc := range x.docs {
+		if id == p.ID {
+			continue
+		}
+		breakdown := dto.SimilarityBreakdown{
+			Brand:          match(target.brand, doc.brand),
+			Category:       match(target.category, doc.category),
+			Price:          priceProximity(p.Price, doc.product.Price),
+			Specifications: x.cosine(targetVector, targetNorm, doc.tokens),
+		}
+		// Filter on the rounded score, so no result is listed with score 0
+		score := round(weightBrand*breakdown.Brand + weightCategory*breakdown.Category +
+			weightPrice*breakdown.Price + weightSpecifications*breakdown.Specifications)
+		if score <= 0 {
+			continue
+		}
+		breakdown.Price = round(breakdown.Price)
+		breakdown.Specifications = round(breakdown.Specifications)
+		results = append(results, dto.SimilarProduct{
+			Product:   doc.product,
+			Score:     score,
+			Breakdown: breakdown,
+		})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Product.ID < results[j].Product.ID
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// put adds or replaces a product. Callers hold mu.
+func (x *Index) put(p dto.ProductResponse) {
+	x.remove(p.ID)
+	doc := newDocument(p)
+	for _, token := range doc.tokens {
+		x.df[token]++
+	}
+	x.docs[p.ID] = doc
+}
+
+// remove drops a product. Callers hold mu.
+func (x *Index) remove(id int) {
+	doc, ok := x.docs[id]
+	if !ok {
+		return
+	}
+	for _, token := range doc.tokens {
+		if x.df[token]--; x.df[token] <= 0 {
+			delete(x.df, token)
+		}
+	}
+	delete(x.docs, id)
+}
+
+// idf returns the smoothed inverse document frequency of a token, so tokens
+// most products share count little
+func (x *Index) idf(token string) float64 {
+	n := float64(len(x.docs))
+	return math.Log((n+1)/(float64(x.df[token])+1)) + 1
+}
+
+// vector returns the TF-IDF weights of the tokens and their norm. Tokens
+// occur at most once per product, so the weight is the IDF.
+func (x *Index) vector(tokens []string) (map[string]float64, float64) {
+	vector := make(map[string]float64, len(tokens))
+	var sum float64
+	for _, token := range tokens {
+		weight := x.idf(token)
+		vector[token] = weight
+		sum += weight * weight
+	}
+	return vector, math.Sqrt(sum)
+}
+
+// cosine returns the cosine similarity between a weighted vector and the
+// tokens of another product
+func (x *Index) cosine(vector map[string]float64, norm float64, tokens []string) float64 {
+	if norm == 0 || len(tokens) == 0 {
+		return 0
+	}
+	var dot, sum float64
+	for _, token := range tokens {
+		weight := x.idf(token)
+		dot += vector[token] * weight
+		sum += weight * weight
+	}
+	if dot == 0 {
+		return 0
+	}
+	return dot / (norm * math.Sqrt(sum))
+}
+
+// newDocument normalizes the signals of a product
+func newDocument(p dto.ProductResponse) *document {
+	return &document{
+		product:  p,
+		brand:    normalize(p.Brand),
+		category: normalize(p.Specifications.Category()),
+		tokens:   specTokens(p.Specifications),
+	}
+}
+
+// specTokens turns specifications into key:word tokens, each once, so
+// "8 GB" and "8GB" of ram both share ram:8 and the unit. The category is
+// scored on its own and left out.
+func specTokens(specs dto.Specifications) []string {
+	seen := make(map[string]bool)
+	var tokens []string
+	for key, value := range specs {
+		key = normalize(key)
+		if key == dto.CategorySpec {
+			continue
+		}
+		for _, word := range words(value) {
+			token := key + ":" + word
+			if !seen[token] {
+				seen[token] = true
+				tokens = append(tokens, token)
+			}
+		}
+	}
+	sort.Strings(tokens)
+	return tokens
+}
+
+// words splits a value into lower case runs of letters and of digits
+func words(value string) []string {
+	var words []string
+	var current []rune
+	kind := 0
+	flush := func() {
+		if len(current) > 0 {
+			words = append(words, string(current))
+			current = current[:0]
+		}
+	}
+	for _, r := range strings.ToLower(value) {
+		switch {
+		case unicode.IsLetter(r):
+			if kind != 1 {
+				flush()
+			}
+			kind = 1
+		case unicode.IsDigit(r):
+			if kind != 2 {
+				flush()
+			}
+			kind = 2
+		default:
+			flush()
+			kind = 0
+			continue
+		}
+		current = append(current, r)
+	}
+	flush()
+	return words
+}
+
+// normalize lower-cases and trims a value for exact comparison
+func normalize(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
+
+// match scores 1 for equal, non-empty values
+func match(a, b string) float64 {
+	if a != "" && a == b {
+		return 1
+	}
+	return 0
+}
+
+// priceProximity scores how close two prices are relative to the higher one
+func priceProximity(a, b float32) float64 {
+	high := math.Max(float64(a), float64(b))
+	if high <= 0 {
+		return 0
+	}
+	return 1 - math.Abs(float64(a)-float64(b))/high
+}
+
+// round keeps scores readable
+func round(f float64) float64 {
+	return math.Round(f*1000) / 1000
+}
@@ -0,0 +1,127 @@
+package similarity
+
+import (
+	"context"
+	"math"
+	"slices"
+	"testing"
+
+	"item-comparison-api/internal/dto"
+	"item-comparison-api/internal/models"
+)
+
+func TestPriceProximity(t *testing.T) {
+	tests := []struct {
+		a, b float32
+		want float64
+	}{
+		{100, 100, 1},
+		{100, 50, 0.5},
+		{50, 100, 0.5},
+		{100, 0, 0},
+		{0, 0, 0},
+		{100, 75, 0.75},
+	}
+
+	for _, tt := range tests {
+		if got := priceProximity(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("priceProximity(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"acme", "acme", 1},
+		{"acme", "other", 0},
+		{"", "", 0},
+	}
+
+	for _, tt := range tests {
+		if got := match(tt.a, tt.b); got != tt.want {
+			t.Errorf("match(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+// testIndex indexes products that share less and less with phone
+func testIndex(t *testing.T) (*Index, dto.ProductResponse) {
+	t.Helper()
+	phone := dto.ProductResponse{ID: 1, Name: "Phone X", Brand: "Acme", Price: 100,
+		Specifications: dto.Specifications{"category": "phones", "color": "black", "storage": "128GB"}}
+	products := []dto.ProductResponse{
+		phone,
+		// Same brand, category, price and specifications
+		{ID: 2, Name: "Phone X2", Brand: "acme ", Price: 100,
+			Specifications: dto.Specifications{"category": "Phones", "color": "black", "storage": "128GB"}},
+		// Same category, half the price, one specification in common
+		{ID: 3, Name: "Phone Y", Brand: "Other", Price: 50,
+			Specifications: dto.Specifications{"category": "phones", "color": "black", "storage": "256GB"}},
+		// Only the price is close
+		{ID: 4, Name: "Chair", Brand: "Furniture Co", Price: 90,
+			Specifications: dto.Specifications{"category": "chairs", "material": "oak"}},
+		// Nothing in common but a price so far off its score rounds to 0
+		{ID: 5, Name: "Yacht", Brand: "Boats", Price: 10000000,
+			Specifications: dto.Specifications{"category": "boats"}},
+	}
+
+	x := NewIndex()
+	events := make([]dto.ProductEvent, 0, len(products))
+	for i := range products {
+		events = append(events, dto.ProductEvent{Type: models.ChangeCreated, ProductID: products[i].ID, Product: &products[i]})
+	}
+	if err := x.Send(context.Background(), events); err != nil {
+		t.Fatal(err)
+	}
+	return x, phone
+}
+
+func TestSimilar(t *testing.T) {
+	x, phone := testIndex(t)
+	tests := []struct {
+		name    string
+		limit   int
+		wantIDs []int
+	}{
+		{"best first without the product itself or zero scores", 10, []int{2, 3, 4}},
+		{"limited", 2, []int{2, 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := x.Similar(phone, tt.limit)
+			var ids []int
+			for _, r := range results {
+				ids = append(ids, r.Product.ID)
+				if r.Score <= 0 || r.Score > 1 {
+					t.Errorf("product %d scored %v, want a score in (0, 1]", r.Product.ID, r.Score)
+				}
+			}
+			if !slices.Equal(ids, tt.wantIDs) {
+				t.Errorf("similar products = %v, want %v", ids, tt.wantIDs)
+			}
+		})
+	}
+
+	results := x.Similar(phone, 1)
+	if best := results[0]; best.Score != 1 || best.Breakdown.Brand != 1 || best.Breakdown.Category != 1 ||
+		best.Breakdown.Price != 1 || best.Breakdown.Specifications != 1 {
+		t.Errorf("identical product scored %v with %+v, want 1 on every signal", best.Score, best.Breakdown)
+	}
+}
+
+// TestSimilarAfterDelete checks that deleted products are no longer suggested
+func TestSimilarAfterDelete(t *testing.T) {
+	x, phone := testIndex(t)
+	if err := x.Send(context.Background(), []dto.ProductEvent{{Type: models.ChangeDeleted, ProductID: 2}}); err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range x.Similar(phone, 10) {
+		if r.Product.ID == 2 {
+			t.Errorf("deleted product 2 is still suggested")
+		}
+	}
+}
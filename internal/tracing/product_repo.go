@@ -0,0 +1,101 @@
+package tracing
+
+import (
+	"context"
+	"item-comparison-api/internal/models"
+	"item-comparison-api/internal/repository"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracedRepo decorates a ProductRepo with a span per call
+type tracedRepo struct {
+	next repository.ProductRepo
+}
+
+// TraceRepo wraps the given repository so every call is traced
+func TraceRepo(next repository.ProductRepo) repository.ProductRepo {
+	return &tracedRepo{next: next}
+}
+
+func (r *tracedRepo) LoadProducts(ctx context.Context) ([]models.Product, error) {
+	ctx, span := tracer.Start(ctx, "ProductRepo.LoadProducts")
+	defer span.End()
+
+	products, err := r.next.LoadProducts(ctx)
+	span.SetAttributes(attribute.Int("product.count", len(products)))
+	recordError(span, err)
+	return products, err
+}
+
+func (r *tracedRepo) SaveProducts(ctx context.Context, products []models.Product) error {
+	ctx, span := tracer.Start(ctx, "ProductRepo.SaveProducts",
+		trace.WithAttributes(attribute.IntSlice("product.ids", productIDs(products))))
+	defer span.End()
+
+	err := r.next.SaveProducts(ctx, products)
+	recordError(span, err)
+	return err
+}
+
+func (r *tracedRepo) UpdateProducts(ctx context.Context, products []models.Product) error {
+	ctx, span := tracer.Start(ctx, "ProductRepo.UpdateProducts",
+		trace.WithAttributes(attribute.IntSlice("product.ids", productIDs(products))))
+	defer span.End()
+
+	err := r.next.UpdateProducts(ctx, products)
+	recordError(span, err)
+	return err
+}
+
+func (r *tracedRepo) CompareProducts(ctx context.Context, ids []int) ([]models.Product, error) {
+	ctx, span := tracer.Start(ctx, "ProductRepo.CompareProducts",
+		trace.WithAttributes(attribute.IntSlice("product.ids", ids)))
+	defer span.End()
+
+	products, err := r.next.CompareProducts(ctx, ids)
+	recordError(span, err)
+	return products, err
+}
+
+func (r *tracedRepo) GetProductByID(ctx context.Context, id int) (*models.Product, error) {
+	ctx, span := tracer.Start(ctx, "ProductRepo.GetProductByID",
+		trace.WithAttributes(attribute.Int("product.id", id)))
+	defer span.End()
+
+	product, err := r.next.GetProductByID(ctx, id)
+	recordError(span, err)
+	return product, err
+}
+
+func (r *tracedRepo) GetProductByGTIN(ctx context.Context, code string) (*models.Product, error) {
+	ctx, span := tracer.Start(ctx, "ProductRepo.GetProductByGTIN",
+		trace.WithAttributes(attribute.String("product.gtin", code)))
+	defer span.End()
+
+	product, err := r.next.GetProductByGTIN(ctx, code)
+	recordError(span, err)
+	return product, err
+}
+
+func (r *tracedRepo) DeleteByID(ctx context.Context, id int, version int) error {
+	ctx, span := tracer.Start(ctx, "ProductRepo.DeleteByID", trace.WithAttributes(
+		attribute.Int("product.id", id),
+		attribute.Int("product.version", version),
+	))
+	defer span.End()
+
+	err := r.next.DeleteByID(ctx, id, version)
+	recordError(span, err)
+	return err
+}
+
+// productIDs collects the IDs of the given products for span attributes
+func productIDs(products []models.Product) []int {
+	ids := make([]int, 0, len(products))
+	for _, p := range products {
+		ids = append(ids, p.ID)
+	}
+	return ids
+}
@@ -0,0 +1,165 @@
+package tracing
+
+import (
+	"context"
+	"item-comparison-api/internal/dto"
+	"item-comparison-api/internal/services"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracedService decorates a ProductServiceInterface with a span per call
+type tracedService struct {
+	next services.ProductServiceInterface
+}
+
+// TraceService wraps the given service so every call is traced
+func TraceService(next services.ProductServiceInterface) services.ProductServiceInterface {
+	return &tracedService{next: next}
+}
+
+func (s *tracedService) LoadProducts(ctx context.Context) ([]dto.ProductResponse, error) {
+	ctx, span := tracer.Start(ctx, "ProductService.LoadProducts")
+	defer span.End()
+
+	products, err := s.next.LoadProducts(ctx)
+	span.SetAttributes(attribute.Int("product.count", len(products)))
+	recordError(span, err)
+	return products, err
+}
+
+func (s *tracedService) SaveProducts(ctx context.Context, req []dto.ProductRequest, sellerID string) error {
+	ctx, span := tracer.Start(ctx, "ProductService.SaveProducts", trace.WithAttributes(
+		attribute.IntSlice("product.ids", requestIDs(req)),
+		attribute.String("seller.id", sellerID),
+	))
+	defer span.End()
+
+	err := s.next.SaveProducts(ctx, req, sellerID)
+	recordError(span, err)
+	return err
+}
+
+func (s *tracedService) UpdateProducts(ctx context.Context, req []dto.ProductRequest, sellerID string) error {
+	ctx, span := tracer.Start(ctx, "ProductService.UpdateProducts", trace.WithAttributes(
+		attribute.IntSlice("product.ids", requestIDs(req)),
+		attribute.String("seller.id", sellerID),
+	))
+	defer span.End()
+
+	err := s.next.UpdateProducts(ctx, req, sellerID)
+	recordError(span, err)
+	return err
+}
+
+func (s *tracedService) SaveProductsBulk(ctx context.Context, req []dto.ProductRequest, sellerID string) []services.ItemResult {
+	ctx, span := tracer.Start(ctx, "ProductService.SaveProductsBulk", trace.WithAttributes(
+		attribute.IntSlice("product.ids", requestIDs(req)),
+		attribute.String("seller.id", sellerID),
+	))
+	defer span.End()
+
+	results := s.next.SaveProductsBulk(ctx, req, sellerID)
+	recordItemResults(span, results)
+	return results
+}
+
+func (s *tracedService) UpdateProductsBulk(ctx context.Context, req []dto.ProductRequest, sellerID string) []services.ItemResult {
+	ctx, span := tracer.Start(ctx, "ProductService.UpdateProductsBulk", trace.WithAttributes(
+		attribute.IntSlice("product.ids", requestIDs(req)),
+		attribute.String("seller.id", sellerID),
+	))
+	defer span.End()
+
+	results := s.next.UpdateProductsBulk(ctx, req, sellerID)
+	recordItemResults(span, results)
+	return results
+}
+
+func (s *tracedService) CompareProducts(ctx context.Context, ids []int) ([]dto.ProductResponse, error) {
+	ctx, span := tracer.Start(ctx, "ProductService.CompareProducts",
+		trace.WithAttributes(attribute.IntSlice("product.ids", ids)))
+	defer span.End()
+
+	products, err := s.next.CompareProducts(ctx, ids)
+	recordError(span, err)
+	return products, err
+}
+
+func (s *tracedService) GetProductByID(ctx context.Context, id int) (*dto.ProductResponse, error) {
+	ctx, span := tracer.Start(ctx, "ProductService.GetProductByID",
+		trace.WithAttributes(attribute.Int("product.id", id)))
+	defer span.End()
+
+	product, err := s.next.GetProductByID(ctx, id)
+	recordError(span, err)
+	return product, err
+}
+
+func (s *tracedService) GetProductByGTIN(ctx context.Context, code string) (*dto.ProductResponse, error) {
+	ctx, span := tracer.Start(ctx, "ProductService.GetProductByGTIN",
+		trace.WithAttributes(attribute.String("product.gtin", code)))
+	defer span.End()
+
+	product, err := s.next.GetProductByGTIN(ctx, code)
+	recordError(span, err)
+	return product, err
+}
+
+func (s *tracedService) DeleteProductByID(ctx context.Context, id int, sellerID string, version int) error {
+	ctx, span := tracer.Start(ctx, "ProductService.DeleteProductByID", trace.WithAttributes(
+		attribute.Int("product.id", id),
+		attribute.String("seller.id", sellerID),
+		attribute.Int("product.version", version),
+	))
+	defer span.End()
+
+	err := s.next.DeleteProductByID(ctx, id, sellerID, version)
+	recordError(span, err)
+	return err
+}
+
+func (s *tracedService) PatchProduct(ctx context.Context, id int, sellerID string, patchType string, patch []byte, version int) (*dto.ProductResponse, error) {
+	ctx, span := tracer.Start(ctx, "ProductService.PatchProduct", trace.WithAttributes(
+		attribute.Int("product.id", id),
+		attribute.String("seller.id", sellerID),
+		attribute.String("patch.type", patchType),
+		attribute.Int("product.version", version),
+	))
+	defer span.End()
+
+	product, err := s.next.PatchProduct(ctx, id, sellerID, patchType, patch, version)
+	recordError(span, err)
+	return product, err
+}
+
+// requestIDs collects the IDs of the given requests for span attributes
+func requestIDs(req []dto.ProductRequest) []int {
+	ids := make([]int, 0, len(req))
+	for _, r := range req {
+		ids = append(ids, r.ID)
+	}
+	return ids
+}
+
+// recordItemResults adds the number of failed items of a bulk operation
+func recordItemResults(span trace.Span, results []services.ItemResult) {
+	failed := 0
+	for _, r := range results {
+		if r.Err != nil {
+			failed++
+		}
+	}
+	span.SetAttributes(attribute.Int("bulk.failed", failed), attribute.Int("bulk.total", len(results)))
+}
+
+// recordError marks the span as failed when err is not nil
+func recordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
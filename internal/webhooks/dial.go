@@ -0,0 +1,70 @@
+package webhooks
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrBlockedAddress is returned when a webhook URL resolves to an address
+// deliveries may not be sent to
+var ErrBlockedAddress = errors.New("webhook target address is not allowed")
+
+// NewTransport returns the transport deliveries are sent with. Unless
+// allowPrivate is set, connections to loopback, private, link-local (which
+// includes the 169.254.169.254 metadata endpoint) and unspecified addresses
+// are refused. The check runs on the address actually dialed, after DNS
+// resolution, so a public name pointing at an internal address is refused
+// too. Proxies from the environment are not used, as they would be dialed
+// instead of the target.
+func NewTransport(allowPrivate bool) *http.Transport {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	if !allowPrivate {
+		dialer.Control = checkAddress
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return transport
+}
+
+// CheckHost rejects URL hosts that are a blocked address or the name
+// localhost, so such targets fail when they are configured. Other names can
+// only be checked when connecting.
+func CheckHost(host string) error {
+	name := strings.TrimSuffix(strings.ToLower(host), ".")
+	if name == "localhost" || strings.HasSuffix(name, ".localhost") {
+		return fmt.Errorf("%w: %s is a loopback address", ErrBlockedAddress, host)
+	}
+	if ip, err := netip.ParseAddr(strings.Trim(host, "[]")); err == nil && blocked(ip) {
+		return fmt.Errorf("%w: %s is a loopback, private or link-local address", ErrBlockedAddress, host)
+	}
+	return nil
+}
+
+// checkAddress is a net.Dialer Control hook refusing blocked addresses
+func checkAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrBlockedAddress, address)
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrBlockedAddress, address)
+	}
+	if blocked(ip) {
+		return fmt.Errorf("%w: %s is a loopback, private or link-local address", ErrBlockedAddress, ip.Unmap())
+	}
+	return nil
+}
+
+// blocked reports whether deliveries may not reach the address
+func blocked(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsUnspecified()
+}
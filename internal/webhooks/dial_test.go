@@ -0,0 +1,113 @@
+package webhooks
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestBlocked(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"127.0.0.1", true},
+		{"127.1.2.3", true},
+		{"::1", true},
+		{"10.0.0.1", true},
+		{"172.16.5.4", true},
+		{"192.168.1.1", true},
+		{"fd00::1", true},
+		{"169.254.169.254", true},
+		{"fe80::1", true},
+		{"224.0.0.251", true},
+		{"ff02::1", true},
+		{"0.0.0.0", true},
+		{"::", true},
+		{"::ffff:127.0.0.1", true},
+		{"::ffff:10.0.0.1", true},
+		{"8.8.8.8", false},
+		{"172.32.0.1", false},
+		{"2606:4700::1111", false},
+		{"::ffff:8.8.8.8", false},
+	}
+
+	for _, tt := range tests {
+		if got := blocked(netip.MustParseAddr(tt.addr)); got != tt.want {
+			t.Errorf("blocked(%s) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestCheckAddress(t *testing.T) {
+	tests := []struct {
+		address string
+		wantErr bool
+	}{
+		{"8.8.8.8:443", false},
+		{"[2606:4700::1111]:443", false},
+		{"127.0.0.1:80", true},
+		{"[::1]:80", true},
+		{"[::ffff:169.254.169.254]:80", true},
+		{"10.1.2.3:8080", true},
+		{"example.com:443", true},
+		{"8.8.8.8", true},
+	}
+
+	for _, tt := range tests {
+		err := checkAddress("tcp", tt.address, nil)
+		if tt.wantErr && !errors.Is(err, ErrBlockedAddress) {
+			t.Errorf("checkAddress(%s) = %v, want %v", tt.address, err, ErrBlockedAddress)
+		}
+		if !tt.wantErr && err != nil {
+			t.Errorf("checkAddress(%s) = %v", tt.address, err)
+		}
+	}
+}
+
+func TestCheckHost(t *testing.T) {
+	tests := []struct {
+		host    string
+		wantErr bool
+	}{
+		{"example.com", false},
+		{"8.8.8.8", false},
+		{"localhost.example.com", false},
+		{"localhost", true},
+		{"LOCALHOST.", true},
+		{"api.localhost", true},
+		{"127.0.0.1", true},
+		{"[::1]", true},
+		{"192.168.0.10", true},
+		{"169.254.169.254", true},
+	}
+
+	for _, tt := range tests {
+		err := CheckHost(tt.host)
+		if tt.wantErr && !errors.Is(err, ErrBlockedAddress) {
+			t.Errorf("CheckHost(%s) = %v, want %v", tt.host, err, ErrBlockedAddress)
+		}
+		if !tt.wantErr && err != nil {
+			t.Errorf("CheckHost(%s) = %v", tt.host, err)
+		}
+	}
+}
+
+// TestNewTransport connects to a receiver on the loopback interface
+func TestNewTransport(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer receiver.Close()
+
+	for _, allowPrivate := range []bool{false, true} {
+		client := &http.Client{Transport: NewTransport(allowPrivate)}
+		resp, err := client.Get(receiver.URL)
+		if err == nil {
+			resp.Body.Close()
+		}
+		if blocked := errors.Is(err, ErrBlockedAddress); blocked == allowPrivate {
+			t.Errorf("allowPrivate %v: error = %v", allowPrivate, err)
+		}
+	}
+}
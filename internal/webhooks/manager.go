@@ -0,0 +1,722 @@
+// Package webhooks notifies partner endpoints of product changes. Every
+// matching event becomes a persisted delivery that is POSTed with an HMAC
+// signature and retried with exponential backoff until it succeeds or runs
+// out of attempts and becomes a dead letter.
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"item-comparison-api/internal/dto"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// maxResponseBytes is how much of a receiver's response body is read, so
+// connections can be reused without reading huge bodies
+const maxResponseBytes = 64 << 10
+
+// eventBuffer is how many published events wait for their deliveries to be
+// saved before Notify blocks
+const eventBuffer = 1024
+
+// Options tune delivery. A delivery is attempted at most MaxAttempts times,
+// waiting BackoffBase after the first failure and doubling up to BackoffMax.
+// HistorySize bounds the succeeded deliveries kept per webhook.
+// AllowPrivateTargets lets deliveries reach loopback, private and link-local
+// addresses, for testing against local receivers.
+type Options struct {
+	Workers     int
+	Timeout     time.Duration
+	MaxAttempts int
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+	HistorySize int
+
+	AllowPrivateTargets bool
+}
+
+// Manager stores webhooks and their deliveries and sends the deliveries in
+// the background. Pending deliveries survive restarts.
+type Manager struct {
+	dir    string
+	opts   Options
+	client *http.Client
+
+	mu         sync.Mutex
+	webhooks   map[string]*Webhook
+	deliveries map[string]*Delivery
+	inFlight   map[string]bool
+	queue      chan string
+	wake       chan struct{}
+	wg         sync.WaitGroup
+
+	// events holds published events until their deliveries are saved.
+	// eventsMu guards closing it against Notify.
+	eventsMu sync.RWMutex
+	events   chan dto.ProductEvent
+	closed   bool
+	saved    sync.WaitGroup
+}
+
+// NewManager creates a Manager keeping its state in dir and loads the
+// webhooks and deliveries saved there
+func NewManager(dir string, opts Options) (*Manager, error) {
+	fmt.Printf("[WebhookManager][NewManager] Initializing with directory: %s\n", dir)
+	for _, sub := range []string{webhooksDir, deliveriesDir} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create webhook directory: %w", err)
+		}
+	}
+
+	hooks, err := loadFiles[Webhook](filepath.Join(dir, webhooksDir))
+	if err != nil {
+		return nil, err
+	}
+	deliveries, err := loadFiles[Delivery](filepath.Join(dir, deliveriesDir))
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Manager{
+		dir:  dir,
+		opts: opts,
+		client: &http.Client{
+			Transport: NewTransport(opts.AllowPrivateTargets),
+			// Receivers must answer themselves, a redirect counts as a failure
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		webhooks:   make(map[string]*Webhook, len(hooks)),
+		deliveries: make(map[string]*Delivery, len(deliveries)),
+		inFlight:   make(map[string]bool),
+		queue:      make(chan string),
+		wake:       make(chan struct{}, 1),
+		events:     make(chan dto.ProductEvent, eventBuffer),
+	}
+	for _, w := range hooks {
+		m.webhooks[w.ID] = w
+	}
+	pending := 0
+	for _, d := range deliveries {
+		// Payloads are saved indented with the rest of the file, send them compact as published
+		var payload bytes.Buffer
+		if err := json.Compact(&payload, d.Payload); err == nil {
+			d.Payload = payload.Bytes()
+		}
+		m.deliveries[d.ID] = d
+		if d.Status == StatusPending {
+			pending++
+		}
+	}
+	fmt.Printf("[WebhookManager][NewManager] Loaded %d webhooks, %d deliveries, %d pending\n", len(hooks), len(deliveries), pending)
+	return m, nil
+}
+
+// Start launches the scheduler and the delivery workers. They stop once
+// ctx is canceled; attempts in progress finish first. Deliveries of
+// published events are saved until Close, as events keep arriving while the
+// remaining changes are relayed.
+func (m *Manager) Start(ctx context.Context) {
+	m.saved.Add(1)
+	go func() {
+		defer m.saved.Done()
+		for event := range m.events {
+			m.save(event)
+		}
+	}()
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		m.schedule(ctx)
+	}()
+	for i := 0; i < m.opts.Workers; i++ {
+		m.wg.Add(1)
+		go func() {
+			defer m.wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case id := <-m.queue:
+					m.attempt(id)
+				}
+			}
+		}()
+	}
+}
+
+// Close stops accepting events once no more are published and saves the
+// deliveries of the queued ones
+func (m *Manager) Close() {
+	m.eventsMu.Lock()
+	if !m.closed {
+		m.closed = true
+		close(m.events)
+	}
+	m.eventsMu.Unlock()
+	m.saved.Wait()
+}
+
+// Wait blocks until the scheduler and every worker have stopped
+func (m *Manager) Wait() {
+	m.wg.Wait()
+}
+
+// Notify queues the event for delivery. It is called by the broker while
+// publishing, so the deliveries are saved by the goroutine Start launches;
+// Notify only blocks when eventBuffer events are waiting.
+func (m *Manager) Notify(event dto.ProductEvent) {
+	m.eventsMu.RLock()
+	defer m.eventsMu.RUnlock()
+	if m.closed {
+		fmt.Printf("[WebhookManager][Notify][ERROR] Event %s published after close\n", event.ID)
+		return
+	}
+	m.events <- event
+}
+
+// save stores a delivery of the event for every active webhook that wants it
+func (m *Manager) save(event dto.ProductEvent) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		fmt.Printf("[WebhookManager][save][ERROR] %v\n", err)
+		return
+	}
+
+	m.mu.Lock()
+	var targets []string
+	for _, w := range m.webhooks {
+		if w.matches(event) {
+			targets = append(targets, w.ID)
+		}
+	}
+	m.mu.Unlock()
+
+	for _, webhookID := range targets {
+		if _, err := m.enqueue(webhookID, event.ID, event.Type, payload); err != nil {
+			fmt.Printf("[WebhookManager][save][ERROR] %v\n", err)
+		}
+	}
+}
+
+// Create validates and stores a new webhook for the seller. A secret is
+// generated when none is given.
+func (m *Manager) Create(sellerID string, w Webhook) (*Webhook, error) {
+	fmt.Printf("[WebhookManager][Create] Called with sellerID: %s, url: %s\n", sellerID, w.URL)
+	if err := m.validate(&w); err != nil {
+		return nil, err
+	}
+
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+	if w.Secret == "" {
+		if w.Secret, err = newSecret(); err != nil {
+			return nil, err
+		}
+	}
+	now := time.Now().Format(time.RFC3339)
+	w.ID = id
+	w.SellerID = sellerID
+	w.CreatedAt = now
+	w.UpdatedAt = now
+	if err := saveFile(filepath.Join(m.dir, webhooksDir), w.ID, &w); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	m.webhooks[id] = &w
+	snapshot := w.clone()
+	m.mu.Unlock()
+
+	fmt.Printf("[WebhookManager][Create] Created webhook %s\n", id)
+	return snapshot, nil
+}
+
+// Update replaces the URL, event types, filter and active flag of a webhook.
+// The secret is only replaced when a new one is given.
+func (m *Manager) Update(id, sellerID string, w Webhook) (*Webhook, error) {
+	fmt.Printf("[WebhookManager][Update] Called with ID: %s, sellerID: %s\n", id, sellerID)
+	if err := m.validate(&w); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	existing, err := m.owned(id, sellerID)
+	if err != nil {
+		m.mu.Unlock()
+		return nil, err
+	}
+	existing.URL = w.URL
+	existing.EventTypes = w.EventTypes
+	existing.Filter = w.Filter
+	existing.Active = w.Active
+	if w.Secret != "" {
+		existing.Secret = w.Secret
+	}
+	existing.UpdatedAt = time.Now().Format(time.RFC3339)
+	snapshot := existing.clone()
+	m.mu.Unlock()
+
+	if err := saveFile(filepath.Join(m.dir, webhooksDir), id, snapshot); err != nil {
+		return nil, err
+	}
+	fmt.Printf("[WebhookManager][Update] Updated webhook %s\n", id)
+	return snapshot, nil
+}
+
+// Delete removes a webhook together with its deliveries
+func (m *Manager) Delete(id, sellerID string) error {
+	fmt.Printf("[WebhookManager][Delete] Called with ID: %s, sellerID: %s\n", id, sellerID)
+	m.mu.Lock()
+	if _, err := m.owned(id, sellerID); err != nil {
+		m.mu.Unlock()
+		return err
+	}
+	delete(m.webhooks, id)
+	var deliveries []string
+	for deliveryID, d := range m.deliveries {
+		if d.WebhookID == id {
+			deliveries = append(deliveries, deliveryID)
+			delete(m.deliveries, deliveryID)
+		}
+	}
+	m.mu.Unlock()
+
+	if err := removeFile(filepath.Join(m.dir, webhooksDir), id); err != nil {
+		return err
+	}
+	for _, deliveryID := range deliveries {
+		if err := removeFile(filepath.Join(m.dir, deliveriesDir), deliveryID); err != nil {
+			fmt.Printf("[WebhookManager][Delete][ERROR] %v\n", err)
+		}
+	}
+	fmt.Printf("[WebhookManager][Delete] Deleted webhook %s and %d deliveries\n", id, len(deliveries))
+	return nil
+}
+
+// Get returns a webhook owned by the seller
+func (m *Manager) Get(id, sellerID string) (*Webhook, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	w, err := m.owned(id, sellerID)
+	if err != nil {
+		return nil, err
+	}
+	return w.clone(), nil
+}
+
+// List returns the webhooks of the seller, oldest first
+func (m *Manager) List(sellerID string) []*Webhook {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	hooks := []*Webhook{}
+	for _, w := range m.webhooks {
+		if w.SellerID == sellerID {
+			hooks = append(hooks, w.clone())
+		}
+	}
+	sort.Slice(hooks, func(i, j int) bool {
+		if hooks[i].CreatedAt != hooks[j].CreatedAt {
+			return hooks[i].CreatedAt < hooks[j].CreatedAt
+		}
+		return hooks[i].ID < hooks[j].ID
+	})
+	return hooks
+}
+
+// Deliveries returns the delivery history of a webhook, newest first
+func (m *Manager) Deliveries(id, sellerID string) ([]*Delivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, err := m.owned(id, sellerID); err != nil {
+		return nil, err
+	}
+	return m.collect(func(d *Delivery) bool { return d.WebhookID == id }), nil
+}
+
+// Delivery returns one delivery of a webhook
+func (m *Manager) Delivery(id, deliveryID, sellerID string) (*Delivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	d, err := m.ownedDelivery(id, deliveryID, sellerID)
+	if err != nil {
+		return nil, err
+	}
+	return d.clone(), nil
+}
+
+// DeadLetters returns the dead deliveries of every webhook of the seller,
+// newest first
+func (m *Manager) DeadLetters(sellerID string) []*Delivery {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.collect(func(d *Delivery) bool {
+		w, ok := m.webhooks[d.WebhookID]
+		return ok && w.SellerID == sellerID && d.Status == StatusDead
+	})
+}
+
+// Redeliver sends a finished delivery again, with a fresh set of attempts
+func (m *Manager) Redeliver(id, deliveryID, sellerID string) (*Delivery, error) {
+	fmt.Printf("[WebhookManager][Redeliver] Called with webhook: %s, delivery: %s\n", id, deliveryID)
+	m.mu.Lock()
+	d, err := m.ownedDelivery(id, deliveryID, sellerID)
+	if err != nil {
+		m.mu.Unlock()
+		return nil, err
+	}
+	if d.Status == StatusPending {
+		m.mu.Unlock()
+		return nil, fmt.Errorf("%w: %s", ErrDeliveryPending, deliveryID)
+	}
+	now := time.Now().Format(time.RFC3339)
+	d.Status = StatusPending
+	d.Tries = 0
+	d.NextAttemptAt = now
+	d.UpdatedAt = now
+	snapshot := d.clone()
+	m.mu.Unlock()
+
+	if err := saveFile(filepath.Join(m.dir, deliveriesDir), deliveryID, snapshot); err != nil {
+		return nil, err
+	}
+	m.signal()
+	return snapshot, nil
+}
+
+// Ping queues a test delivery to a webhook, active or not
+func (m *Manager) Ping(id, sellerID string) (*Delivery, error) {
+	fmt.Printf("[WebhookManager][Ping] Called with ID: %s, sellerID: %s\n", id, sellerID)
+	m.mu.Lock()
+	_, err := m.owned(id, sellerID)
+	m.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := json.Marshal(map[string]string{
+		"type":        EventPing,
+		"webhook_id":  id,
+		"occurred_at": time.Now().Format(time.RFC3339),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return m.enqueue(id, "", EventPing, payload)
+}
+
+// enqueue stores a new pending delivery and wakes the scheduler
+func (m *Manager) enqueue(webhookID, eventID, eventType string, payload []byte) (*Delivery, error) {
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().Format(time.RFC3339)
+	d := &Delivery{
+		ID:            id,
+		WebhookID:     webhookID,
+		EventID:       eventID,
+		EventType:     eventType,
+		Payload:       payload,
+		Status:        StatusPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if err := saveFile(filepath.Join(m.dir, deliveriesDir), id, d); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	m.deliveries[id] = d
+	snapshot := d.clone()
+	m.mu.Unlock()
+	m.signal()
+	return snapshot, nil
+}
+
+// schedule hands due deliveries to the workers and sleeps until the next
+// one is due or a delivery is added
+func (m *Manager) schedule(ctx context.Context) {
+	for {
+		due, wait := m.due(time.Now())
+		for _, id := range due {
+			select {
+			case m.queue <- id:
+			case <-ctx.Done():
+				return
+			}
+		}
+		if len(due) > 0 {
+			continue
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-m.wake:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
+
+// due marks the pending deliveries whose next attempt has come as in flight
+// and returns them, with the time until the next one is due otherwise
+func (m *Manager) due(now time.Time) ([]string, time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	wait := time.Minute
+	var due []string
+	for id, d := range m.deliveries {
+		if d.Status != StatusPending || m.inFlight[id] {
+			continue
+		}
+		next, err := time.Parse(time.RFC3339, d.NextAttemptAt)
+		if err != nil || !next.After(now) {
+			m.inFlight[id] = true
+			due = append(due, id)
+			continue
+		}
+		wait = min(wait, next.Sub(now))
+	}
+	return due, wait
+}
+
+// attempt sends a delivery once and records the outcome
+func (m *Manager) attempt(id string) {
+	m.mu.Lock()
+	d, ok := m.deliveries[id]
+	var w *Webhook
+	if ok {
+		w, ok = m.webhooks[d.WebhookID]
+	}
+	if !ok {
+		// The webhook was deleted while the delivery was queued
+		delete(m.inFlight, id)
+		m.mu.Unlock()
+		return
+	}
+	target, secret, eventType, payload := w.URL, w.Secret, d.EventType, d.Payload
+	m.mu.Unlock()
+
+	// Attempts in progress finish during shutdown, bounded by the timeout
+	ctx, cancel := context.WithTimeout(context.Background(), m.opts.Timeout)
+	defer cancel()
+	start := time.Now()
+	statusCode, err := m.send(ctx, target, secret, id, eventType, payload)
+	attempt := Attempt{At: start.Format(time.RFC3339), StatusCode: statusCode, DurationMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		attempt.Error = err.Error()
+	}
+
+	m.mu.Lock()
+	d.addAttempt(attempt)
+	now := time.Now()
+	switch {
+	case err == nil:
+		d.Status = StatusSucceeded
+		d.NextAttemptAt = ""
+	case d.Tries >= m.opts.MaxAttempts:
+		d.Status = StatusDead
+		d.NextAttemptAt = ""
+	default:
+		d.NextAttemptAt = now.Add(m.backoff(d.Tries)).Format(time.RFC3339)
+	}
+	d.UpdatedAt = now.Format(time.RFC3339)
+	delete(m.inFlight, id)
+	snapshot := d.clone()
+	var pruned []string
+	if d.Status == StatusSucceeded {
+		pruned = m.prune(d.WebhookID)
+	}
+	m.mu.Unlock()
+
+	if err := saveFile(filepath.Join(m.dir, deliveriesDir), id, snapshot); err != nil {
+		fmt.Printf("[WebhookManager][attempt][ERROR] %v\n", err)
+	}
+	for _, prunedID := range pruned {
+		if err := removeFile(filepath.Join(m.dir, deliveriesDir), prunedID); err != nil {
+			fmt.Printf("[WebhookManager][attempt][ERROR] %v\n", err)
+		}
+	}
+
+	switch snapshot.Status {
+	case StatusSucceeded:
+		fmt.Printf("[WebhookManager][attempt] Delivered %s to webhook %s on try %d\n", id, snapshot.WebhookID, snapshot.Tries)
+	case StatusDead:
+		fmt.Printf("[WebhookManager][attempt][ERROR] Delivery %s to webhook %s is dead after %d tries: %v\n", id, snapshot.WebhookID, snapshot.Tries, err)
+	default:
+		fmt.Printf("[WebhookManager][attempt][ERROR] Delivery %s to webhook %s failed on try %d, retrying at %s: %v\n", id, snapshot.WebhookID, snapshot.Tries, snapshot.NextAttemptAt, err)
+	}
+	m.signal()
+}
+
+// send POSTs a signed payload. Only 2xx responses count as delivered.
+func (m *Manager) send(ctx context.Context, target, secret, deliveryID, eventType string, payload []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "item-comparison-api-webhooks/1.0")
+	req.Header.Set(HeaderDelivery, deliveryID)
+	req.Header.Set(HeaderEvent, eventType)
+	req.Header.Set(HeaderSignature, Sign(secret, time.Now(), payload))
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseBytes))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("receiver answered %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff returns the wait after the given number of failed tries
+func (m *Manager) backoff(tries int) time.Duration {
+	wait := m.opts.BackoffBase
+	for i := 1; i < tries && wait < m.opts.BackoffMax; i++ {
+		wait *= 2
+	}
+	return min(wait, m.opts.BackoffMax)
+}
+
+// prune forgets the oldest succeeded deliveries of a webhook beyond the
+// history size and returns their IDs. Dead letters are kept. Callers hold mu.
+func (m *Manager) prune(webhookID string) []string {
+	var succeeded []*Delivery
+	for _, d := range m.deliveries {
+		if d.WebhookID == webhookID && d.Status == StatusSucceeded {
+			succeeded = append(succeeded, d)
+		}
+	}
+	if len(succeeded) <= m.opts.HistorySize {
+		return nil
+	}
+
+	sortNewestFirst(succeeded)
+	var pruned []string
+	for _, d := range succeeded[m.opts.HistorySize:] {
+		delete(m.deliveries, d.ID)
+		pruned = append(pruned, d.ID)
+	}
+	return pruned
+}
+
+// collect returns copies of the matching deliveries, newest first. Callers hold mu.
+func (m *Manager) collect(match func(*Delivery) bool) []*Delivery {
+	deliveries := []*Delivery{}
+	for _, d := range m.deliveries {
+		if match(d) {
+			deliveries = append(deliveries, d.clone())
+		}
+	}
+	sortNewestFirst(deliveries)
+	return deliveries
+}
+
+// owned returns the webhook if the seller owns it. Callers hold mu.
+func (m *Manager) owned(id, sellerID string) (*Webhook, error) {
+	w, ok := m.webhooks[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrWebhookNotFound, id)
+	}
+	if w.SellerID != sellerID {
+		return nil, ErrForbidden
+	}
+	return w, nil
+}
+
+// ownedDelivery returns a delivery of a webhook the seller owns. Callers hold mu.
+func (m *Manager) ownedDelivery(id, deliveryID, sellerID string) (*Delivery, error) {
+	if _, err := m.owned(id, sellerID); err != nil {
+		return nil, err
+	}
+	d, ok := m.deliveries[deliveryID]
+	if !ok || d.WebhookID != id {
+		return nil, fmt.Errorf("%w: %s", ErrDeliveryNotFound, deliveryID)
+	}
+	return d, nil
+}
+
+// signal wakes up the scheduler
+func (m *Manager) signal() {
+	select {
+	case m.wake <- struct{}{}:
+	default:
+	}
+}
+
+// validate checks the URL, event types and filter of a webhook
+func (m *Manager) validate(w *Webhook) error {
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: url %q must be an absolute http or https URL", ErrInvalidWebhook, w.URL)
+	}
+	if !m.opts.AllowPrivateTargets {
+		if err := CheckHost(u.Hostname()); err != nil {
+			return fmt.Errorf("%w: url %q: %v", ErrInvalidWebhook, w.URL, err)
+		}
+	}
+	for _, t := range w.EventTypes {
+		if !slices.Contains([]string{dto.EventProductCreated, dto.EventProductUpdated, dto.EventProductDeleted}, t) {
+			return fmt.Errorf("%w: event type %q must be %s, %s or %s", ErrInvalidWebhook, t, dto.EventProductCreated, dto.EventProductUpdated, dto.EventProductDeleted)
+		}
+	}
+	for _, id := range w.Filter.ProductIDs {
+		if id <= 0 {
+			return fmt.Errorf("%w: product ID %d must be positive", ErrInvalidWebhook, id)
+		}
+	}
+	return nil
+}
+
+// sortNewestFirst orders deliveries by creation time, newest first
+func sortNewestFirst(deliveries []*Delivery) {
+	sort.Slice(deliveries, func(i, j int) bool {
+		if deliveries[i].CreatedAt != deliveries[j].CreatedAt {
+			return deliveries[i].CreatedAt > deliveries[j].CreatedAt
+		}
+		return deliveries[i].ID > deliveries[j].ID
+	})
+}
+
+// newID returns a random webhook or delivery ID
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate webhook ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// newSecret returns a random signing secret
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
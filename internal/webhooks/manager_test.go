@@ -0,0 +1,67 @@
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"item-comparison-api/internal/dto"
+)
+
+// TestNotifySavesDeliveriesInBackground checks that every event handed to
+// Notify becomes a delivery once the manager is closed, even with more
+// events than fit the buffer
+func TestNotifySavesDeliveriesInBackground(t *testing.T) {
+	dir := t.TempDir()
+	m, err := NewManager(dir, Options{Workers: 1, MaxAttempts: 3, BackoffBase: time.Second, BackoffMax: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Create("s1", Webhook{URL: "https://example.com/hook", Active: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the saving goroutine runs, the workers stop right away
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	m.Start(ctx)
+	events := eventBuffer + 10
+	for i := 0; i < events; i++ {
+		m.Notify(dto.ProductEvent{ID: fmt.Sprint(i + 1), Type: dto.EventProductCreated})
+	}
+	m.Close()
+	m.Wait()
+
+	// Events after Close are dropped instead of panicking
+	m.Notify(dto.ProductEvent{ID: "late", Type: dto.EventProductCreated})
+
+	reloaded, err := NewManager(dir, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(reloaded.deliveries); got != events {
+		t.Errorf("saved %d deliveries, want %d", got, events)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	m := &Manager{opts: Options{BackoffBase: time.Second, BackoffMax: 10 * time.Second}}
+	tests := []struct {
+		tries int
+		want  time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{4, 8 * time.Second},
+		{5, 10 * time.Second},
+		{50, 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := m.backoff(tt.tries); got != tt.want {
+			t.Errorf("backoff(%d) = %v, want %v", tt.tries, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package webhooks
+
+import "item-comparison-api/internal/dto"
+
+// RequestToWebhook converts a WebhookRequest DTO to a Webhook
+func RequestToWebhook(req dto.WebhookRequest) Webhook {
+	active := true
+	if req.Active != nil {
+		active = *req.Active
+	}
+	return Webhook{
+		URL:        req.URL,
+		EventTypes: req.EventTypes,
+		Filter: Filter{
+			ProductIDs: req.Filter.ProductIDs,
+			SellerID:   req.Filter.SellerID,
+			Category:   req.Filter.Category,
+		},
+		Secret: req.Secret,
+		Active: active,
+	}
+}
+
+// WebhookToResponse converts a Webhook to a WebhookResponse DTO, leaving
+// out the secret
+func WebhookToResponse(w *Webhook) dto.WebhookResponse {
+	eventTypes := w.EventTypes
+	if eventTypes == nil {
+		eventTypes = []string{}
+	}
+	return dto.WebhookResponse{
+		ID:         w.ID,
+		URL:        w.URL,
+		EventTypes: eventTypes,
+		Filter: dto.WebhookFilter{
+			ProductIDs: w.Filter.ProductIDs,
+			SellerID:   w.Filter.SellerID,
+			Category:   w.Filter.Category,
+		},
+		Active:    w.Active,
+		CreatedAt: w.CreatedAt,
+		UpdatedAt: w.UpdatedAt,
+	}
+}
+
+// DeliveryToResponse converts a Delivery to a WebhookDeliveryResponse DTO
+func DeliveryToResponse(d *Delivery) dto.WebhookDeliveryResponse {
+	attempts := make([]dto.WebhookAttempt, 0, len(d.Attempts))
+	for _, a := range d.Attempts {
+		attempts = append(attempts, dto.WebhookAttempt{At: a.At, StatusCode: a.StatusCode, Error: a.Error, DurationMs: a.DurationMs})
+	}
+	return dto.WebhookDeliveryResponse{
+		ID:            d.ID,
+		WebhookID:     d.WebhookID,
+		EventID:       d.EventID,
+		EventType:     d.EventType,
+		Payload:       d.Payload,
+		Status:        d.Status,
+		Tries:         d.Tries,
+		Attempts:      attempts,
+		NextAttemptAt: d.NextAttemptAt,
+		CreatedAt:     d.CreatedAt,
+		UpdatedAt:     d.UpdatedAt,
+	}
+}
+
+// DeliveriesToResponse converts Deliveries to WebhookDeliveryResponse DTOs
+func DeliveriesToResponse(deliveries []*Delivery) []dto.WebhookDeliveryResponse {
+	responses := make([]dto.WebhookDeliveryResponse, 0, len(deliveries))
+	for _, d := range deliveries {
+		responses = append(responses, DeliveryToResponse(d))
+	}
+	return responses
+}
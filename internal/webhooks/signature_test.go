@@ -0,0 +1,60 @@
+package webhooks
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSign(t *testing.T) {
+	got := Sign("secret", time.Unix(1700000000, 0), []byte(`{"id":"1"}`))
+	want := "t=1700000000,v1=086f6aff7bd084c98679825129c5a64dbad88c760016d6d2c0fb123f27951d54"
+	if got != want {
+		t.Errorf("Sign = %q, want %q", got, want)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"id":"1","type":"product.created"}`)
+	now := time.Now()
+	tests := []struct {
+		name    string
+		secret  string
+		header  string
+		body    []byte
+		wantErr string
+	}{
+		{"valid", "secret", Sign("secret", now, body), body, ""},
+		{"fields in any order", "secret", reorder(Sign("secret", now, body)), body, ""},
+		{"within tolerance", "secret", Sign("secret", now.Add(-4*time.Minute), body), body, ""},
+		{"wrong secret", "other", Sign("secret", now, body), body, "does not match"},
+		{"changed body", "secret", Sign("secret", now, body), []byte(`{"id":"2","type":"product.created"}`), "does not match"},
+		{"too old", "secret", Sign("secret", now.Add(-10*time.Minute), body), body, "off"},
+		{"in the future", "secret", Sign("secret", now.Add(10*time.Minute), body), body, "off"},
+		{"missing signature", "secret", "t=1700000000", body, "malformed"},
+		{"missing timestamp", "secret", "v1=abc", body, "malformed"},
+		{"invalid timestamp", "secret", "t=yesterday,v1=abc", body, "invalid signature timestamp"},
+		{"empty", "secret", "", body, "malformed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify(tt.secret, tt.header, tt.body, 5*time.Minute)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Verify error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Verify error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// reorder puts the signature before the timestamp
+func reorder(header string) string {
+	timestamp, sig, _ := strings.Cut(header, ",")
+	return sig + "," + timestamp
+}
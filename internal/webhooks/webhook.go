@@ -0,0 +1,126 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"errors"
+	"item-comparison-api/internal/dto"
+	"item-comparison-api/internal/events"
+)
+
+// Delivery statuses. Pending deliveries are retried until they succeed or
+// run out of attempts and become dead letters.
+const (
+	StatusPending   = "pending"
+	StatusSucceeded = "succeeded"
+	StatusDead      = "dead"
+)
+
+// EventPing is the type of test deliveries sent on request
+const EventPing = "webhook.ping"
+
+// maxAttemptsKept bounds the attempt history of a delivery across redeliveries
+const maxAttemptsKept = 50
+
+var (
+	// ErrWebhookNotFound is returned for unknown webhook IDs
+	ErrWebhookNotFound = errors.New("webhook not found")
+	// ErrDeliveryNotFound is returned for unknown delivery IDs
+	ErrDeliveryNotFound = errors.New("webhook delivery not found")
+	// ErrForbidden is returned when a seller accesses a webhook of another seller
+	ErrForbidden = errors.New("unauthorized: you do not own this webhook")
+	// ErrInvalidWebhook is returned for webhooks with an invalid URL, event type or filter
+	ErrInvalidWebhook = errors.New("invalid webhook")
+	// ErrDeliveryPending is returned when redelivering a delivery that is still being retried
+	ErrDeliveryPending = errors.New("webhook delivery is still pending")
+)
+
+// Webhook is the persisted subscription of a seller to product events.
+// Empty EventTypes subscribe to every type.
+type Webhook struct {
+	ID         string   `json:"id"`
+	SellerID   string   `json:"seller_id"`
+	URL        string   `json:"url"`
+	EventTypes []string `json:"event_types,omitempty"`
+	Filter     Filter   `json:"filter"`
+	Secret     string   `json:"secret"`
+	Active     bool     `json:"active"`
+	CreatedAt  string   `json:"created_at"`
+	UpdatedAt  string   `json:"updated_at"`
+}
+
+// Filter restricts the events a webhook receives, like the event stream filters
+type Filter struct {
+	ProductIDs []int  `json:"product_ids,omitempty"`
+	SellerID   string `json:"seller_id,omitempty"`
+	Category   string `json:"category,omitempty"`
+}
+
+// Delivery is one event sent to one webhook, with every attempt made.
+// Tries counts the attempts since the delivery was created or redelivered.
+type Delivery struct {
+	ID            string          `json:"id"`
+	WebhookID     string          `json:"webhook_id"`
+	EventID       string          `json:"event_id"`
+	EventType     string          `json:"event_type"`
+	Payload       json.RawMessage `json:"payload"`
+	Status        string          `json:"status"`
+	Tries         int             `json:"tries"`
+	Attempts      []Attempt       `json:"attempts,omitempty"`
+	NextAttemptAt string          `json:"next_attempt_at,omitempty"`
+	CreatedAt     string          `json:"created_at"`
+	UpdatedAt     string          `json:"updated_at"`
+}
+
+// Attempt records one HTTP request of a delivery. StatusCode is 0 when no
+// response was received.
+type Attempt struct {
+	At         string `json:"at"`
+	StatusCode int    `json:"status_code,omitempty"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// matches reports whether the webhook wants the event
+func (w *Webhook) matches(event dto.ProductEvent) bool {
+	if !w.Active {
+		return false
+	}
+	filter := events.Filter{SellerID: w.Filter.SellerID, Category: w.Filter.Category}
+	if len(w.EventTypes) > 0 {
+		filter.Types = make(map[string]bool, len(w.EventTypes))
+		for _, t := range w.EventTypes {
+			filter.Types[t] = true
+		}
+	}
+	if len(w.Filter.ProductIDs) > 0 {
+		filter.ProductIDs = make(map[int]bool, len(w.Filter.ProductIDs))
+		for _, id := range w.Filter.ProductIDs {
+			filter.ProductIDs[id] = true
+		}
+	}
+	return filter.Match(event)
+}
+
+// addAttempt records an attempt, keeping the most recent maxAttemptsKept
+func (d *Delivery) addAttempt(a Attempt) {
+	d.Tries++
+	d.Attempts = append(d.Attempts, a)
+	if len(d.Attempts) > maxAttemptsKept {
+		d.Attempts = d.Attempts[len(d.Attempts)-maxAttemptsKept:]
+	}
+}
+
+// clone returns a copy of the webhook that is safe to hand out
+func (w *Webhook) clone() *Webhook {
+	c := *w
+	c.EventTypes = append([]string(nil), w.EventTypes...)
+	c.Filter.ProductIDs = append([]int(nil), w.Filter.ProductIDs...)
+	return &c
+}
+
+// clone returns a copy of the delivery that is safe to hand out
+func (d *Delivery) clone() *Delivery {
+	c := *d
+	c.Attempts = append([]Attempt(nil), d.Attempts...)
+	return &c
+}